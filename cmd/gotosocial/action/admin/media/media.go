@@ -0,0 +1,89 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package media
+
+import (
+	"context"
+	"fmt"
+
+	"codeberg.org/gruf/go-store/kv"
+	gostorage "codeberg.org/gruf/go-store/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/db/bundb"
+	"github.com/superseriousbusiness/gotosocial/internal/media"
+	gtsstorage "github.com/superseriousbusiness/gotosocial/internal/storage"
+)
+
+// BackfillFileHashes sets the file hash of any media attachments stored before file hashes were recorded.
+var BackfillFileHashes action.GTSAction = func(ctx context.Context) error {
+	dbConn, mediaHandler, err := initMediaHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := mediaHandler.BackfillFileHashes(ctx)
+	if err != nil {
+		return fmt.Errorf("error backfilling file hashes: %s", err)
+	}
+	logrus.Infof("set file hashes of %d attachments", hashed)
+
+	return dbConn.Stop(ctx)
+}
+
+// BackfillBlurhashes sets the blurhash of any image attachments that don't have one,
+// or of all image attachments if force is set.
+var BackfillBlurhashes action.GTSAction = func(ctx context.Context) error {
+	dbConn, mediaHandler, err := initMediaHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	force := viper.GetBool(config.Keys.AdminMediaForce)
+	derived, err := mediaHandler.BackfillBlurhashes(ctx, force)
+	if err != nil {
+		return fmt.Errorf("error backfilling blurhashes: %s", err)
+	}
+	logrus.Infof("set blurhashes of %d attachments", derived)
+
+	return dbConn.Stop(ctx)
+}
+
+// initMediaHandler opens the database and storage backend, and returns a media handler using them.
+func initMediaHandler(ctx context.Context) (db.DB, media.Handler, error) {
+	dbConn, err := bundb.NewBunDBService(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating dbservice: %s", err)
+	}
+
+	diskStorage, err := gostorage.OpenFile(viper.GetString(config.Keys.StorageLocalBasePath), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating storage backend: %s", err)
+	}
+
+	storage, err := kv.OpenStorage(gtsstorage.NewLimited(diskStorage, int64(viper.GetInt(config.Keys.StorageMaxObjectSize))))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating storage backend: %s", err)
+	}
+
+	return dbConn, media.New(dbConn, storage), nil
+}
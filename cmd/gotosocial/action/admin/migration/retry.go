@@ -0,0 +1,39 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migration
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/db/bundb"
+)
+
+// Retry runs the pending database migration with the given name, on its own.
+var Retry action.GTSAction = func(ctx context.Context) error {
+	name := viper.GetString(config.Keys.AdminMigrationName)
+	if name == "" {
+		return errors.New("no migration name set")
+	}
+
+	return bundb.RetryMigration(ctx, name)
+}
@@ -27,6 +27,7 @@ import (
 	"syscall"
 
 	"codeberg.org/gruf/go-store/kv"
+	gostorage "codeberg.org/gruf/go-store/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action"
@@ -65,6 +66,7 @@ import (
 	"github.com/superseriousbusiness/gotosocial/internal/oidc"
 	"github.com/superseriousbusiness/gotosocial/internal/processing"
 	"github.com/superseriousbusiness/gotosocial/internal/router"
+	gtsstorage "github.com/superseriousbusiness/gotosocial/internal/storage"
 	timelineprocessing "github.com/superseriousbusiness/gotosocial/internal/timeline"
 	"github.com/superseriousbusiness/gotosocial/internal/transport"
 	"github.com/superseriousbusiness/gotosocial/internal/typeutils"
@@ -99,7 +101,14 @@ var Start action.GTSAction = func(ctx context.Context) error {
 
 	// Open the storage backend
 	storageBasePath := viper.GetString(config.Keys.StorageLocalBasePath)
-	storage, err := kv.OpenFile(storageBasePath, nil)
+	diskStorage, err := gostorage.OpenFile(storageBasePath, nil)
+	if err != nil {
+		return fmt.Errorf("error creating storage backend: %s", err)
+	}
+
+	// refuse to store anything unreasonably big
+	storageMaxObjectSize := int64(viper.GetInt(config.Keys.StorageMaxObjectSize))
+	storage, err := kv.OpenStorage(gtsstorage.NewLimited(diskStorage, storageMaxObjectSize))
 	if err != nil {
 		return fmt.Errorf("error creating storage backend: %s", err)
 	}
@@ -209,6 +218,17 @@ var Start action.GTSAction = func(ctx context.Context) error {
 		return fmt.Errorf("error starting gotosocial service: %s", err)
 	}
 
+	// SIGUSR1 toggles read-only mode, so that an instance can
+	// be kept browsable while doing maintenance without a restart
+	maintenance := make(chan os.Signal, 1)
+	signal.Notify(maintenance, syscall.SIGUSR1)
+	go func() {
+		for range maintenance {
+			dbService.SetReadOnly(!dbService.IsReadOnly())
+			logrus.Infof("received SIGUSR1, read-only mode is now %t", dbService.IsReadOnly())
+		}
+	}()
+
 	// catch shutdown signals from the operating system
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
@@ -21,6 +21,8 @@ package main
 import (
 	"github.com/spf13/cobra"
 	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action/admin/account"
+	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action/admin/media"
+	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action/admin/migration"
 	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action/admin/trans"
 	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/flag"
 	"github.com/superseriousbusiness/gotosocial/internal/config"
@@ -165,5 +167,65 @@ func adminCommands() *cobra.Command {
 	flag.AdminTrans(adminImportCmd, config.Defaults)
 	adminCmd.AddCommand(adminImportCmd)
 
+	/*
+	   ADMIN MIGRATION COMMANDS
+	*/
+
+	adminMigrationCmd := &cobra.Command{
+		Use:   "migration",
+		Short: "admin commands related to database migrations",
+	}
+
+	adminMigrationRetryCmd := &cobra.Command{
+		Use:   "retry",
+		Short: "run a single pending database migration that failed on startup",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), migration.Retry)
+		},
+	}
+	flag.AdminMigration(adminMigrationRetryCmd, config.Defaults)
+	adminMigrationCmd.AddCommand(adminMigrationRetryCmd)
+
+	adminCmd.AddCommand(adminMigrationCmd)
+
+	/*
+	   ADMIN MEDIA COMMANDS
+	*/
+
+	adminMediaCmd := &cobra.Command{
+		Use:   "media",
+		Short: "admin commands related to stored media",
+	}
+
+	adminMediaBackfillHashesCmd := &cobra.Command{
+		Use:   "backfill-hashes",
+		Short: "set the file hash of media attachments stored before file hashes were recorded",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), media.BackfillFileHashes)
+		},
+	}
+	adminMediaCmd.AddCommand(adminMediaBackfillHashesCmd)
+
+	adminMediaBackfillBlurhashesCmd := &cobra.Command{
+		Use:   "backfill-blurhashes",
+		Short: "work out the blurhash of image attachments that don't have one",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), media.BackfillBlurhashes)
+		},
+	}
+	flag.AdminMediaBlurhashes(adminMediaBackfillBlurhashesCmd, config.Defaults)
+	adminMediaCmd.AddCommand(adminMediaBackfillBlurhashesCmd)
+
+	adminCmd.AddCommand(adminMediaCmd)
+
 	return adminCmd
 }
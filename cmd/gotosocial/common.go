@@ -22,7 +22,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action"
 	"github.com/superseriousbusiness/gotosocial/internal/config"
 	"github.com/superseriousbusiness/gotosocial/internal/log"
@@ -55,5 +57,9 @@ func run(ctx context.Context, action action.GTSAction) error {
 		return fmt.Errorf("error initializing log: %s", err)
 	}
 
+	if viper.GetBool(config.Keys.DumpConfig) {
+		logrus.WithFields(logrus.Fields(config.Dump())).Info("effective configuration")
+	}
+
 	return action(ctx)
 }
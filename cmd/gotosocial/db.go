@@ -0,0 +1,48 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+	dbaction "github.com/superseriousbusiness/gotosocial/cmd/gotosocial/action/db"
+	"github.com/superseriousbusiness/gotosocial/cmd/gotosocial/flag"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+func dbCommands() *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "gotosocial database-related tasks",
+	}
+
+	dbBackupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "write a consistent snapshot of the sqlite database to a new file; safe to run while gotosocial is running",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return preRun(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), dbaction.Backup)
+		},
+	}
+	flag.DbBackup(dbBackupCmd, config.Defaults)
+
+	dbCmd.AddCommand(dbBackupCmd)
+	return dbCmd
+}
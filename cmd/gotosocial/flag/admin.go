@@ -60,3 +60,16 @@ func AdminTrans(cmd *cobra.Command, values config.Values) {
 		panic(err)
 	}
 }
+
+// AdminMigration attaches flags pertaining to database migration commands.
+func AdminMigration(cmd *cobra.Command, values config.Values) {
+	cmd.Flags().String(config.Keys.AdminMigrationName, "", usage.AdminMigrationName) // REQUIRED
+	if err := cmd.MarkFlagRequired(config.Keys.AdminMigrationName); err != nil {
+		panic(err)
+	}
+}
+
+// AdminMediaBlurhashes attaches flags pertaining to the blurhash backfill command.
+func AdminMediaBlurhashes(cmd *cobra.Command, values config.Values) {
+	cmd.Flags().Bool(config.Keys.AdminMediaForce, false, usage.AdminMediaForce)
+}
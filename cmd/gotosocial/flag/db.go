@@ -0,0 +1,32 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package flag
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+// DbBackup attaches flags pertaining to database backups.
+func DbBackup(cmd *cobra.Command, values config.Values) {
+	cmd.Flags().String(config.Keys.DbBackupOut, "", usage.DbBackupOut) // REQUIRED
+	if err := cmd.MarkFlagRequired(config.Keys.DbBackupOut); err != nil {
+		panic(err)
+	}
+}
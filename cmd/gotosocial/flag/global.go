@@ -32,6 +32,7 @@ func Global(cmd *cobra.Command, values config.Values) {
 	cmd.PersistentFlags().String(config.Keys.Protocol, values.Protocol, usage.Protocol)
 	cmd.PersistentFlags().String(config.Keys.LogLevel, values.LogLevel, usage.LogLevel)
 	cmd.PersistentFlags().String(config.Keys.ConfigPath, values.ConfigPath, usage.ConfigPath)
+	cmd.PersistentFlags().Bool(config.Keys.DumpConfig, values.DumpConfig, usage.DumpConfig)
 
 	// database stuff
 	cmd.PersistentFlags().String(config.Keys.DbType, values.DbType, usage.DbType)
@@ -42,4 +43,28 @@ func Global(cmd *cobra.Command, values config.Values) {
 	cmd.PersistentFlags().String(config.Keys.DbDatabase, values.DbDatabase, usage.DbDatabase)
 	cmd.PersistentFlags().String(config.Keys.DbTLSMode, values.DbTLSMode, usage.DbTLSMode)
 	cmd.PersistentFlags().String(config.Keys.DbTLSCACert, values.DbTLSCACert, usage.DbTLSCACert)
+	cmd.PersistentFlags().Bool(config.Keys.DbMigrationAnalyze, values.DbMigrationAnalyze, usage.DbMigrationAnalyze)
+	cmd.PersistentFlags().Bool(config.Keys.DbReadOnly, values.DbReadOnly, usage.DbReadOnly)
+	cmd.PersistentFlags().Bool(config.Keys.DbOpenReadOnly, values.DbOpenReadOnly, usage.DbOpenReadOnly)
+	cmd.PersistentFlags().Duration(config.Keys.DbMigrationTimeout, values.DbMigrationTimeout, usage.DbMigrationTimeout)
+	cmd.PersistentFlags().Bool(config.Keys.DbMigrateOnStart, values.DbMigrateOnStart, usage.DbMigrateOnStart)
+	cmd.PersistentFlags().Bool(config.Keys.DbMigrationVerifyChecksums, values.DbMigrationVerifyChecksums, usage.DbMigrationVerifyChecksums)
+	cmd.PersistentFlags().Bool(config.Keys.DbMigrationSkipIncompatible, values.DbMigrationSkipIncompatible, usage.DbMigrationSkipIncompatible)
+	cmd.PersistentFlags().Bool(config.Keys.DbAllowNoPassword, values.DbAllowNoPassword, usage.DbAllowNoPassword)
+	cmd.PersistentFlags().Duration(config.Keys.DbSqliteBusyTimeout, values.DbSqliteBusyTimeout, usage.DbSqliteBusyTimeout)
+	cmd.PersistentFlags().String(config.Keys.DbSqliteCache, values.DbSqliteCache, usage.DbSqliteCache)
+	cmd.PersistentFlags().Bool(config.Keys.DbSqliteForeignKeys, values.DbSqliteForeignKeys, usage.DbSqliteForeignKeys)
+	cmd.PersistentFlags().String(config.Keys.DbTimezone, values.DbTimezone, usage.DbTimezone)
+	cmd.PersistentFlags().String(config.Keys.DbPostgresFlavor, values.DbPostgresFlavor, usage.DbPostgresFlavor)
+	cmd.PersistentFlags().String(config.Keys.DbPostgresSchema, values.DbPostgresSchema, usage.DbPostgresSchema)
+	cmd.PersistentFlags().Duration(config.Keys.DbPoolSampleInterval, values.DbPoolSampleInterval, usage.DbPoolSampleInterval)
+	cmd.PersistentFlags().Duration(config.Keys.DbPoolWaitThreshold, values.DbPoolWaitThreshold, usage.DbPoolWaitThreshold)
+	cmd.PersistentFlags().Float64(config.Keys.DbQueryLogSampleRate, values.DbQueryLogSampleRate, usage.DbQueryLogSampleRate)
+	cmd.PersistentFlags().Duration(config.Keys.DbKeepaliveInterval, values.DbKeepaliveInterval, usage.DbKeepaliveInterval)
+	cmd.PersistentFlags().Duration(config.Keys.DbDialTimeout, values.DbDialTimeout, usage.DbDialTimeout)
+	cmd.PersistentFlags().Bool(config.Keys.DbWarmup, values.DbWarmup, usage.DbWarmup)
+	cmd.PersistentFlags().Bool(config.Keys.DbSqlCommenterEnabled, values.DbSqlCommenterEnabled, usage.DbSqlCommenterEnabled)
+	cmd.PersistentFlags().Bool(config.Keys.DbLogConnections, values.DbLogConnections, usage.DbLogConnections)
+	cmd.PersistentFlags().String(config.Keys.DbSqliteBackupPath, values.DbSqliteBackupPath, usage.DbSqliteBackupPath)
+	cmd.PersistentFlags().Duration(config.Keys.DbSqliteBackupInterval, values.DbSqliteBackupInterval, usage.DbSqliteBackupInterval)
 }
@@ -69,6 +69,7 @@ func Media(cmd *cobra.Command, values config.Values) {
 func Storage(cmd *cobra.Command, values config.Values) {
 	cmd.Flags().String(config.Keys.StorageBackend, values.StorageBackend, usage.StorageBackend)
 	cmd.Flags().String(config.Keys.StorageLocalBasePath, values.StorageLocalBasePath, usage.StorageLocalBasePath)
+	cmd.Flags().Int(config.Keys.StorageMaxObjectSize, values.StorageMaxObjectSize, usage.StorageMaxObjectSize)
 }
 
 // Statuses attaches flags pertaining to statuses config.
@@ -21,60 +21,89 @@ package flag
 import "github.com/superseriousbusiness/gotosocial/internal/config"
 
 var usage = config.KeyNames{
-	LogLevel:                   "Log level to run at: [trace, debug, info, warn, fatal]",
-	ApplicationName:            "Name of the application, used in various places internally",
-	ConfigPath:                 "Path to a file containing gotosocial configuration. Values set in this file will be overwritten by values set as env vars or arguments",
-	Host:                       "Hostname to use for the server (eg., example.org, gotosocial.whatever.com). DO NOT change this on a server that's already run!",
-	AccountDomain:              "Domain to use in account names (eg., example.org, whatever.com). If not set, will default to the setting for host. DO NOT change this on a server that's already run!",
-	Protocol:                   "Protocol to use for the REST api of the server (only use http for debugging and tests!)",
-	BindAddress:                "Bind address to use for the GoToSocial server (eg., 0.0.0.0, 172.138.0.9, [::], localhost). For ipv6, enclose the address in square brackets, eg [2001:db8::fed1]. Default binds to all interfaces.",
-	Port:                       "Port to use for GoToSocial. Change this to 443 if you're running the binary directly on the host machine.",
-	TrustedProxies:             "Proxies to trust when parsing x-forwarded headers into real IPs.",
-	DbType:                     "Database type: eg., postgres",
-	DbAddress:                  "Database ipv4 address, hostname, or filename",
-	DbPort:                     "Database port",
-	DbUser:                     "Database username",
-	DbPassword:                 "Database password",
-	DbDatabase:                 "Database name",
-	DbTLSMode:                  "Database tls mode",
-	DbTLSCACert:                "Path to CA cert for db tls connection",
-	WebTemplateBaseDir:         "Basedir for html templating files for rendering pages and composing emails.",
-	WebAssetBaseDir:            "Directory to serve static assets from, accessible at example.org/assets/",
-	AccountsRegistrationOpen:   "Allow anyone to submit an account signup request. If false, server will be invite-only.",
-	AccountsApprovalRequired:   "Do account signups require approval by an admin or moderator before user can log in? If false, new registrations will be automatically approved.",
-	AccountsReasonRequired:     "Do new account signups require a reason to be submitted on registration?",
-	MediaImageMaxSize:          "Max size of accepted images in bytes",
-	MediaVideoMaxSize:          "Max size of accepted videos in bytes",
-	MediaDescriptionMinChars:   "Min required chars for an image description",
-	MediaDescriptionMaxChars:   "Max permitted chars for an image description",
-	StorageBackend:             "Storage backend to use for media attachments",
-	StorageLocalBasePath:       "Full path to an already-created directory where gts should store/retrieve media files. Subfolders will be created within this dir.",
-	StatusesMaxChars:           "Max permitted characters for posted statuses",
-	StatusesCWMaxChars:         "Max permitted characters for content/spoiler warnings on statuses",
-	StatusesPollMaxOptions:     "Max amount of options permitted on a poll",
-	StatusesPollOptionMaxChars: "Max amount of characters for a poll option",
-	StatusesMediaMaxFiles:      "Maximum number of media files/attachments per status",
-	LetsEncryptEnabled:         "Enable letsencrypt TLS certs for this server. If set to true, then cert dir also needs to be set (or take the default).",
-	LetsEncryptPort:            "Port to listen on for letsencrypt certificate challenges. Must not be the same as the GtS webserver/API port.",
-	LetsEncryptCertDir:         "Directory to store acquired letsencrypt certificates.",
-	LetsEncryptEmailAddress:    "Email address to use when requesting letsencrypt certs. Will receive updates on cert expiry etc.",
-	OIDCEnabled:                "Enabled OIDC authorization for this instance. If set to true, then the other OIDC flags must also be set.",
-	OIDCIdpName:                "Name of the OIDC identity provider. Will be shown to the user when logging in.",
-	OIDCSkipVerification:       "Skip verification of tokens returned by the OIDC provider. Should only be set to 'true' for testing purposes, never in a production environment!",
-	OIDCIssuer:                 "Address of the OIDC issuer. Should be the web address, including protocol, at which the issuer can be reached. Eg., 'https://example.org/auth'",
-	OIDCClientID:               "ClientID of GoToSocial, as registered with the OIDC provider.",
-	OIDCClientSecret:           "ClientSecret of GoToSocial, as registered with the OIDC provider.",
-	OIDCScopes:                 "OIDC scopes.",
-	SMTPHost:                   "Host of the smtp server. Eg., 'smtp.eu.mailgun.org'",
-	SMTPPort:                   "Port of the smtp server. Eg., 587",
-	SMTPUsername:               "Username to authenticate with the smtp server as. Eg., 'postmaster@mail.example.org'",
-	SMTPPassword:               "Password to pass to the smtp server.",
-	SMTPFrom:                   "Address to use as the 'from' field of the email. Eg., 'gotosocial@example.org'",
-	SyslogEnabled:              "Enable the syslog logging hook. Logs will be mirrored to the configured destination.",
-	SyslogProtocol:             "Protocol to use when directing logs to syslog. Leave empty to connect to local syslog.",
-	SyslogAddress:              "Address:port to send syslog logs to. Leave empty to connect to local syslog.",
-	AdminAccountUsername:       "the username to create/delete/etc",
-	AdminAccountEmail:          "the email address of this account",
-	AdminAccountPassword:       "the password to set for this account",
-	AdminTransPath:             "the path of the file to import from/export to",
+	LogLevel:                    "Log level to run at: [trace, debug, info, warn, fatal]",
+	ApplicationName:             "Name of the application, used in various places internally",
+	ConfigPath:                  "Path to a file containing gotosocial configuration. Values set in this file will be overwritten by values set as env vars or arguments",
+	DumpConfig:                  "Log the effective configuration at startup, with passwords and secrets redacted",
+	Host:                        "Hostname to use for the server (eg., example.org, gotosocial.whatever.com). DO NOT change this on a server that's already run!",
+	AccountDomain:               "Domain to use in account names (eg., example.org, whatever.com). If not set, will default to the setting for host. DO NOT change this on a server that's already run!",
+	Protocol:                    "Protocol to use for the REST api of the server (only use http for debugging and tests!)",
+	BindAddress:                 "Bind address to use for the GoToSocial server (eg., 0.0.0.0, 172.138.0.9, [::], localhost). For ipv6, enclose the address in square brackets, eg [2001:db8::fed1]. Default binds to all interfaces.",
+	Port:                        "Port to use for GoToSocial. Change this to 443 if you're running the binary directly on the host machine.",
+	TrustedProxies:              "Proxies to trust when parsing x-forwarded headers into real IPs.",
+	DbType:                      "Database type: eg., postgres",
+	DbAddress:                   "Database ipv4 address, hostname, or filename",
+	DbPort:                      "Database port",
+	DbUser:                      "Database username",
+	DbPassword:                  "Database password",
+	DbDatabase:                  "Database name",
+	DbTLSMode:                   "Database tls mode",
+	DbTLSCACert:                 "Path to CA cert for db tls connection",
+	DbMigrationAnalyze:          "Refresh query planner statistics (ANALYZE on postgres, PRAGMA optimize on sqlite) after new migrations have been applied",
+	DbReadOnly:                  "Start with the database in read-only mode: reads will work as normal, but all writes will be rejected, and the database connection itself is opened read-only",
+	DbOpenReadOnly:              "Open the database connection itself read-only, for inspecting a database after something has gone wrong: migrations are skipped, and read-only mode can't be turned off without a restart",
+	DbMigrationTimeout:          "Maximum time that database migrations are allowed to run for on startup. 0 means no limit.",
+	DbMigrateOnStart:            "Apply pending database migrations on startup. If false, only check that the database schema is up to date, and refuse to start if it isn't.",
+	DbMigrationVerifyChecksums:  "Refuse to start if a database migration that has already been applied has been changed since",
+	DbMigrationSkipIncompatible: "Mark database migrations that don't support the configured db-type as applied without running them, instead of refusing to start",
+	DbAllowNoPassword:           "Allow connecting to postgres without a password, for trust or peer authentication. Always allowed when connecting over a unix socket.",
+	DbSqliteBusyTimeout:         "How long sqlite should wait for a locked database to become available before giving up with a 'database is locked' error. 0 means don't wait.",
+	DbSqliteCache:               "SQLite only: cache mode for database connections: private or shared. In-memory databases always use shared.",
+	DbSqliteForeignKeys:         "SQLite only: enforce foreign key constraints, like postgres always does.",
+	DbTimezone:                  "Postgres only: timezone to set for each database session. Leave empty to use the server's TimeZone setting.",
+	DbPostgresFlavor:            "Postgres only: which postgres-compatible database is being connected to: postgres or cockroach",
+	DbPostgresSchema:            "Postgres only. Schema to keep GoToSocial's tables in, set as the search_path of each connection. Empty means use the server's default search_path",
+	DbPoolSampleInterval:        "How often to check the database connection pool for saturation. Set to 0 to disable checking.",
+	DbPoolWaitThreshold:         "Log a warning when queries spent longer than this waiting for a free database connection since the last pool check",
+	DbQueryLogSampleRate:        "Fraction of database queries to log at trace level, between 0 and 1",
+	DbKeepaliveInterval:         "Interval between TCP keepalive probes on idle postgres connections, so connections dropped by a firewall or NAT are noticed; 0 disables keepalives",
+	DbDialTimeout:               "How long to wait when connecting to the database before giving up. 0 means wait as long as it takes",
+	DbWarmup:                    "Open a full pool of idle postgres connections on startup, so the first requests don't have to wait for connections to be made",
+	DbSqlCommenterEnabled:       "Prepend a comment naming the subsystem that ran each query, sqlcommenter style, so queries can be traced back from database-side logs",
+	DbLogConnections:            "Log a debug message whenever a database connection is opened or closed, along with how many are open",
+	DbSqliteBackupPath:          "Path to write scheduled sqlite backups to. Only used if db-sqlite-backup-interval is set.",
+	DbSqliteBackupInterval:      "How often to back up the sqlite database to db-sqlite-backup-path. Set to 0 to disable scheduled backups.",
+	WebTemplateBaseDir:          "Basedir for html templating files for rendering pages and composing emails.",
+	WebAssetBaseDir:             "Directory to serve static assets from, accessible at example.org/assets/",
+	AccountsRegistrationOpen:    "Allow anyone to submit an account signup request. If false, server will be invite-only.",
+	AccountsApprovalRequired:    "Do account signups require approval by an admin or moderator before user can log in? If false, new registrations will be automatically approved.",
+	AccountsReasonRequired:      "Do new account signups require a reason to be submitted on registration?",
+	MediaImageMaxSize:           "Max size of accepted images in bytes",
+	MediaVideoMaxSize:           "Max size of accepted videos in bytes",
+	MediaDescriptionMinChars:    "Min required chars for an image description",
+	MediaDescriptionMaxChars:    "Max permitted chars for an image description",
+	StorageBackend:              "Storage backend to use for media attachments",
+	StorageLocalBasePath:        "Full path to an already-created directory where gts should store/retrieve media files. Subfolders will be created within this dir.",
+	StorageMaxObjectSize:        "Maximum size in bytes of any single object written to storage. Writes of bigger objects are rejected. 0 means no limit.",
+	StatusesMaxChars:            "Max permitted characters for posted statuses",
+	StatusesCWMaxChars:          "Max permitted characters for content/spoiler warnings on statuses",
+	StatusesPollMaxOptions:      "Max amount of options permitted on a poll",
+	StatusesPollOptionMaxChars:  "Max amount of characters for a poll option",
+	StatusesMediaMaxFiles:       "Maximum number of media files/attachments per status",
+	LetsEncryptEnabled:          "Enable letsencrypt TLS certs for this server. If set to true, then cert dir also needs to be set (or take the default).",
+	LetsEncryptPort:             "Port to listen on for letsencrypt certificate challenges. Must not be the same as the GtS webserver/API port.",
+	LetsEncryptCertDir:          "Directory to store acquired letsencrypt certificates.",
+	LetsEncryptEmailAddress:     "Email address to use when requesting letsencrypt certs. Will receive updates on cert expiry etc.",
+	OIDCEnabled:                 "Enabled OIDC authorization for this instance. If set to true, then the other OIDC flags must also be set.",
+	OIDCIdpName:                 "Name of the OIDC identity provider. Will be shown to the user when logging in.",
+	OIDCSkipVerification:        "Skip verification of tokens returned by the OIDC provider. Should only be set to 'true' for testing purposes, never in a production environment!",
+	OIDCIssuer:                  "Address of the OIDC issuer. Should be the web address, including protocol, at which the issuer can be reached. Eg., 'https://example.org/auth'",
+	OIDCClientID:                "ClientID of GoToSocial, as registered with the OIDC provider.",
+	OIDCClientSecret:            "ClientSecret of GoToSocial, as registered with the OIDC provider.",
+	OIDCScopes:                  "OIDC scopes.",
+	SMTPHost:                    "Host of the smtp server. Eg., 'smtp.eu.mailgun.org'",
+	SMTPPort:                    "Port of the smtp server. Eg., 587",
+	SMTPUsername:                "Username to authenticate with the smtp server as. Eg., 'postmaster@mail.example.org'",
+	SMTPPassword:                "Password to pass to the smtp server.",
+	SMTPFrom:                    "Address to use as the 'from' field of the email. Eg., 'gotosocial@example.org'",
+	SyslogEnabled:               "Enable the syslog logging hook. Logs will be mirrored to the configured destination.",
+	SyslogProtocol:              "Protocol to use when directing logs to syslog. Leave empty to connect to local syslog.",
+	SyslogAddress:               "Address:port to send syslog logs to. Leave empty to connect to local syslog.",
+	AdminAccountUsername:        "the username to create/delete/etc",
+	AdminAccountEmail:           "the email address of this account",
+	AdminAccountPassword:        "the password to set for this account",
+	AdminTransPath:              "the path of the file to import from/export to",
+	AdminMigrationName:          "the name of the database migration to run, as logged when it failed",
+	AdminMediaForce:             "also work out blurhashes again for attachments that already have one",
+	DbBackupOut:                 "the path to write the database backup to; must not already exist",
 }
@@ -69,6 +69,7 @@ func main() {
 	rootCmd.AddCommand(testrigCommands())
 	rootCmd.AddCommand(debugCommands())
 	rootCmd.AddCommand(adminCommands())
+	rootCmd.AddCommand(dbCommands())
 
 	// run
 	if err := rootCmd.Execute(); err != nil {
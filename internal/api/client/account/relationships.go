@@ -7,7 +7,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/superseriousbusiness/gotosocial/internal/api"
-	"github.com/superseriousbusiness/gotosocial/internal/api/model"
 	"github.com/superseriousbusiness/gotosocial/internal/oauth"
 )
 
@@ -76,15 +75,10 @@ func (m *Module) AccountRelationshipsGETHandler(c *gin.Context) {
 		targetAccountIDs = append(targetAccountIDs, id)
 	}
 
-	relationships := []model.Relationship{}
-
-	for _, targetAccountID := range targetAccountIDs {
-		r, errWithCode := m.processor.AccountRelationshipGet(c.Request.Context(), authed, targetAccountID)
-		if err != nil {
-			c.JSON(errWithCode.Code(), gin.H{"error": errWithCode.Safe()})
-			return
-		}
-		relationships = append(relationships, *r)
+	relationships, errWithCode := m.processor.AccountRelationshipsGet(c.Request.Context(), authed, targetAccountIDs)
+	if errWithCode != nil {
+		c.JSON(errWithCode.Code(), gin.H{"error": errWithCode.Safe()})
+		return
 	}
 
 	c.JSON(http.StatusOK, relationships)
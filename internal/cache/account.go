@@ -130,6 +130,7 @@ func copyAccount(account *gtsmodel.Account) *gtsmodel.Account {
 		Note:                    account.Note,
 		Memorial:                account.Memorial,
 		MovedToAccountID:        account.MovedToAccountID,
+		MovedAt:                 account.MovedAt,
 		CreatedAt:               account.CreatedAt,
 		UpdatedAt:               account.UpdatedAt,
 		Bot:                     account.Bot,
@@ -157,5 +158,7 @@ func copyAccount(account *gtsmodel.Account) *gtsmodel.Account {
 		SuspendedAt:             account.SuspendedAt,
 		HideCollections:         account.HideCollections,
 		SuspensionOrigin:        account.SuspensionOrigin,
+		UnsuspendedAt:           account.UnsuspendedAt,
+		UnsuspensionOrigin:      account.UnsuspensionOrigin,
 	}
 }
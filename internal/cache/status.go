@@ -155,5 +155,6 @@ func copyStatus(status *gtsmodel.Status) *gtsmodel.Status {
 		ActivityStreamsType:      status.ActivityStreamsType,
 		Text:                     status.Text,
 		Pinned:                   status.Pinned,
+		PinnedAt:                 status.PinnedAt,
 	}
 }
@@ -18,7 +18,11 @@
 
 package config
 
-import "github.com/coreos/go-oidc/v3/oidc"
+import (
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
 
 // Defaults returns a populated Values struct with most of the values set to reasonable defaults.
 // Note that if you use this, you still need to set Host and, if desired, ConfigPath.
@@ -26,6 +30,7 @@ var Defaults = Values{
 	LogLevel:        "info",
 	ApplicationName: "gotosocial",
 	ConfigPath:      "",
+	DumpConfig:      false,
 	Host:            "",
 	AccountDomain:   "",
 	Protocol:        "https",
@@ -33,14 +38,38 @@ var Defaults = Values{
 	Port:            8080,
 	TrustedProxies:  []string{"127.0.0.1/32"}, // localhost
 
-	DbType:      "postgres",
-	DbAddress:   "localhost",
-	DbPort:      5432,
-	DbUser:      "postgres",
-	DbPassword:  "postgres",
-	DbDatabase:  "postgres",
-	DbTLSMode:   "disable",
-	DbTLSCACert: "",
+	DbType:                      "postgres",
+	DbAddress:                   "localhost",
+	DbPort:                      5432,
+	DbUser:                      "postgres",
+	DbPassword:                  "postgres",
+	DbDatabase:                  "postgres",
+	DbTLSMode:                   "disable",
+	DbTLSCACert:                 "",
+	DbMigrationAnalyze:          true,
+	DbReadOnly:                  false,
+	DbOpenReadOnly:              false,
+	DbMigrationTimeout:          0,
+	DbMigrateOnStart:            true,
+	DbMigrationVerifyChecksums:  true,
+	DbMigrationSkipIncompatible: false,
+	DbAllowNoPassword:           false,
+	DbSqliteBusyTimeout:         5 * time.Second,
+	DbSqliteCache:               "private",
+	DbSqliteForeignKeys:         true,
+	DbTimezone:                  "UTC",
+	DbPostgresFlavor:            "postgres",
+	DbPostgresSchema:            "",
+	DbPoolSampleInterval:        time.Minute,
+	DbPoolWaitThreshold:         time.Second,
+	DbQueryLogSampleRate:        1,
+	DbKeepaliveInterval:         time.Minute,
+	DbDialTimeout:               10 * time.Second,
+	DbWarmup:                    false,
+	DbSqlCommenterEnabled:       false,
+	DbLogConnections:            false,
+	DbSqliteBackupPath:          "",
+	DbSqliteBackupInterval:      0,
 
 	WebTemplateBaseDir: "./web/template/",
 	WebAssetBaseDir:    "./web/assets/",
@@ -56,6 +85,7 @@ var Defaults = Values{
 
 	StorageBackend:       "local",
 	StorageLocalBasePath: "/gotosocial/storage",
+	StorageMaxObjectSize: 41943040,
 
 	StatusesMaxChars:           5000,
 	StatusesCWMaxChars:         100,
@@ -0,0 +1,77 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// redacted is shown in place of the value of secret keys.
+const redacted = "[redacted]"
+
+// secretSuffixes are the final dash-separated parts of key names that hold secrets.
+// Name new secret keys accordingly (eg., 'some-service-password', 'some-api-token')
+// and they'll be redacted automatically when dumped.
+var secretSuffixes = map[string]bool{
+	"password": true,
+	"secret":   true,
+	"token":    true,
+	"key":      true,
+}
+
+// IsSecret returns true if the key with the given name holds a secret value,
+// going by the naming convention described on secretSuffixes.
+func IsSecret(key string) bool {
+	parts := strings.Split(key, "-")
+	return secretSuffixes[parts[len(parts)-1]]
+}
+
+// Dump returns the effective value of every config key from the viper store,
+// with the values of secret keys redacted, so the result is safe to log.
+// Secrets that aren't set are left empty, so it's still possible to tell
+// whether or not they were picked up.
+func Dump() map[string]interface{} {
+	keyValues := reflect.ValueOf(Keys)
+
+	dump := make(map[string]interface{}, keyValues.NumField())
+	for i := 0; i < keyValues.NumField(); i++ {
+		key := keyValues.Field(i).String()
+		value := viper.Get(key)
+
+		if IsSecret(key) {
+			switch v := value.(type) {
+			case nil, bool:
+				// nothing secret about whether something's switched on or not
+			case string:
+				if v != "" {
+					value = redacted
+				}
+			default:
+				value = redacted
+			}
+		}
+
+		dump[key] = value
+	}
+
+	return dump
+}
@@ -0,0 +1,71 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type DumpTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DumpTestSuite) SetupTest() {
+	viper.Reset()
+}
+
+func (suite *DumpTestSuite) TearDownTest() {
+	viper.Reset()
+}
+
+func (suite *DumpTestSuite) TestIsSecret() {
+	suite.True(config.IsSecret(config.Keys.DbPassword))
+	suite.True(config.IsSecret(config.Keys.OIDCClientSecret))
+	suite.True(config.IsSecret(config.Keys.SMTPPassword))
+	suite.True(config.IsSecret(config.Keys.AdminAccountPassword))
+	suite.True(config.IsSecret("some-new-api-token"))
+	suite.False(config.IsSecret(config.Keys.DbUser))
+	suite.False(config.IsSecret(config.Keys.OIDCClientID))
+}
+
+func (suite *DumpTestSuite) TestDump() {
+	viper.Set(config.Keys.Host, "example.org")
+	viper.Set(config.Keys.DbPassword, "hunter2")
+	viper.Set(config.Keys.SMTPPassword, "")
+	viper.Set(config.Keys.DbAllowNoPassword, true)
+
+	dump := config.Dump()
+
+	// every key should be in there
+	suite.Contains(dump, config.Keys.LogLevel)
+	suite.Contains(dump, config.Keys.StorageBackend)
+
+	suite.Equal("example.org", dump[config.Keys.Host])
+	suite.Equal("[redacted]", dump[config.Keys.DbPassword])
+	suite.Equal("", dump[config.Keys.SMTPPassword])
+	suite.Equal(true, dump[config.Keys.DbAllowNoPassword])
+}
+
+func TestDumpTestSuite(t *testing.T) {
+	suite.Run(t, new(DumpTestSuite))
+}
@@ -23,6 +23,7 @@ type KeyNames struct {
 	// root
 	LogLevel   string
 	ConfigPath string
+	DumpConfig string
 
 	// general
 	ApplicationName string
@@ -35,14 +36,38 @@ type KeyNames struct {
 	SoftwareVersion string
 
 	// database
-	DbType      string
-	DbAddress   string
-	DbPort      string
-	DbUser      string
-	DbPassword  string
-	DbDatabase  string
-	DbTLSMode   string
-	DbTLSCACert string
+	DbType                      string
+	DbAddress                   string
+	DbPort                      string
+	DbUser                      string
+	DbPassword                  string
+	DbDatabase                  string
+	DbTLSMode                   string
+	DbTLSCACert                 string
+	DbMigrationAnalyze          string
+	DbReadOnly                  string
+	DbOpenReadOnly              string
+	DbMigrationTimeout          string
+	DbMigrateOnStart            string
+	DbMigrationVerifyChecksums  string
+	DbMigrationSkipIncompatible string
+	DbAllowNoPassword           string
+	DbSqliteBusyTimeout         string
+	DbSqliteCache               string
+	DbSqliteForeignKeys         string
+	DbTimezone                  string
+	DbPostgresFlavor            string
+	DbPostgresSchema            string
+	DbPoolSampleInterval        string
+	DbPoolWaitThreshold         string
+	DbQueryLogSampleRate        string
+	DbKeepaliveInterval         string
+	DbDialTimeout               string
+	DbWarmup                    string
+	DbSqlCommenterEnabled       string
+	DbLogConnections            string
+	DbSqliteBackupPath          string
+	DbSqliteBackupInterval      string
 
 	// template
 	WebTemplateBaseDir string
@@ -62,6 +87,7 @@ type KeyNames struct {
 	// storage
 	StorageBackend       string
 	StorageLocalBasePath string
+	StorageMaxObjectSize string
 
 	// statuses
 	StatusesMaxChars           string
@@ -102,6 +128,11 @@ type KeyNames struct {
 	AdminAccountEmail    string
 	AdminAccountPassword string
 	AdminTransPath       string
+	AdminMigrationName   string
+	AdminMediaForce      string
+
+	// db
+	DbBackupOut string
 }
 
 // Keys contains the names of the various keys used for initializing and storing flag variables,
@@ -110,6 +141,7 @@ var Keys = KeyNames{
 	LogLevel:        "log-level",
 	ApplicationName: "application-name",
 	ConfigPath:      "config-path",
+	DumpConfig:      "dump-config",
 	Host:            "host",
 	AccountDomain:   "account-domain",
 	Protocol:        "protocol",
@@ -118,14 +150,38 @@ var Keys = KeyNames{
 	TrustedProxies:  "trusted-proxies",
 	SoftwareVersion: "software-version",
 
-	DbType:      "db-type",
-	DbAddress:   "db-address",
-	DbPort:      "db-port",
-	DbUser:      "db-user",
-	DbPassword:  "db-password",
-	DbDatabase:  "db-database",
-	DbTLSMode:   "db-tls-mode",
-	DbTLSCACert: "db-tls-ca-cert",
+	DbType:                      "db-type",
+	DbAddress:                   "db-address",
+	DbPort:                      "db-port",
+	DbUser:                      "db-user",
+	DbPassword:                  "db-password",
+	DbDatabase:                  "db-database",
+	DbTLSMode:                   "db-tls-mode",
+	DbTLSCACert:                 "db-tls-ca-cert",
+	DbMigrationAnalyze:          "db-migration-analyze",
+	DbReadOnly:                  "db-read-only",
+	DbOpenReadOnly:              "db-open-read-only",
+	DbMigrationTimeout:          "db-migration-timeout",
+	DbMigrateOnStart:            "db-migrate-on-start",
+	DbMigrationVerifyChecksums:  "db-migration-verify-checksums",
+	DbMigrationSkipIncompatible: "db-migration-skip-incompatible",
+	DbAllowNoPassword:           "db-allow-no-password",
+	DbSqliteBusyTimeout:         "db-sqlite-busy-timeout",
+	DbSqliteCache:               "db-sqlite-cache",
+	DbSqliteForeignKeys:         "db-sqlite-foreign-keys",
+	DbTimezone:                  "db-timezone",
+	DbPostgresFlavor:            "db-postgres-flavor",
+	DbPostgresSchema:            "db-postgres-schema",
+	DbPoolSampleInterval:        "db-pool-sample-interval",
+	DbPoolWaitThreshold:         "db-pool-wait-threshold",
+	DbQueryLogSampleRate:        "db-query-log-sample-rate",
+	DbKeepaliveInterval:         "db-keepalive-interval",
+	DbDialTimeout:               "db-dial-timeout",
+	DbWarmup:                    "db-warmup",
+	DbSqlCommenterEnabled:       "db-sqlcommenter-enabled",
+	DbLogConnections:            "db-log-connections",
+	DbSqliteBackupPath:          "db-sqlite-backup-path",
+	DbSqliteBackupInterval:      "db-sqlite-backup-interval",
 
 	WebTemplateBaseDir: "web-template-base-dir",
 	WebAssetBaseDir:    "web-asset-base-dir",
@@ -141,6 +197,7 @@ var Keys = KeyNames{
 
 	StorageBackend:       "storage-backend",
 	StorageLocalBasePath: "storage-local-base-path",
+	StorageMaxObjectSize: "storage-max-object-size",
 
 	StatusesMaxChars:           "statuses-max-chars",
 	StatusesCWMaxChars:         "statuses-cw-max-chars",
@@ -175,4 +232,9 @@ var Keys = KeyNames{
 	AdminAccountEmail:    "email",
 	AdminAccountPassword: "password",
 	AdminTransPath:       "path",
+	AdminMigrationName:   "name",
+	AdminMediaForce:      "force",
+
+	// db
+	DbBackupOut: "out",
 }
@@ -18,11 +18,14 @@
 
 package config
 
+import "time"
+
 // Values contains contains the type of each configuration value.
 type Values struct {
 	LogLevel        string
 	ApplicationName string
 	ConfigPath      string
+	DumpConfig      bool
 	Host            string
 	AccountDomain   string
 	Protocol        string
@@ -31,14 +34,38 @@ type Values struct {
 	TrustedProxies  []string
 	SoftwareVersion string
 
-	DbType      string
-	DbAddress   string
-	DbPort      int
-	DbUser      string
-	DbPassword  string
-	DbDatabase  string
-	DbTLSMode   string
-	DbTLSCACert string
+	DbType                      string
+	DbAddress                   string
+	DbPort                      int
+	DbUser                      string
+	DbPassword                  string
+	DbDatabase                  string
+	DbTLSMode                   string
+	DbTLSCACert                 string
+	DbMigrationAnalyze          bool
+	DbReadOnly                  bool
+	DbOpenReadOnly              bool
+	DbMigrationTimeout          time.Duration
+	DbMigrateOnStart            bool
+	DbMigrationVerifyChecksums  bool
+	DbMigrationSkipIncompatible bool
+	DbAllowNoPassword           bool
+	DbSqliteBusyTimeout         time.Duration
+	DbSqliteCache               string
+	DbSqliteForeignKeys         bool
+	DbTimezone                  string
+	DbPostgresFlavor            string
+	DbPostgresSchema            string
+	DbPoolSampleInterval        time.Duration
+	DbPoolWaitThreshold         time.Duration
+	DbQueryLogSampleRate        float64
+	DbKeepaliveInterval         time.Duration
+	DbDialTimeout               time.Duration
+	DbWarmup                    bool
+	DbSqlCommenterEnabled       bool
+	DbLogConnections            bool
+	DbSqliteBackupPath          string
+	DbSqliteBackupInterval      time.Duration
 
 	WebTemplateBaseDir string
 	WebAssetBaseDir    string
@@ -54,6 +81,7 @@ type Values struct {
 
 	StorageBackend       string
 	StorageLocalBasePath string
+	StorageMaxObjectSize int
 
 	StatusesMaxChars           int
 	StatusesCWMaxChars         int
@@ -88,4 +116,8 @@ type Values struct {
 	AdminAccountEmail    string
 	AdminAccountPassword string
 	AdminTransPath       string
+	AdminMigrationName   string
+	AdminMediaForce      bool
+
+	DbBackupOut string
 }
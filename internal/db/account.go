@@ -54,6 +54,11 @@ type Account interface {
 	// In case of no entries, a 'no entries' error will be returned
 	GetAccountStatuses(ctx context.Context, accountID string, limit int, excludeReplies bool, maxID string, minID string, pinnedOnly bool, mediaOnly bool, publicOnly bool) ([]*gtsmodel.Status, Error)
 
+	// GetAccountPinnedStatuses returns the statuses that accountID has pinned and which can be federated
+	// (public or unlisted, and not local-only), most recently pinned first. This is what goes in the
+	// account's ActivityPub featured collection. If there are no such statuses, ErrNoEntries will be returned.
+	GetAccountPinnedStatuses(ctx context.Context, accountID string) ([]*gtsmodel.Status, Error)
+
 	GetAccountBlocks(ctx context.Context, accountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, Error)
 
 	// GetAccountLastPosted simply gets the timestamp of the most recent post by the account.
@@ -64,7 +69,108 @@ type Account interface {
 	// SetAccountHeaderOrAvatar sets the header or avatar for the given accountID to the given media attachment.
 	SetAccountHeaderOrAvatar(ctx context.Context, mediaAttachment *gtsmodel.MediaAttachment, accountID string) Error
 
+	// GetRemoteAccountsWithNoStatuses returns remote accounts that were created before olderThan, which have never posted
+	// any statuses, and which no local account follows or has requested to follow. These are good candidates for pruning.
+	// Accounts are returned newest first; if maxID is set, only accounts with an ID lower than maxID will be returned.
+	// If no accounts are found, ErrNoEntries will be returned.
+	GetRemoteAccountsWithNoStatuses(ctx context.Context, olderThan time.Time, maxID string, limit int) ([]*gtsmodel.Account, Error)
+
+	// GetInactiveLocalAccounts returns up to limit local accounts that were created before createdBefore, have never posted
+	// any statuses, and whose user hasn't signed in since createdBefore, oldest first. These are accounts that were signed up
+	// for and never used, so their usernames can be reclaimed. If no accounts are found, ErrNoEntries will be returned.
+	GetInactiveLocalAccounts(ctx context.Context, createdBefore time.Time, limit int) ([]*gtsmodel.Account, Error)
+
+	// GetStaleRemoteAccounts returns up to limit remote accounts that were last fetched before olderThan, stalest first,
+	// so that their profiles can be refreshed. Accounts that have never been fetched go by when they were last updated.
+	// If no accounts are found, ErrNoEntries will be returned.
+	GetStaleRemoteAccounts(ctx context.Context, olderThan time.Time, limit int) ([]*gtsmodel.Account, Error)
+
+	// SetAccountFetchedAt records that the given remote account was fetched (refreshed) at fetchedAt.
+	SetAccountFetchedAt(ctx context.Context, accountID string, fetchedAt time.Time) Error
+
+	// GetAccountsByRole pages through the local accounts with the given role (one of AccountRoleAdmin, AccountRoleModerator
+	// or AccountRoleUser), newest first. Each account has exactly one role: admins who are also moderators count as admins.
+	// If maxID is set, only accounts with an ID lower than maxID will be returned.
+	// If no accounts are found, ErrNoEntries will be returned.
+	GetAccountsByRole(ctx context.Context, role string, maxID string, limit int) ([]*gtsmodel.Account, Error)
+
+	// GetSuspendedAccounts pages through suspended accounts, newest first, along with whoever suspended them.
+	// If maxID is set, only accounts with an ID lower than maxID will be returned.
+	// If no accounts are found, ErrNoEntries will be returned.
+	GetSuspendedAccounts(ctx context.Context, maxID string, limit int) ([]*SuspendedAccount, Error)
+
+	// UnsuspendAccount lifts the suspension of the given account, recording that moderatorID lifted it.
+	// Note that anything removed from the account when it was suspended (statuses, profile fields etc) won't come back.
+	UnsuspendAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, Error)
+
+	// SilenceAccount silences the given account, recording that moderatorID silenced it. This hides its statuses
+	// from the public timeline for everyone except its followers. Its statuses are still delivered to followers as usual.
+	SilenceAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, Error)
+
+	// UnsilenceAccount lifts the silence of the given account, recording that moderatorID lifted it.
+	UnsilenceAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, Error)
+
 	// GetInstanceAccount returns the instance account for the given domain.
 	// If domain is empty, this instance account will be returned.
 	GetInstanceAccount(ctx context.Context, domain string) (*gtsmodel.Account, Error)
+
+	// GetGrantedApplications returns the applications that currently hold unexpired access tokens for the given
+	// local account, one entry per application however many tokens it has, most recently authorized first.
+	GetGrantedApplications(ctx context.Context, accountID string) ([]*GrantedApplication, Error)
+
+	// RevokeGrantedApplication deletes all the tokens that the application with the given client ID holds for the
+	// given local account, so that it can't act for the account anymore until it's authorized again.
+	RevokeGrantedApplication(ctx context.Context, accountID string, clientID string) Error
+
+	// GetAccountFields returns the profile fields (eg., pronouns, website) of the given account, in the order they're shown on its profile.
+	GetAccountFields(ctx context.Context, accountID string) ([]gtsmodel.Field, Error)
+
+	// ReplaceAccountFields replaces all profile fields of the given account with the given fields, keeping their order, in one update.
+	// Fields whose VerifiedAt is set are shown as verified links. ErrTooManyAccountFields is returned if there are more than MaxAccountFields.
+	ReplaceAccountFields(ctx context.Context, accountID string, fields []gtsmodel.Field) Error
+
+	// AddAccountAlias records that aliasAccountID is an alias of the given account (ActivityPub alsoKnownAs), eg., because
+	// it's the same person's account on another instance. Adding an alias that's already there does nothing.
+	AddAccountAlias(ctx context.Context, accountID string, aliasAccountID string) Error
+
+	// GetAccountAliases returns the accounts that the given account lists as its aliases, in the order they were added.
+	GetAccountAliases(ctx context.Context, accountID string) ([]*gtsmodel.Account, Error)
+
+	// SetAccountMovedTo records that the given account has moved to targetAccountID, as announced by a Move activity,
+	// and returns the updated account with MovedToAccount populated. The move is only honoured if both accounts list each
+	// other as aliases; otherwise ErrAccountAliasNotMutual is returned. An empty targetAccountID clears a previous move.
+	SetAccountMovedTo(ctx context.Context, accountID string, targetAccountID string) (*gtsmodel.Account, Error)
+}
+
+const (
+	// AccountRoleAdmin is the role of local accounts whose user is an admin.
+	AccountRoleAdmin string = "admin"
+	// AccountRoleModerator is the role of local accounts whose user is a moderator, but not an admin.
+	AccountRoleModerator string = "moderator"
+	// AccountRoleUser is the role of local accounts whose user is neither an admin nor a moderator.
+	AccountRoleUser string = "user"
+)
+
+// MaxAccountFields is the most profile fields that an account can have.
+const MaxAccountFields = 4
+
+// SuspendedAccount is a suspended account, along with whatever caused the suspension.
+type SuspendedAccount struct {
+	Account *gtsmodel.Account
+	// Account that suspended Account, if it was suspended by a moderator.
+	SuspendedBy *gtsmodel.Account
+	// Domain block that suspended Account, if it was suspended along with its domain.
+	DomainBlock *gtsmodel.DomainBlock
+}
+
+// GrantedApplication is an application that a local account has authorized to act for it.
+type GrantedApplication struct {
+	Application *gtsmodel.Application
+	// Scopes granted to the application across all its tokens, in alphabetical order.
+	Scopes []string
+	// When the application was last issued a token. Tokens don't record when they're used,
+	// so this is the closest thing there is to when the application was last used.
+	LastAuthorizedAt time.Time
+	// How many unexpired access tokens the application holds.
+	Tokens int
 }
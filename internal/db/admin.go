@@ -20,7 +20,9 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"net"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
@@ -51,4 +53,47 @@ type Admin interface {
 	// Ie., if the instance is hosted at 'example.org' the instance will have a domain of 'example.org'.
 	// This is needed for things like serving instance information through /api/v1/instance
 	CreateInstanceInstance(ctx context.Context) Error
+
+	// PutAdminAction records the given moderation action in the audit log.
+	// If the action has no ID yet, one will be generated for it.
+	PutAdminAction(ctx context.Context, action *gtsmodel.AdminAction) Error
+
+	// GetAdminActions pages through the audit log of moderation actions, newest first, narrowed down by the given filter.
+	// If maxID is set, only actions with an ID lower than maxID will be returned.
+	// If no actions are found, ErrNoEntries will be returned.
+	GetAdminActions(ctx context.Context, filter AdminActionFilter, maxID string, limit int) ([]*gtsmodel.AdminAction, Error)
+
+	// ImportMastodon copies accounts, the users of local accounts, and follows from the given Mastodon database into this one,
+	// for instances migrating from Mastodon. Rows are streamed from source and written in batches, one transaction per batch,
+	// with Mastodon's numeric IDs remapped to ULIDs. Accounts that an earlier import already put here are reused, while
+	// other accounts with the same username and domain are left alone. Rows that can't be imported, such as follows of
+	// accounts that weren't, are logged and skipped. Statuses are not imported.
+	//
+	// If progress is not nil, it's called after every batch with the running totals.
+	ImportMastodon(ctx context.Context, source *sql.DB, progress func(MastodonImportProgress)) Error
+}
+
+// MastodonImportProgress describes how far ImportMastodon has got.
+type MastodonImportProgress struct {
+	// Accounts is how many accounts have been imported, or matched to existing accounts.
+	Accounts int
+	// Users is how many users of local accounts have been imported.
+	Users int
+	// Follows is how many follows have been imported, or were already present.
+	Follows int
+	// Skipped is how many rows couldn't be imported.
+	Skipped int
+}
+
+// AdminActionFilter narrows down which moderation actions GetAdminActions returns.
+// Zero fields are ignored.
+type AdminActionFilter struct {
+	// AccountID only includes actions taken by this moderator.
+	AccountID string
+	// Type only includes actions of this type.
+	Type gtsmodel.AdminActionType
+	// Since only includes actions taken at or after this time.
+	Since time.Time
+	// Until only includes actions taken before this time.
+	Until time.Time
 }
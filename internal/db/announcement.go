@@ -0,0 +1,57 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+// Announcement contains functions for creating, getting, and reading instance announcements.
+type Announcement interface {
+	// GetAnnouncement returns the announcement with the given id.
+	GetAnnouncement(ctx context.Context, id string) (*gtsmodel.Announcement, Error)
+
+	// GetAnnouncements returns all announcements, published or not, ordered by ID descending.
+	// This is intended for admins managing announcements. If there are none, ErrNoEntries will be returned.
+	GetAnnouncements(ctx context.Context) ([]*gtsmodel.Announcement, Error)
+
+	// GetActiveAnnouncements returns published announcements that should be shown at the given time,
+	// ie., announcements that have started but not yet ended, ordered by ID descending.
+	// If there are none, ErrNoEntries will be returned.
+	GetActiveAnnouncements(ctx context.Context, now time.Time) ([]*gtsmodel.Announcement, Error)
+
+	// PutAnnouncement stores a new announcement.
+	PutAnnouncement(ctx context.Context, announcement *gtsmodel.Announcement) Error
+
+	// UpdateAnnouncement updates an existing announcement, setting its updated_at time to now.
+	UpdateAnnouncement(ctx context.Context, announcement *gtsmodel.Announcement) Error
+
+	// DeleteAnnouncement deletes the announcement with the given id, along with any record of who has read it.
+	DeleteAnnouncement(ctx context.Context, id string) Error
+
+	// MarkAnnouncementRead marks the given announcement as read by the given account.
+	// Marking an announcement as read more than once is not an error.
+	MarkAnnouncementRead(ctx context.Context, announcementID string, accountID string) Error
+
+	// IsAnnouncementRead returns true if the given account has marked the given announcement as read.
+	IsAnnouncementRead(ctx context.Context, announcementID string, accountID string) (bool, Error)
+}
@@ -62,6 +62,11 @@ type Basic interface {
 	// The given interface i will be set to the result of the query, whatever it is. Use a pointer or a slice.
 	Put(ctx context.Context, i interface{}) Error
 
+	// PutIfNew stores i like Put does, unless it's already stored: for models that have a uri, that means a row with the
+	// same uri exists, otherwise a row with the same primary key. It returns whether i was actually stored, so that
+	// callers handling the same thing more than once (eg., retried federated activities) can skip any side effects.
+	PutIfNew(ctx context.Context, i interface{}) (bool, Error)
+
 	// UpdateByPrimaryKey updates all values of i based on its primary key.
 	// The given interface i will be set to the result of the query, whatever it is. Use a pointer or a slice.
 	UpdateByPrimaryKey(ctx context.Context, i interface{}) Error
@@ -76,4 +81,11 @@ type Basic interface {
 	// DeleteWhere deletes i where key = value
 	// If i didn't exist anyway, then no error should be returned.
 	DeleteWhere(ctx context.Context, where []Where, i interface{}) Error
+
+	// SetReadOnly turns read-only mode on or off. While read-only mode is on, reads work as normal,
+	// but any function that would write to the database returns ErrReadOnly without touching the database.
+	SetReadOnly(readOnly bool)
+
+	// IsReadOnly returns true if the database is currently in read-only mode.
+	IsReadOnly() bool
 }
@@ -20,8 +20,11 @@ package bundb
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -29,6 +32,7 @@ import (
 	"github.com/superseriousbusiness/gotosocial/internal/config"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
 	"github.com/uptrace/bun"
 )
 
@@ -98,10 +102,32 @@ func (a *accountDB) getAccount(ctx context.Context, cacheGet func() (*gtsmodel.A
 		a.cache.Put(account)
 	}
 
+	if account.MovedToAccountID != "" {
+		// only go one level deep here, in case accounts have moved to each other
+		movedTo, cached := a.cache.GetByID(account.MovedToAccountID)
+		if !cached {
+			movedTo = &gtsmodel.Account{}
+			err := a.newAccountQ(movedTo).Where("account.id = ?", account.MovedToAccountID).Scan(ctx)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, a.conn.ProcessError(err)
+			}
+			if err == nil {
+				a.cache.Put(movedTo)
+			}
+		}
+		if movedTo.ID != "" {
+			account.MovedToAccount = movedTo
+		}
+	}
+
 	return account, nil
 }
 
 func (a *accountDB) UpdateAccount(ctx context.Context, account *gtsmodel.Account) (*gtsmodel.Account, db.Error) {
+	if err := a.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
 	// Update the account's last-updated
 	account.UpdatedAt = time.Now()
 
@@ -162,6 +188,10 @@ func (a *accountDB) GetAccountLastPosted(ctx context.Context, accountID string)
 }
 
 func (a *accountDB) SetAccountHeaderOrAvatar(ctx context.Context, mediaAttachment *gtsmodel.MediaAttachment, accountID string) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	if mediaAttachment.Avatar && mediaAttachment.Header {
 		return errors.New("one media attachment cannot be both header and avatar")
 	}
@@ -285,6 +315,92 @@ func (a *accountDB) GetAccountStatuses(ctx context.Context, accountID string, li
 	return statuses, nil
 }
 
+func (a *accountDB) GetAccountPinnedStatuses(ctx context.Context, accountID string) ([]*gtsmodel.Status, db.Error) {
+	statuses := []*gtsmodel.Status{}
+
+	q := a.conn.
+		NewSelect().
+		Model(&statuses).
+		Where("status.account_id = ?", accountID).
+		Where("status.pinned = ?", true).
+		Where("status.federated = ?", true).
+		Where("status.visibility IN (?)", bun.In([]gtsmodel.Visibility{
+			gtsmodel.VisibilityPublic,
+			gtsmodel.VisibilityUnlocked,
+		})).
+		// statuses pinned before pinned_at existed don't have it set,
+		// so fall back to when the status itself was created
+		OrderExpr("COALESCE(?, ?) DESC", bun.Ident("status.pinned_at"), bun.Ident("status.created_at")).
+		Order("status.id DESC")
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(statuses) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return statuses, nil
+}
+
+func (a *accountDB) GetRemoteAccountsWithNoStatuses(ctx context.Context, olderThan time.Time, maxID string, limit int) ([]*gtsmodel.Account, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	accounts := make([]*gtsmodel.Account, 0, limit)
+
+	statusesQ := a.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.account_id = account.id")
+
+	// localFollowsQ returns a query for local accounts following (or
+	// requesting to follow) the account, using the given table
+	localFollowsQ := func(table string) *bun.SelectQuery {
+		return a.conn.
+			NewSelect().
+			TableExpr("? AS ?", bun.Ident(table), bun.Ident("f")).
+			Column("f.id").
+			Join("JOIN ? AS ? ON ? = ?", bun.Ident("accounts"), bun.Ident("follower"), bun.Ident("follower.id"), bun.Ident("f.account_id")).
+			Where("f.target_account_id = account.id").
+			WhereGroup(" AND ", whereEmptyOrNull("follower.domain"))
+	}
+
+	q := a.conn.
+		NewSelect().
+		Model(&accounts).
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("account.domain")).
+		Where("account.created_at < ?", olderThan).
+		Where("NOT EXISTS (?)", statusesQ).
+		// be careful not to return anything local accounts care about
+		Where("NOT EXISTS (?)", localFollowsQ("follows")).
+		Where("NOT EXISTS (?)", localFollowsQ("follow_requests")).
+		Order("account.id DESC")
+
+	if maxID != "" {
+		q = q.Where("account.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return accounts, nil
+}
+
 func (a *accountDB) GetAccountBlocks(ctx context.Context, accountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, db.Error) {
 	blocks := []*gtsmodel.Block{}
 
@@ -324,3 +440,633 @@ func (a *accountDB) GetAccountBlocks(ctx context.Context, accountID string, maxI
 	prevMinID := blocks[0].ID
 	return accounts, nextMaxID, prevMinID, nil
 }
+
+// accountFetchedAtExpr is when an account was last fetched, falling back to when
+// it was last updated for accounts that have never been fetched. It matches the
+// expression of the accounts_fetched_at_idx index, so don't change one without the other.
+const accountFetchedAtExpr = "COALESCE(account.last_webfingered_at, account.updated_at)"
+
+func (a *accountDB) GetStaleRemoteAccounts(ctx context.Context, olderThan time.Time, limit int) ([]*gtsmodel.Account, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	accounts := make([]*gtsmodel.Account, 0, limit)
+
+	q := a.conn.
+		NewSelect().
+		Model(&accounts).
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("account.domain")).
+		Where(accountFetchedAtExpr+" < ?", olderThan).
+		OrderExpr(accountFetchedAtExpr + " ASC").
+		Order("account.id ASC")
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return accounts, nil
+}
+
+func (a *accountDB) SetAccountFetchedAt(ctx context.Context, accountID string, fetchedAt time.Time) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if _, err := a.conn.
+		NewUpdate().
+		Model((*gtsmodel.Account)(nil)).
+		Set("last_webfingered_at = ?", fetchedAt).
+		Where("id = ?", accountID).
+		Exec(ctx); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	// Update the cached copy too, if there is one
+	if account, ok := a.cache.GetByID(accountID); ok {
+		account.LastWebfingeredAt = fetchedAt
+		a.cache.Put(account)
+	}
+
+	return nil
+}
+
+func (a *accountDB) GetInactiveLocalAccounts(ctx context.Context, createdBefore time.Time, limit int) ([]*gtsmodel.Account, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	accounts := make([]*gtsmodel.Account, 0, limit)
+
+	statusesQ := a.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.account_id = account.id")
+
+	// only accounts with a user can have signed in; this
+	// also keeps the instance account out of the results
+	usersQ := a.conn.
+		NewSelect().
+		Model((*gtsmodel.User)(nil)).
+		Column("user.account_id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereOr("? IS NULL", bun.Ident("user.current_sign_in_at")).
+				WhereOr("? < ?", bun.Ident("user.current_sign_in_at"), createdBefore)
+		}).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereOr("? IS NULL", bun.Ident("user.last_sign_in_at")).
+				WhereOr("? < ?", bun.Ident("user.last_sign_in_at"), createdBefore)
+		})
+
+	q := a.conn.
+		NewSelect().
+		Model(&accounts).
+		WhereGroup(" AND ", whereEmptyOrNull("account.domain")).
+		Where("account.created_at < ?", createdBefore).
+		Where("NOT EXISTS (?)", statusesQ).
+		Where("account.id IN (?)", usersQ).
+		Order("account.id ASC")
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return accounts, nil
+}
+
+func (a *accountDB) GetAccountsByRole(ctx context.Context, role string, maxID string, limit int) ([]*gtsmodel.Account, db.Error) {
+	// user is a reserved word in postgres, so columns
+	// of the users table always need to be quoted
+	usersQ := a.conn.
+		NewSelect().
+		Model((*gtsmodel.User)(nil)).
+		Column("user.account_id")
+
+	switch role {
+	case db.AccountRoleAdmin:
+		usersQ = usersQ.Where("? = ?", bun.Ident("user.admin"), true)
+	case db.AccountRoleModerator:
+		usersQ = usersQ.
+			Where("? = ?", bun.Ident("user.moderator"), true).
+			Where("? = ?", bun.Ident("user.admin"), false)
+	case db.AccountRoleUser:
+		usersQ = usersQ.
+			Where("? = ?", bun.Ident("user.moderator"), false).
+			Where("? = ?", bun.Ident("user.admin"), false)
+	default:
+		return nil, fmt.Errorf("account role %s not recognised", role)
+	}
+
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	accounts := make([]*gtsmodel.Account, 0, limit)
+
+	q := a.conn.
+		NewSelect().
+		Model(&accounts).
+		Where("account.id IN (?)", usersQ).
+		Order("account.id DESC")
+
+	if maxID != "" {
+		q = q.Where("account.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return accounts, nil
+}
+
+func (a *accountDB) GetSuspendedAccounts(ctx context.Context, maxID string, limit int) ([]*db.SuspendedAccount, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	accounts := make([]*gtsmodel.Account, 0, limit)
+
+	q := a.conn.
+		NewSelect().
+		Model(&accounts).
+		Where("? IS NOT NULL", bun.Ident("account.suspended_at")).
+		Order("account.id DESC")
+
+	if maxID != "" {
+		q = q.Where("account.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(accounts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	// the suspension origin may be either an account or a domain
+	// block, so just look for each origin ID in both tables
+	originIDs := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if account.SuspensionOrigin != "" {
+			originIDs = append(originIDs, account.SuspensionOrigin)
+		}
+	}
+
+	originAccounts := map[string]*gtsmodel.Account{}
+	originBlocks := map[string]*gtsmodel.DomainBlock{}
+
+	if len(originIDs) > 0 {
+		moderators := []*gtsmodel.Account{}
+		if err := a.conn.
+			NewSelect().
+			Model(&moderators).
+			Where("account.id IN (?)", bun.In(originIDs)).
+			Scan(ctx); err != nil {
+			return nil, a.conn.ProcessError(err)
+		}
+		for _, moderator := range moderators {
+			originAccounts[moderator.ID] = moderator
+		}
+
+		blocks := []*gtsmodel.DomainBlock{}
+		if err := a.conn.
+			NewSelect().
+			Model(&blocks).
+			Where("domain_block.id IN (?)", bun.In(originIDs)).
+			Scan(ctx); err != nil {
+			return nil, a.conn.ProcessError(err)
+		}
+		for _, block := range blocks {
+			originBlocks[block.ID] = block
+		}
+	}
+
+	suspended := make([]*db.SuspendedAccount, 0, len(accounts))
+	for _, account := range accounts {
+		suspended = append(suspended, &db.SuspendedAccount{
+			Account:     account,
+			SuspendedBy: originAccounts[account.SuspensionOrigin],
+			DomainBlock: originBlocks[account.SuspensionOrigin],
+		})
+	}
+
+	return suspended, nil
+}
+
+func (a *accountDB) UnsuspendAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, db.Error) {
+	if err := a.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	account, err := a.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.SuspendedAt.IsZero() {
+		return nil, fmt.Errorf("account %s is not suspended", accountID)
+	}
+
+	account.SuspendedAt = time.Time{}
+	account.SuspensionOrigin = ""
+	account.UnsuspendedAt = time.Now()
+	account.UnsuspensionOrigin = moderatorID
+	account.UpdatedAt = account.UnsuspendedAt
+
+	if err := a.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		if _, err := tx.
+			NewUpdate().
+			Model(account).
+			Column("suspended_at", "suspension_origin", "unsuspended_at", "unsuspension_origin", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertAdminAction(ctx, tx, &gtsmodel.AdminAction{
+			AccountID:       moderatorID,
+			Type:            gtsmodel.AdminActionUnsuspend,
+			TargetAccountID: accountID,
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	// Place updated account in cache
+	// (this will replace existing, i.e. invalidating)
+	a.cache.Put(account)
+
+	return account, nil
+}
+
+func (a *accountDB) SilenceAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, db.Error) {
+	return a.setSilencedAt(ctx, accountID, moderatorID, time.Now())
+}
+
+func (a *accountDB) UnsilenceAccount(ctx context.Context, accountID string, moderatorID string) (*gtsmodel.Account, db.Error) {
+	return a.setSilencedAt(ctx, accountID, moderatorID, time.Time{})
+}
+
+// setSilencedAt sets the silenced_at of the given account, where a zero time means not silenced,
+// and records in the audit log that moderatorID did so.
+func (a *accountDB) setSilencedAt(ctx context.Context, accountID string, moderatorID string, silencedAt time.Time) (*gtsmodel.Account, db.Error) {
+	if err := a.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	account, err := a.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	account.SilencedAt = silencedAt
+	account.UpdatedAt = time.Now()
+
+	actionType := gtsmodel.AdminActionSilence
+	if silencedAt.IsZero() {
+		actionType = gtsmodel.AdminActionUnsilence
+	}
+
+	if err := a.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		if _, err := tx.
+			NewUpdate().
+			Model(account).
+			Column("silenced_at", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertAdminAction(ctx, tx, &gtsmodel.AdminAction{
+			AccountID:       moderatorID,
+			Type:            actionType,
+			TargetAccountID: accountID,
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	// Place updated account in cache
+	// (this will replace existing, i.e. invalidating)
+	a.cache.Put(account)
+
+	return account, nil
+}
+
+// userIDQ returns a query for the user ID of the given local account.
+func (a *accountDB) userIDQ(accountID string) *bun.SelectQuery {
+	return a.conn.
+		NewSelect().
+		Model((*gtsmodel.User)(nil)).
+		Column("user.id").
+		// "user" is a reserved word in postgres, so quote it
+		Where("? = ?", bun.Ident("user.account_id"), accountID)
+}
+
+func (a *accountDB) GetGrantedApplications(ctx context.Context, accountID string) ([]*db.GrantedApplication, db.Error) {
+	tokens := []*gtsmodel.Token{}
+	if err := a.conn.
+		NewSelect().
+		Model(&tokens).
+		Column("token.client_id", "token.scope", "token.created_at", "token.access_create_at").
+		Where("token.user_id = (?)", a.userIDQ(accountID)).
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("token.access")).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereOr("token.access_expires_at IS NULL").
+				WhereOr("token.access_expires_at > ?", time.Now())
+		}).
+		Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	// collapse the tokens down to one entry per client
+	byClientID := map[string]*db.GrantedApplication{}
+	scopes := map[string]map[string]bool{}
+	for _, token := range tokens {
+		g, ok := byClientID[token.ClientID]
+		if !ok {
+			g = &db.GrantedApplication{}
+			byClientID[token.ClientID] = g
+			scopes[token.ClientID] = map[string]bool{}
+		}
+
+		g.Tokens++
+
+		authorizedAt := token.AccessCreateAt
+		if authorizedAt.IsZero() {
+			authorizedAt = token.CreatedAt
+		}
+		if authorizedAt.After(g.LastAuthorizedAt) {
+			g.LastAuthorizedAt = authorizedAt
+		}
+
+		for _, scope := range strings.Fields(token.Scope) {
+			scopes[token.ClientID][scope] = true
+		}
+	}
+
+	if len(byClientID) == 0 {
+		return []*db.GrantedApplication{}, nil
+	}
+
+	clientIDs := make([]string, 0, len(byClientID))
+	for clientID := range byClientID {
+		clientIDs = append(clientIDs, clientID)
+	}
+
+	apps := []*gtsmodel.Application{}
+	if err := a.conn.
+		NewSelect().
+		Model(&apps).
+		Where("application.client_id IN (?)", bun.In(clientIDs)).
+		Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	for _, app := range apps {
+		byClientID[app.ClientID].Application = app
+	}
+
+	// drop tokens left over from applications that are gone
+	withApps := make([]*db.GrantedApplication, 0, len(byClientID))
+	for clientID, g := range byClientID {
+		if g.Application == nil {
+			continue
+		}
+		for scope := range scopes[clientID] {
+			g.Scopes = append(g.Scopes, scope)
+		}
+		sort.Strings(g.Scopes)
+		withApps = append(withApps, g)
+	}
+
+	sort.Slice(withApps, func(i, j int) bool {
+		if !withApps[i].LastAuthorizedAt.Equal(withApps[j].LastAuthorizedAt) {
+			return withApps[i].LastAuthorizedAt.After(withApps[j].LastAuthorizedAt)
+		}
+		return withApps[i].Application.ID > withApps[j].Application.ID
+	})
+
+	return withApps, nil
+}
+
+func (a *accountDB) RevokeGrantedApplication(ctx context.Context, accountID string, clientID string) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := a.conn.
+		NewDelete().
+		Model((*gtsmodel.Token)(nil)).
+		Where("user_id = (?)", a.userIDQ(accountID)).
+		Where("client_id = ?", clientID).
+		Exec(ctx)
+	return a.conn.ProcessError(err)
+}
+
+func (a *accountDB) GetAccountFields(ctx context.Context, accountID string) ([]gtsmodel.Field, db.Error) {
+	account, err := a.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// fields are stored as one json array, so they
+	// come back in the same order they were put in
+	fields := make([]gtsmodel.Field, len(account.Fields))
+	copy(fields, account.Fields)
+	return fields, nil
+}
+
+func (a *accountDB) ReplaceAccountFields(ctx context.Context, accountID string, fields []gtsmodel.Field) db.Error {
+	if len(fields) > db.MaxAccountFields {
+		return db.ErrTooManyAccountFields
+	}
+
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	account, err := a.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	account.Fields = make([]gtsmodel.Field, len(fields))
+	copy(account.Fields, fields)
+	account.UpdatedAt = time.Now()
+
+	if _, err := a.conn.
+		NewUpdate().
+		Model(account).
+		Column("fields", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	// Place updated account in cache
+	// (this will replace existing, i.e. invalidating)
+	a.cache.Put(account)
+
+	return nil
+}
+
+func (a *accountDB) AddAccountAlias(ctx context.Context, accountID string, aliasAccountID string) db.Error {
+	if accountID == aliasAccountID {
+		return fmt.Errorf("account %s can't be an alias of itself", accountID)
+	}
+
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	aliasID, err := id.NewULID()
+	if err != nil {
+		return err
+	}
+
+	if _, err := insertIfNew(ctx, a.conn, &gtsmodel.AccountAlias{
+		ID:             aliasID,
+		AccountID:      accountID,
+		AliasAccountID: aliasAccountID,
+	}, "account_id", "alias_account_id"); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (a *accountDB) GetAccountAliases(ctx context.Context, accountID string) ([]*gtsmodel.Account, db.Error) {
+	aliasAccountIDs := []string{}
+
+	if err := a.conn.
+		NewSelect().
+		Model((*gtsmodel.AccountAlias)(nil)).
+		Column("account_alias.alias_account_id").
+		Where("account_alias.account_id = ?", accountID).
+		Order("account_alias.id ASC").
+		Scan(ctx, &aliasAccountIDs); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	aliases := make([]*gtsmodel.Account, 0, len(aliasAccountIDs))
+	for _, aliasAccountID := range aliasAccountIDs {
+		alias, err := a.GetAccountByID(ctx, aliasAccountID)
+		if err != nil {
+			if err == db.ErrNoEntries {
+				// the alias has been deleted since
+				continue
+			}
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, nil
+}
+
+func (a *accountDB) SetAccountMovedTo(ctx context.Context, accountID string, targetAccountID string) (*gtsmodel.Account, db.Error) {
+	if err := a.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	account, err := a.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *gtsmodel.Account
+	if targetAccountID != "" {
+		// both accounts have to vouch for each other, or anyone could claim to be anyone
+		mutual, err := a.conn.
+			NewSelect().
+			Model((*gtsmodel.AccountAlias)(nil)).
+			WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.
+					Where("account_alias.account_id = ?", accountID).
+					Where("account_alias.alias_account_id = ?", targetAccountID)
+			}).
+			WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.
+					Where("account_alias.account_id = ?", targetAccountID).
+					Where("account_alias.alias_account_id = ?", accountID)
+			}).
+			Count(ctx)
+		if err != nil {
+			return nil, a.conn.ProcessError(err)
+		}
+		if mutual != 2 {
+			return nil, db.ErrAccountAliasNotMutual
+		}
+
+		target, err = a.GetAccountByID(ctx, targetAccountID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	account.MovedToAccountID = targetAccountID
+	account.MovedToAccount = target
+	account.MovedAt = time.Time{}
+	if target != nil {
+		account.MovedAt = time.Now()
+	}
+	account.UpdatedAt = time.Now()
+
+	if _, err := a.conn.
+		NewUpdate().
+		Model(account).
+		Column("moved_to_account_id", "moved_at", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	// Place updated account in cache
+	// (this will replace existing, i.e. invalidating)
+	a.cache.Put(account)
+
+	return account, nil
+}
@@ -22,12 +22,16 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
 	"github.com/superseriousbusiness/gotosocial/internal/ap"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
 )
 
 type AccountTestSuite struct {
@@ -88,6 +92,520 @@ func (suite *AccountTestSuite) TestInsertAccountWithDefaults() {
 	suite.False(newAccount.HideCollections)
 }
 
+func (suite *AccountTestSuite) TestGetRemoteAccountsWithNoStatuses() {
+	accounts, err := suite.db.GetRemoteAccountsWithNoStatuses(context.Background(), time.Now(), "", 0)
+	suite.NoError(err)
+	suite.NotEmpty(accounts)
+
+	for _, account := range accounts {
+		suite.NotEmpty(account.Domain)
+
+		count, err := suite.db.CountAccountStatuses(context.Background(), account.ID)
+		suite.NoError(err)
+		suite.Zero(count)
+	}
+
+	// once a local account follows one of them, it shouldn't be returned anymore
+	followed := accounts[0]
+	err = suite.db.Put(context.Background(), &gtsmodel.Follow{
+		ID:              "01FVB8X9EAFD6N5CNP2J7WGZVT",
+		AccountID:       suite.testAccounts["local_account_1"].ID,
+		TargetAccountID: followed.ID,
+		URI:             "http://localhost:8080/users/the_mighty_zork/follow/01FVB8X9EAFD6N5CNP2J7WGZVT",
+	})
+	suite.NoError(err)
+
+	accounts, err = suite.db.GetRemoteAccountsWithNoStatuses(context.Background(), time.Now(), "", 0)
+	if err != db.ErrNoEntries {
+		suite.NoError(err)
+	}
+	for _, account := range accounts {
+		suite.NotEqual(followed.ID, account.ID)
+	}
+}
+
+func (suite *AccountTestSuite) TestGetRemoteAccountsWithNoStatusesCutoff() {
+	// nothing's that old in the test data
+	accounts, err := suite.db.GetRemoteAccountsWithNoStatuses(context.Background(), time.Unix(0, 0), "", 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(accounts)
+}
+
+func (suite *AccountTestSuite) TestGetInactiveLocalAccounts() {
+	ctx := context.Background()
+	now := time.Now()
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	longAgo := cutoff.Add(-24 * time.Hour)
+
+	// putLocalAccount stores a local account created at createdAt,
+	// with a user that last signed in at signedInAt (if ever)
+	putLocalAccount := func(username string, createdAt time.Time, signedInAt time.Time) *gtsmodel.Account {
+		accountID, err := id.NewULIDFromTime(createdAt)
+		suite.NoError(err)
+		userID, err := id.NewULID()
+		suite.NoError(err)
+
+		account := &gtsmodel.Account{
+			ID:           accountID,
+			CreatedAt:    createdAt,
+			Username:     username,
+			URI:          "http://localhost:8080/users/" + username,
+			ActorType:    ap.ActorPerson,
+			PublicKeyURI: "http://localhost:8080/users/" + username + "#main-key",
+		}
+		suite.NoError(suite.db.Put(ctx, account))
+		suite.NoError(suite.db.Put(ctx, &gtsmodel.User{
+			ID:                userID,
+			AccountID:         account.ID,
+			EncryptedPassword: "hunter2",
+			CurrentSignInAt:   signedInAt,
+		}))
+		return account
+	}
+
+	dormant := putLocalAccount("dormant", longAgo, time.Time{})
+	dormantSignedIn := putLocalAccount("dormant_signed_in", longAgo.Add(time.Second), longAgo.Add(time.Hour))
+	recentLogin := putLocalAccount("recent_login", longAgo.Add(2*time.Second), now.Add(-time.Hour))
+	newcomer := putLocalAccount("newcomer", now.Add(-time.Hour), time.Time{})
+	poster := putLocalAccount("poster", longAgo.Add(3*time.Second), time.Time{})
+	statusID, err := id.NewULID()
+	suite.NoError(err)
+	suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+		ID:                  statusID,
+		URI:                 poster.URI + "/statuses/" + statusID,
+		AccountURI:          poster.URI,
+		AccountID:           poster.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: ap.ObjectNote,
+	}))
+
+	accounts, err := suite.db.GetInactiveLocalAccounts(ctx, cutoff, 0)
+	suite.NoError(err)
+
+	ids := []string{}
+	for _, account := range accounts {
+		suite.Empty(account.Domain)
+		ids = append(ids, account.ID)
+	}
+	suite.Contains(ids, dormant.ID)
+	suite.Contains(ids, dormantSignedIn.ID)
+	for _, active := range []*gtsmodel.Account{recentLogin, newcomer, poster} {
+		suite.NotContains(ids, active.ID, active.Username)
+	}
+
+	// oldest first
+	suite.True(sort.StringsAreSorted(ids))
+
+	accounts, err = suite.db.GetInactiveLocalAccounts(ctx, cutoff, 1)
+	suite.NoError(err)
+	suite.Len(accounts, 1)
+
+	// nothing's that old
+	_, err = suite.db.GetInactiveLocalAccounts(ctx, time.Unix(0, 0), 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *AccountTestSuite) TestGetStaleRemoteAccounts() {
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	// only remote_account_1 hasn't been touched in the last day;
+	// local accounts older than that shouldn't show up at all
+	accounts, err := suite.db.GetStaleRemoteAccounts(context.Background(), cutoff, 0)
+	suite.NoError(err)
+	suite.Len(accounts, 1)
+	suite.Equal(suite.testAccounts["remote_account_1"].ID, accounts[0].ID)
+
+	// everything remote is stale if the cutoff is now
+	accounts, err = suite.db.GetStaleRemoteAccounts(context.Background(), time.Now(), 0)
+	suite.NoError(err)
+	suite.Len(accounts, 2)
+	for _, account := range accounts {
+		suite.NotEmpty(account.Domain)
+	}
+	suite.Equal(suite.testAccounts["remote_account_1"].ID, accounts[0].ID)
+
+	accounts, err = suite.db.GetStaleRemoteAccounts(context.Background(), time.Now(), 1)
+	suite.NoError(err)
+	suite.Len(accounts, 1)
+}
+
+func (suite *AccountTestSuite) TestGetStaleRemoteAccountsTiedFetchedAt() {
+	ctx := context.Background()
+	fetchedAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	remoteIDs := []string{}
+	for _, account := range suite.testAccounts {
+		if account.Domain != "" {
+			suite.NoError(suite.db.SetAccountFetchedAt(ctx, account.ID, fetchedAt))
+			remoteIDs = append(remoteIDs, account.ID)
+		}
+	}
+	suite.Greater(len(remoteIDs), 1)
+	sort.Strings(remoteIDs)
+
+	// work through the stale accounts one at a time, the way a
+	// refetch worker would; ties are broken by id, so none of
+	// them should be skipped or come up twice
+	seen := []string{}
+	for range remoteIDs {
+		accounts, err := suite.db.GetStaleRemoteAccounts(ctx, time.Now().Add(-24*time.Hour), 1)
+		suite.NoError(err)
+		suite.Len(accounts, 1)
+		seen = append(seen, accounts[0].ID)
+		suite.NoError(suite.db.SetAccountFetchedAt(ctx, accounts[0].ID, time.Now()))
+	}
+	suite.Equal(remoteIDs, seen)
+
+	_, err := suite.db.GetStaleRemoteAccounts(ctx, time.Now().Add(-24*time.Hour), 1)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *AccountTestSuite) TestSetAccountFetchedAt() {
+	testAccount := suite.testAccounts["remote_account_1"]
+	cutoff := time.Now().Add(-24 * time.Hour)
+
+	err := suite.db.SetAccountFetchedAt(context.Background(), testAccount.ID, time.Now())
+	suite.NoError(err)
+
+	accounts, err := suite.db.GetStaleRemoteAccounts(context.Background(), cutoff, 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(accounts)
+
+	account, err := suite.db.GetAccountByID(context.Background(), testAccount.ID)
+	suite.NoError(err)
+	suite.WithinDuration(time.Now(), account.LastWebfingeredAt, time.Minute)
+}
+
+func (suite *AccountTestSuite) TestSuspendedAccountsLifecycle() {
+	ctx := context.Background()
+	moderator := suite.testAccounts["admin_account"]
+
+	// nobody is suspended to begin with
+	_, err := suite.db.GetSuspendedAccounts(ctx, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	account := &gtsmodel.Account{}
+	*account = *suite.testAccounts["remote_account_1"]
+	account.SuspendedAt = time.Now()
+	account.SuspensionOrigin = moderator.ID
+	_, err = suite.db.UpdateAccount(ctx, account)
+	suite.NoError(err)
+
+	suspended, err := suite.db.GetSuspendedAccounts(ctx, "", 10)
+	suite.NoError(err)
+	suite.Len(suspended, 1)
+	suite.Equal(account.ID, suspended[0].Account.ID)
+	suite.Equal(moderator.ID, suspended[0].SuspendedBy.ID)
+	suite.Nil(suspended[0].DomainBlock)
+
+	// paging past the only suspended account gives nothing
+	_, err = suite.db.GetSuspendedAccounts(ctx, account.ID, 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	unsuspended, err := suite.db.UnsuspendAccount(ctx, account.ID, moderator.ID)
+	suite.NoError(err)
+	suite.True(unsuspended.SuspendedAt.IsZero())
+	suite.Empty(unsuspended.SuspensionOrigin)
+	suite.WithinDuration(time.Now(), unsuspended.UnsuspendedAt, time.Minute)
+	suite.Equal(moderator.ID, unsuspended.UnsuspensionOrigin)
+
+	// the change should be stored, not just cached
+	dbAccount := &gtsmodel.Account{}
+	err = suite.db.GetByID(ctx, account.ID, dbAccount)
+	suite.NoError(err)
+	suite.True(dbAccount.SuspendedAt.IsZero())
+	suite.Equal(moderator.ID, dbAccount.UnsuspensionOrigin)
+
+	_, err = suite.db.GetSuspendedAccounts(ctx, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// can't lift a suspension twice
+	_, err = suite.db.UnsuspendAccount(ctx, account.ID, moderator.ID)
+	suite.Error(err)
+}
+
+func (suite *AccountTestSuite) TestGetAccountPinnedStatuses() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_2"]
+	now := time.Now()
+
+	for _, pin := range []struct {
+		id         string
+		visibility gtsmodel.Visibility
+		federated  bool
+		pinned     bool
+		pinnedAt   time.Time
+	}{
+		{"01FW3E4S0J6YQY4VXP2K0T7H1A", gtsmodel.VisibilityPublic, true, true, now.Add(-1 * time.Hour)},
+		// pinned before pin times were recorded
+		{"01FW3E5B8DGN3M0N1H0Q5C4W2B", gtsmodel.VisibilityPublic, true, true, time.Time{}},
+		{"01FW3E5RZ4CY8S6V2EYJ7A9N3C", gtsmodel.VisibilityUnlocked, true, true, now.Add(-30 * time.Minute)},
+		{"01FW3E64JM2T4WDXB1A0F6R84D", gtsmodel.VisibilityFollowersOnly, true, true, now},
+		{"01FW3E6H1Q9R7P5KZ3V7B2D35E", gtsmodel.VisibilityPublic, false, true, now},
+		{"01FW3E6W6X0B3SJK9H8N4E5G6F", gtsmodel.VisibilityPublic, true, false, time.Time{}},
+	} {
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  pin.id,
+			URI:                 account.URI + "/statuses/" + pin.id,
+			Text:                "status " + pin.id,
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          pin.visibility,
+			Federated:           pin.federated,
+			Pinned:              pin.pinned,
+			PinnedAt:            pin.pinnedAt,
+			CreatedAt:           now.Add(-24 * time.Hour),
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	statuses, err := suite.db.GetAccountPinnedStatuses(ctx, account.ID)
+	suite.NoError(err)
+
+	ids := []string{}
+	for _, s := range statuses {
+		ids = append(ids, s.ID)
+	}
+
+	// most recently pinned first, followers-only, local-only
+	// and unpinned statuses left out altogether
+	suite.Equal([]string{
+		"01FW3E5RZ4CY8S6V2EYJ7A9N3C",
+		"01FW3E4S0J6YQY4VXP2K0T7H1A",
+		"01FW3E5B8DGN3M0N1H0Q5C4W2B",
+	}, ids)
+
+	_, err = suite.db.GetAccountPinnedStatuses(ctx, suite.testAccounts["remote_account_1"].ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *AccountTestSuite) TestGetGrantedApplications() {
+	testAccount := suite.testAccounts["local_account_1"]
+	testUser := suite.testUsers["local_account_1"]
+	app1 := suite.testApplications["application_1"]
+	app2 := suite.testApplications["application_2"]
+
+	// local_account_1 already has one token from application_1;
+	// give it a couple more, plus some from application_2
+	for _, token := range []*gtsmodel.Token{
+		{
+			ID:              "01FVWFQ3SVA2PQ1GQ5QD4F9R7S",
+			ClientID:        app1.ClientID,
+			UserID:          testUser.ID,
+			RedirectURI:     "http://localhost:8080",
+			Scope:           "read",
+			Access:          "01FVWFQ3SVA2PQ1GQ5QD4F9R7S",
+			AccessCreateAt:  time.Now().Add(-48 * time.Hour),
+			AccessExpiresAt: time.Now().Add(24 * time.Hour),
+		},
+		{
+			ID:             "01FVWFQBEJ3JSXK29T1MQN6WMR",
+			ClientID:       app1.ClientID,
+			UserID:         testUser.ID,
+			RedirectURI:    "http://localhost:8080",
+			Scope:          "admin",
+			Access:         "01FVWFQBEJ3JSXK29T1MQN6WMR",
+			AccessCreateAt: time.Now().Add(-72 * time.Hour),
+		},
+		{
+			ID:              "01FVWFQJ5D1Z3H1HHP7E8C3T6K",
+			ClientID:        app2.ClientID,
+			UserID:          testUser.ID,
+			RedirectURI:     "http://localhost:8080",
+			Scope:           "read",
+			Access:          "01FVWFQJ5D1Z3H1HHP7E8C3T6K",
+			AccessCreateAt:  time.Now().Add(-96 * time.Hour),
+			AccessExpiresAt: time.Now().Add(time.Hour),
+		},
+		{
+			// expired, so doesn't count
+			ID:              "01FVWFQSA1F3J3TTTW63ZCT0S2",
+			ClientID:        app2.ClientID,
+			UserID:          testUser.ID,
+			RedirectURI:     "http://localhost:8080",
+			Scope:           "write",
+			Access:          "01FVWFQSA1F3J3TTTW63ZCT0S2",
+			AccessCreateAt:  time.Now().Add(-2 * time.Hour),
+			AccessExpiresAt: time.Now().Add(-time.Hour),
+		},
+	} {
+		suite.NoError(suite.db.Put(context.Background(), token))
+	}
+
+	granted, err := suite.db.GetGrantedApplications(context.Background(), testAccount.ID)
+	suite.NoError(err)
+	suite.Len(granted, 2)
+
+	// the three application_1 tokens collapse into one entry
+	suite.Equal(app1.ID, granted[0].Application.ID)
+	suite.Equal(3, granted[0].Tokens)
+	suite.Equal([]string{"admin", "follow", "push", "read", "write"}, granted[0].Scopes)
+	suite.WithinDuration(suite.testTokens["local_account_1"].AccessCreateAt, granted[0].LastAuthorizedAt, time.Second)
+
+	suite.Equal(app2.ID, granted[1].Application.ID)
+	suite.Equal(1, granted[1].Tokens)
+	suite.Equal([]string{"read"}, granted[1].Scopes)
+
+	// revoking an application takes it off the list
+	suite.NoError(suite.db.RevokeGrantedApplication(context.Background(), testAccount.ID, app1.ClientID))
+
+	granted, err = suite.db.GetGrantedApplications(context.Background(), testAccount.ID)
+	suite.NoError(err)
+	suite.Len(granted, 1)
+	suite.Equal(app2.ID, granted[0].Application.ID)
+
+	// other accounts' tokens aren't touched
+	granted, err = suite.db.GetGrantedApplications(context.Background(), suite.testAccounts["local_account_2"].ID)
+	suite.NoError(err)
+	suite.Len(granted, 1)
+}
+
+func (suite *AccountTestSuite) TestReplaceAccountFields() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+	verifiedAt := time.Now().Truncate(time.Second)
+
+	fields := []gtsmodel.Field{
+		{Name: "pronouns", Value: "they/them"},
+		{Name: "website", Value: "https://zork.example.org", VerifiedAt: verifiedAt},
+		{Name: "location", Value: "the great underground empire"},
+	}
+	suite.NoError(suite.db.ReplaceAccountFields(ctx, account.ID, fields))
+
+	got, err := suite.db.GetAccountFields(ctx, account.ID)
+	suite.NoError(err)
+	suite.Len(got, 3)
+	for i, field := range fields {
+		suite.Equal(field.Name, got[i].Name)
+		suite.Equal(field.Value, got[i].Value)
+	}
+	suite.True(got[0].VerifiedAt.IsZero())
+	suite.True(got[1].VerifiedAt.Equal(verifiedAt))
+
+	// the fields should be stored, not just cached
+	dbAccount := &gtsmodel.Account{}
+	suite.NoError(suite.db.GetByID(ctx, account.ID, dbAccount))
+	suite.Len(dbAccount.Fields, 3)
+	suite.Equal("location", dbAccount.Fields[2].Name)
+
+	// replacing drops the old fields entirely, and order follows the new slice
+	suite.NoError(suite.db.ReplaceAccountFields(ctx, account.ID, []gtsmodel.Field{fields[2], fields[0]}))
+	got, err = suite.db.GetAccountFields(ctx, account.ID)
+	suite.NoError(err)
+	suite.Len(got, 2)
+	suite.Equal("location", got[0].Name)
+	suite.Equal("pronouns", got[1].Name)
+
+	suite.NoError(suite.db.ReplaceAccountFields(ctx, account.ID, nil))
+	got, err = suite.db.GetAccountFields(ctx, account.ID)
+	suite.NoError(err)
+	suite.Empty(got)
+}
+
+func (suite *AccountTestSuite) TestReplaceAccountFieldsTooMany() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+
+	suite.NoError(suite.db.ReplaceAccountFields(ctx, account.ID, []gtsmodel.Field{{Name: "pronouns", Value: "they/them"}}))
+
+	fields := make([]gtsmodel.Field, db.MaxAccountFields+1)
+	for i := range fields {
+		fields[i] = gtsmodel.Field{Name: fmt.Sprintf("field %d", i), Value: "value"}
+	}
+	err := suite.db.ReplaceAccountFields(ctx, account.ID, fields)
+	suite.ErrorIs(err, db.ErrTooManyAccountFields)
+
+	// the old fields are left alone
+	got, err := suite.db.GetAccountFields(ctx, account.ID)
+	suite.NoError(err)
+	suite.Len(got, 1)
+
+	// exactly the maximum is fine
+	suite.NoError(suite.db.ReplaceAccountFields(ctx, account.ID, fields[:db.MaxAccountFields]))
+}
+
+func (suite *AccountTestSuite) TestGetAccountsByRole() {
+	ctx := context.Background()
+
+	admins, err := suite.db.GetAccountsByRole(ctx, db.AccountRoleAdmin, "", 10)
+	suite.NoError(err)
+	suite.Len(admins, 1)
+	suite.Equal(suite.testAccounts["admin_account"].ID, admins[0].ID)
+
+	// the admin is a moderator too, but only counts as an admin
+	_, err = suite.db.GetAccountsByRole(ctx, db.AccountRoleModerator, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	users, err := suite.db.GetAccountsByRole(ctx, db.AccountRoleUser, "", 10)
+	suite.NoError(err)
+	suite.Len(users, 3)
+	for i, user := range users {
+		suite.NotEqual(admins[0].ID, user.ID)
+		suite.Empty(user.Domain)
+		if i > 0 {
+			suite.Less(user.ID, users[i-1].ID)
+		}
+	}
+
+	paged, err := suite.db.GetAccountsByRole(ctx, db.AccountRoleUser, users[0].ID, 1)
+	suite.NoError(err)
+	suite.Len(paged, 1)
+	suite.Equal(users[1].ID, paged[0].ID)
+
+	_, err = suite.db.GetAccountsByRole(ctx, "overlord", "", 10)
+	suite.Error(err)
+}
+
+func (suite *AccountTestSuite) TestAccountAliasesAndMove() {
+	ctx := context.Background()
+	zork := suite.testAccounts["local_account_1"]
+	remote := suite.testAccounts["remote_account_1"]
+
+	aliases, err := suite.db.GetAccountAliases(ctx, zork.ID)
+	suite.NoError(err)
+	suite.Empty(aliases)
+
+	suite.Error(suite.db.AddAccountAlias(ctx, zork.ID, zork.ID))
+
+	// adding the same alias twice is fine
+	suite.NoError(suite.db.AddAccountAlias(ctx, zork.ID, remote.ID))
+	suite.NoError(suite.db.AddAccountAlias(ctx, zork.ID, remote.ID))
+
+	aliases, err = suite.db.GetAccountAliases(ctx, zork.ID)
+	suite.NoError(err)
+	suite.Len(aliases, 1)
+	suite.Equal(remote.ID, aliases[0].ID)
+
+	// only zork vouches for remote so far, so the move isn't honoured
+	_, err = suite.db.SetAccountMovedTo(ctx, zork.ID, remote.ID)
+	suite.ErrorIs(err, db.ErrAccountAliasNotMutual)
+
+	suite.NoError(suite.db.AddAccountAlias(ctx, remote.ID, zork.ID))
+
+	moved, err := suite.db.SetAccountMovedTo(ctx, zork.ID, remote.ID)
+	suite.NoError(err)
+	suite.Equal(remote.ID, moved.MovedToAccountID)
+	suite.NotNil(moved.MovedToAccount)
+	suite.WithinDuration(time.Now(), moved.MovedAt, time.Minute)
+
+	// reads surface the account moved to, whether from the cache or not
+	account, err := suite.db.GetAccountByID(ctx, zork.ID)
+	suite.NoError(err)
+	suite.Equal(remote.ID, account.MovedToAccount.ID)
+
+	account = &gtsmodel.Account{}
+	suite.NoError(suite.db.GetByID(ctx, zork.ID, account))
+	suite.Equal(remote.ID, account.MovedToAccountID)
+	suite.False(account.MovedAt.IsZero())
+
+	// moving back clears it again
+	moved, err = suite.db.SetAccountMovedTo(ctx, zork.ID, "")
+	suite.NoError(err)
+	suite.Empty(moved.MovedToAccountID)
+	suite.Nil(moved.MovedToAccount)
+	suite.True(moved.MovedAt.IsZero())
+}
+
 func TestAccountTestSuite(t *testing.T) {
 	suite.Run(t, new(AccountTestSuite))
 }
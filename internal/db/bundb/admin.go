@@ -38,6 +38,7 @@ import (
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/internal/id"
 	"github.com/superseriousbusiness/gotosocial/internal/uris"
+	"github.com/uptrace/bun"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -86,6 +87,10 @@ func (a *adminDB) IsEmailAvailable(ctx context.Context, email string) (bool, db.
 }
 
 func (a *adminDB) NewSignup(ctx context.Context, username string, reason string, requireApproval bool, email string, password string, signUpIP net.IP, locale string, appID string, emailVerified bool, admin bool) (*gtsmodel.User, db.Error) {
+	if err := a.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
 		logrus.Errorf("error creating new rsa key: %s", err)
@@ -193,6 +198,10 @@ func (a *adminDB) CreateInstanceAccount(ctx context.Context) db.Error {
 		return nil
 	}
 
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
 		logrus.Errorf("error creating new rsa key: %s", err)
@@ -253,6 +262,10 @@ func (a *adminDB) CreateInstanceInstance(ctx context.Context) db.Error {
 		return nil
 	}
 
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	iID, err := id.NewRandomULID()
 	if err != nil {
 		return err
@@ -277,3 +290,83 @@ func (a *adminDB) CreateInstanceInstance(ctx context.Context) db.Error {
 	logrus.Infof("created instance instance %s with id %s", host, i.ID)
 	return nil
 }
+
+func (a *adminDB) PutAdminAction(ctx context.Context, action *gtsmodel.AdminAction) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if err := insertAdminAction(ctx, a.conn, action); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (a *adminDB) GetAdminActions(ctx context.Context, filter db.AdminActionFilter, maxID string, limit int) ([]*gtsmodel.AdminAction, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	actions := make([]*gtsmodel.AdminAction, 0, limit)
+
+	q := a.conn.
+		NewSelect().
+		Model(&actions).
+		Relation("Account").
+		Order("admin_action.id DESC")
+
+	if maxID != "" {
+		q = q.Where("admin_action.id < ?", maxID)
+	}
+
+	if filter.AccountID != "" {
+		q = q.Where("admin_action.account_id = ?", filter.AccountID)
+	}
+
+	if filter.Type != "" {
+		q = q.Where("admin_action.type = ?", filter.Type)
+	}
+
+	if !filter.Since.IsZero() {
+		q = q.Where("admin_action.created_at >= ?", filter.Since)
+	}
+
+	if !filter.Until.IsZero() {
+		q = q.Where("admin_action.created_at < ?", filter.Until)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(actions) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return actions, nil
+}
+
+// insertAdminAction inserts the given action into the audit log using idb, so that
+// it can be written in the same transaction as the change it records.
+func insertAdminAction(ctx context.Context, idb bun.IDB, action *gtsmodel.AdminAction) error {
+	if action.ID == "" {
+		actionID, err := id.NewULID()
+		if err != nil {
+			return err
+		}
+		action.ID = actionID
+	}
+
+	_, err := idb.
+		NewInsert().
+		Model(action).
+		Exec(ctx)
+	return err
+}
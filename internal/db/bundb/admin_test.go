@@ -21,8 +21,11 @@ package bundb_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/testrig"
 )
 
@@ -51,6 +54,69 @@ func (suite *AdminTestSuite) TestCreateInstanceAccount() {
 	suite.NotNil(acct)
 }
 
+func (suite *AdminTestSuite) TestGetAdminActions() {
+	ctx := context.Background()
+	admin := suite.testAccounts["admin_account"]
+	target := suite.testAccounts["remote_account_1"]
+
+	// nothing has been done yet
+	_, err := suite.db.GetAdminActions(ctx, db.AdminActionFilter{}, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	before := time.Now().Add(-time.Second)
+
+	_, err = suite.db.SilenceAccount(ctx, target.ID, admin.ID)
+	suite.NoError(err)
+	_, err = suite.db.UnsilenceAccount(ctx, target.ID, admin.ID)
+	suite.NoError(err)
+	err = suite.db.PutAdminAction(ctx, &gtsmodel.AdminAction{
+		AccountID:    admin.ID,
+		Type:         gtsmodel.AdminActionDomainBlock,
+		TargetDomain: "example.org",
+		Text:         "spam",
+	})
+	suite.NoError(err)
+
+	// everything comes back newest first, with the moderator attached
+	actions, err := suite.db.GetAdminActions(ctx, db.AdminActionFilter{}, "", 10)
+	suite.NoError(err)
+	suite.Len(actions, 3)
+	types := map[gtsmodel.AdminActionType]*gtsmodel.AdminAction{}
+	for i, action := range actions {
+		if i > 0 {
+			suite.Less(action.ID, actions[i-1].ID)
+		}
+		suite.NotNil(action.Account)
+		suite.Equal(admin.ID, action.Account.ID)
+		types[action.Type] = action
+	}
+	suite.Equal(target.ID, types[gtsmodel.AdminActionSilence].TargetAccountID)
+	suite.Equal(target.ID, types[gtsmodel.AdminActionUnsilence].TargetAccountID)
+	suite.Equal("example.org", types[gtsmodel.AdminActionDomainBlock].TargetDomain)
+
+	// page past the newest
+	paged, err := suite.db.GetAdminActions(ctx, db.AdminActionFilter{}, actions[0].ID, 1)
+	suite.NoError(err)
+	suite.Len(paged, 1)
+	suite.Equal(actions[1].ID, paged[0].ID)
+
+	// filter by type
+	actions, err = suite.db.GetAdminActions(ctx, db.AdminActionFilter{Type: gtsmodel.AdminActionSilence}, "", 10)
+	suite.NoError(err)
+	suite.Len(actions, 1)
+
+	// filter by moderator
+	_, err = suite.db.GetAdminActions(ctx, db.AdminActionFilter{AccountID: suite.testAccounts["local_account_1"].ID}, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// filter by date
+	actions, err = suite.db.GetAdminActions(ctx, db.AdminActionFilter{AccountID: admin.ID, Since: before}, "", 10)
+	suite.NoError(err)
+	suite.Len(actions, 3)
+	_, err = suite.db.GetAdminActions(ctx, db.AdminActionFilter{Until: before}, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
 func TestAdminTestSuite(t *testing.T) {
 	suite.Run(t, new(AdminTestSuite))
 }
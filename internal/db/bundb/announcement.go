@@ -0,0 +1,188 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"time"
+
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
+	"github.com/uptrace/bun"
+)
+
+type announcementDB struct {
+	conn *DBConn
+}
+
+func (a *announcementDB) GetAnnouncement(ctx context.Context, id string) (*gtsmodel.Announcement, db.Error) {
+	announcement := &gtsmodel.Announcement{}
+
+	if err := a.conn.
+		NewSelect().
+		Model(announcement).
+		Where("announcement.id = ?", id).
+		Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	return announcement, nil
+}
+
+func (a *announcementDB) GetAnnouncements(ctx context.Context) ([]*gtsmodel.Announcement, db.Error) {
+	announcements := []*gtsmodel.Announcement{}
+
+	if err := a.conn.
+		NewSelect().
+		Model(&announcements).
+		Order("announcement.id DESC").
+		Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(announcements) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return announcements, nil
+}
+
+func (a *announcementDB) GetActiveAnnouncements(ctx context.Context, now time.Time) ([]*gtsmodel.Announcement, db.Error) {
+	announcements := []*gtsmodel.Announcement{}
+
+	q := a.conn.
+		NewSelect().
+		Model(&announcements).
+		Where("announcement.published = ?", true).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereOr("? IS NULL", bun.Ident("announcement.starts_at")).
+				WhereOr("announcement.starts_at <= ?", now)
+		}).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereOr("? IS NULL", bun.Ident("announcement.ends_at")).
+				WhereOr("announcement.ends_at > ?", now)
+		}).
+		Order("announcement.id DESC")
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, a.conn.ProcessError(err)
+	}
+
+	if len(announcements) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return announcements, nil
+}
+
+func (a *announcementDB) PutAnnouncement(ctx context.Context, announcement *gtsmodel.Announcement) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if _, err := a.conn.
+		NewInsert().
+		Model(announcement).
+		Exec(ctx); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (a *announcementDB) UpdateAnnouncement(ctx context.Context, announcement *gtsmodel.Announcement) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	announcement.UpdatedAt = time.Now()
+
+	if _, err := a.conn.
+		NewUpdate().
+		Model(announcement).
+		WherePK().
+		Exec(ctx); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (a *announcementDB) DeleteAnnouncement(ctx context.Context, id string) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	return a.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		// nobody needs to know who read an announcement that's gone
+		if _, err := tx.
+			NewDelete().
+			Model((*gtsmodel.AnnouncementRead)(nil)).
+			Where("announcement_id = ?", id).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.
+			NewDelete().
+			Model((*gtsmodel.Announcement)(nil)).
+			Where("id = ?", id).
+			Exec(ctx)
+		return err
+	})
+}
+
+func (a *announcementDB) MarkAnnouncementRead(ctx context.Context, announcementID string, accountID string) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	readID, err := id.NewULID()
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.conn.
+		NewInsert().
+		Model(&gtsmodel.AnnouncementRead{
+			ID:             readID,
+			AnnouncementID: announcementID,
+			AccountID:      accountID,
+		}).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx); err != nil {
+		return a.conn.ProcessError(err)
+	}
+
+	return nil
+}
+
+func (a *announcementDB) IsAnnouncementRead(ctx context.Context, announcementID string, accountID string) (bool, db.Error) {
+	q := a.conn.
+		NewSelect().
+		Model((*gtsmodel.AnnouncementRead)(nil)).
+		Column("announcement_read.id").
+		Where("announcement_read.announcement_id = ?", announcementID).
+		Where("announcement_read.account_id = ?", accountID)
+
+	return a.conn.Exists(ctx, q)
+}
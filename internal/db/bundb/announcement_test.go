@@ -0,0 +1,127 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type AnnouncementTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *AnnouncementTestSuite) putAnnouncement(id string, published bool, startsAt time.Time, endsAt time.Time) *gtsmodel.Announcement {
+	announcement := &gtsmodel.Announcement{
+		ID:                 id,
+		Text:               "announcement " + id,
+		StartsAt:           startsAt,
+		EndsAt:             endsAt,
+		Published:          published,
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+	}
+	if err := suite.db.PutAnnouncement(context.Background(), announcement); err != nil {
+		suite.FailNow(err.Error())
+	}
+	return announcement
+}
+
+func (suite *AnnouncementTestSuite) TestGetActiveAnnouncements() {
+	now := time.Now()
+
+	noWindow := suite.putAnnouncement("01FVSQ0XMSH3T4ZQYVQ5A4N9PJ", true, time.Time{}, time.Time{})
+	inWindow := suite.putAnnouncement("01FVSQ19ZQ1WTKTRRVJ0W5KM2C", true, now.Add(-time.Hour), now.Add(time.Hour))
+	notStarted := suite.putAnnouncement("01FVSQ1HQW2YS0PSTT5F9XMZ8X", true, now.Add(time.Hour), time.Time{})
+	suite.putAnnouncement("01FVSQ1RY6PDXVQ51T4Y5DHFZ4", true, time.Time{}, now.Add(-time.Hour)) // already ended
+	suite.putAnnouncement("01FVSQ1ZHC3YJ8KSB8TEJTZ7YK", false, time.Time{}, time.Time{})        // draft
+
+	announcements, err := suite.db.GetActiveAnnouncements(context.Background(), now)
+	suite.NoError(err)
+	suite.Len(announcements, 2)
+	suite.Equal(inWindow.ID, announcements[0].ID)
+	suite.Equal(noWindow.ID, announcements[1].ID)
+
+	// admins can see everything
+	announcements, err = suite.db.GetAnnouncements(context.Background())
+	suite.NoError(err)
+	suite.Len(announcements, 5)
+
+	// a couple of hours later, one window has passed and another has started
+	announcements, err = suite.db.GetActiveAnnouncements(context.Background(), now.Add(2*time.Hour))
+	suite.NoError(err)
+	suite.Len(announcements, 2)
+	suite.Equal(notStarted.ID, announcements[0].ID)
+	suite.Equal(noWindow.ID, announcements[1].ID)
+
+	// unpublishing takes them away again
+	for _, announcement := range []*gtsmodel.Announcement{noWindow, notStarted} {
+		announcement.Published = false
+		err = suite.db.UpdateAnnouncement(context.Background(), announcement)
+		suite.NoError(err)
+	}
+
+	_, err = suite.db.GetActiveAnnouncements(context.Background(), now.Add(2*time.Hour))
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *AnnouncementTestSuite) TestAnnouncementReadState() {
+	announcement := suite.putAnnouncement("01FVSQ2BWJ0JZ2WTG5Q8FZ4PNM", true, time.Time{}, time.Time{})
+	reader := suite.testAccounts["local_account_1"]
+	otherAccount := suite.testAccounts["local_account_2"]
+
+	read, err := suite.db.IsAnnouncementRead(context.Background(), announcement.ID, reader.ID)
+	suite.NoError(err)
+	suite.False(read)
+
+	err = suite.db.MarkAnnouncementRead(context.Background(), announcement.ID, reader.ID)
+	suite.NoError(err)
+
+	// marking it read again should be fine
+	err = suite.db.MarkAnnouncementRead(context.Background(), announcement.ID, reader.ID)
+	suite.NoError(err)
+
+	read, err = suite.db.IsAnnouncementRead(context.Background(), announcement.ID, reader.ID)
+	suite.NoError(err)
+	suite.True(read)
+
+	// read state is per account
+	read, err = suite.db.IsAnnouncementRead(context.Background(), announcement.ID, otherAccount.ID)
+	suite.NoError(err)
+	suite.False(read)
+
+	// deleting the announcement clears up read state too
+	err = suite.db.DeleteAnnouncement(context.Background(), announcement.ID)
+	suite.NoError(err)
+
+	_, err = suite.db.GetAnnouncement(context.Background(), announcement.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	read, err = suite.db.IsAnnouncementRead(context.Background(), announcement.ID, reader.ID)
+	suite.NoError(err)
+	suite.False(read)
+}
+
+func TestAnnouncementTestSuite(t *testing.T) {
+	suite.Run(t, new(AnnouncementTestSuite))
+}
@@ -0,0 +1,154 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// errBackupPostgres is returned when trying to back up a postgres database.
+var errBackupPostgres = errors.New("backing up postgres databases isn't supported, use pg_dump instead")
+
+// Backup writes a consistent snapshot of the configured sqlite database to out, which must not
+// exist yet. The database can be in use by a running instance while the backup is taken.
+func Backup(ctx context.Context, out string) error {
+	if strings.ToLower(viper.GetString(config.Keys.DbType)) == dbTypePostgres {
+		return errBackupPostgres
+	}
+
+	conn, err := openConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return backup(ctx, conn.DB, out)
+}
+
+// backup writes a snapshot of db to out using VACUUM INTO, which reads the whole
+// database in one transaction, so writes made meanwhile don't end up half in the backup.
+func backup(ctx context.Context, db *bun.DB, out string) error {
+	if db.Dialect().Name() != dialect.SQLite {
+		return errBackupPostgres
+	}
+
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("backup destination %s already exists", out)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error checking backup destination %s: %s", out, err)
+	}
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", out); err != nil {
+		return fmt.Errorf("error backing up database to %s: %s", out, err)
+	}
+
+	return nil
+}
+
+// backupScheduler periodically backs up a sqlite database to the same path,
+// replacing the previous backup only once the new one has been written in full.
+type backupScheduler struct {
+	db       *bun.DB
+	path     string
+	interval time.Duration
+
+	// ctx is done once the scheduler is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newBackupScheduler(db *bun.DB, path string, interval time.Duration) *backupScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backupScheduler{
+		db:       db,
+		path:     path,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// start backs up the database every interval in the background, until the scheduler is stopped.
+// It does nothing if the interval is 0, or if the database isn't sqlite, in which case it warns
+// that the interval is being ignored.
+func (b *backupScheduler) start() {
+	if b.interval <= 0 {
+		return
+	}
+
+	if b.db.Dialect().Name() != dialect.SQLite {
+		logrus.Warnf("db-sqlite-backup-interval is set, but scheduled backups only work for sqlite; back up postgres with pg_dump instead")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.backup(); err != nil {
+					logrus.Errorf("scheduled database backup failed: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// backup writes a new backup next to the previous one, then swaps it into place.
+func (b *backupScheduler) backup() error {
+	tmp := b.path + ".tmp"
+
+	// clear up after any backup that was interrupted partway through
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	start := time.Now()
+	if err := backup(b.ctx, b.db, tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+
+	logrus.Infof("backed up database to %s in %s", b.path, time.Since(start))
+	return nil
+}
+
+// stop stops taking backups.
+func (b *backupScheduler) stop() {
+	b.cancel()
+}
@@ -0,0 +1,149 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type BackupTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+	conn          *DBConn
+	dir           string
+}
+
+func (suite *BackupTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+	)
+
+	// use a database file of our own, since an in-memory
+	// database would be shared with every other suite
+	suite.dir = suite.T().TempDir()
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, filepath.Join(suite.dir, "sqlite.db"))
+
+	conn, err := sqliteConn(context.Background())
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.conn = conn
+
+	ctx := context.Background()
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE things (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		suite.FailNow(err.Error())
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO things (name) VALUES ('one'), ('two')"); err != nil {
+		suite.FailNow(err.Error())
+	}
+}
+
+func (suite *BackupTestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.restoreConfig()
+}
+
+// countThings opens the sqlite database at path, and counts the rows in its things table.
+func (suite *BackupTestSuite) countThings(path string) int {
+	backup, err := sql.Open("sqlite", path)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer backup.Close()
+
+	var count int
+	if err := backup.QueryRow("SELECT COUNT(*) FROM things").Scan(&count); err != nil {
+		suite.FailNow(err.Error())
+	}
+	return count
+}
+
+func (suite *BackupTestSuite) TestBackup() {
+	out := filepath.Join(suite.dir, "backup.db")
+
+	suite.NoError(backup(context.Background(), suite.conn.DB, out))
+	suite.Equal(2, suite.countThings(out))
+}
+
+func (suite *BackupTestSuite) TestBackupExists() {
+	out := filepath.Join(suite.dir, "backup.db")
+
+	suite.NoError(backup(context.Background(), suite.conn.DB, out))
+
+	err := backup(context.Background(), suite.conn.DB, out)
+	suite.EqualError(err, "backup destination "+out+" already exists")
+}
+
+func (suite *BackupTestSuite) TestScheduledBackupReplaces() {
+	ctx := context.Background()
+	out := filepath.Join(suite.dir, "backup.db")
+	scheduler := newBackupScheduler(suite.conn.DB, out, 0)
+
+	suite.NoError(scheduler.backup())
+	suite.Equal(2, suite.countThings(out))
+
+	if _, err := suite.conn.ExecContext(ctx, "INSERT INTO things (name) VALUES ('three')"); err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	suite.NoError(scheduler.backup())
+	suite.Equal(3, suite.countThings(out))
+	suite.NoFileExists(out + ".tmp")
+}
+
+func (suite *BackupTestSuite) TestBackupPostgres() {
+	viper.Set(config.Keys.DbType, "postgres")
+
+	err := Backup(context.Background(), filepath.Join(suite.dir, "backup.db"))
+	suite.ErrorIs(err, errBackupPostgres)
+}
+
+func (suite *BackupTestSuite) TestSchedulePostgres() {
+	out := logrus.StandardLogger().Out
+	defer logrus.SetOutput(out)
+	buf := &bytes.Buffer{}
+	logrus.SetOutput(buf)
+
+	// scheduled backups are skipped on postgres, but not silently
+	pg := bun.NewDB(suite.conn.DB.DB, pgdialect.New())
+	scheduler := newBackupScheduler(pg, filepath.Join(suite.dir, "backup.db"), time.Minute)
+	scheduler.start()
+	scheduler.stop()
+
+	suite.Contains(buf.String(), "scheduled backups only work for sqlite")
+	suite.NoFileExists(filepath.Join(suite.dir, "backup.db"))
+}
+
+func TestBackupTestSuite(t *testing.T) {
+	suite.Run(t, new(BackupTestSuite))
+}
@@ -21,6 +21,7 @@ package bundb
 import (
 	"context"
 	"errors"
+	"reflect"
 
 	"github.com/sirupsen/logrus"
 
@@ -31,13 +32,36 @@ import (
 
 type basicDB struct {
 	conn *DBConn
+
+	// mentions needs to hear about statuses being updated or deleted through here
+	mentions *mentionDB
 }
 
 func (b *basicDB) Put(ctx context.Context, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	_, err := b.conn.NewInsert().Model(i).Exec(ctx)
 	return b.conn.ProcessError(err)
 }
 
+func (b *basicDB) PutIfNew(ctx context.Context, i interface{}) (bool, db.Error) {
+	if err := b.conn.CheckWritable(); err != nil {
+		return false, err
+	}
+
+	conflictColumn := "id"
+	if t := reflect.TypeOf(i); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		if b.conn.Dialect().Tables().Get(t).HasField("uri") {
+			conflictColumn = "uri"
+		}
+	}
+
+	inserted, err := insertIfNew(ctx, b.conn, i, conflictColumn)
+	return inserted, b.conn.ProcessError(err)
+}
+
 func (b *basicDB) GetByID(ctx context.Context, id string, i interface{}) db.Error {
 	q := b.conn.
 		NewSelect().
@@ -71,16 +95,27 @@ func (b *basicDB) GetAll(ctx context.Context, i interface{}) db.Error {
 }
 
 func (b *basicDB) DeleteByID(ctx context.Context, id string, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	q := b.conn.
 		NewDelete().
 		Model(i).
 		Where("id = ?", id)
 
 	_, err := q.Exec(ctx)
+	if err == nil {
+		b.invalidate(i, id)
+	}
 	return b.conn.ProcessError(err)
 }
 
 func (b *basicDB) DeleteWhere(ctx context.Context, where []db.Where, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	if len(where) == 0 {
 		return errors.New("no queries provided")
 	}
@@ -96,16 +131,43 @@ func (b *basicDB) DeleteWhere(ctx context.Context, where []db.Where, i interface
 }
 
 func (b *basicDB) UpdateByPrimaryKey(ctx context.Context, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if status, ok := i.(*gtsmodel.Status); ok {
+		if err := checkReplyCycle(ctx, b.conn, status.ID, status.InReplyToID); err != nil {
+			return err
+		}
+	}
+
 	q := b.conn.
 		NewUpdate().
 		Model(i).
 		WherePK()
 
 	_, err := q.Exec(ctx)
+	if err == nil {
+		if status, ok := i.(*gtsmodel.Status); ok {
+			b.invalidate(i, status.ID)
+		}
+	}
 	return b.conn.ProcessError(err)
 }
 
+// invalidate drops anything cached elsewhere about the model i with the given id,
+// now that it's been changed or deleted.
+func (b *basicDB) invalidate(i interface{}, id string) {
+	if _, ok := i.(*gtsmodel.Status); ok && b.mentions != nil {
+		b.mentions.invalidateStatusMentions(id)
+	}
+}
+
 func (b *basicDB) UpdateWhere(ctx context.Context, where []db.Where, key string, value interface{}, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	q := b.conn.NewUpdate().Model(i)
 
 	updateWhere(q, where)
@@ -117,6 +179,10 @@ func (b *basicDB) UpdateWhere(ctx context.Context, where []db.Where, key string,
 }
 
 func (b *basicDB) CreateTable(ctx context.Context, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	_, err := b.conn.NewCreateTable().Model(i).IfNotExists().Exec(ctx)
 	return err
 }
@@ -138,6 +204,13 @@ func (b *basicDB) CreateAllTables(ctx context.Context) db.Error {
 		&gtsmodel.StatusFave{},
 		&gtsmodel.StatusBookmark{},
 		&gtsmodel.StatusMute{},
+		&gtsmodel.StatusEdit{},
+		&gtsmodel.Announcement{},
+		&gtsmodel.AnnouncementRead{},
+		&gtsmodel.FeatureFlag{},
+		&gtsmodel.ThemeAsset{},
+		&gtsmodel.AccountAlias{},
+		&gtsmodel.AdminAction{},
 		&gtsmodel.Tag{},
 		&gtsmodel.User{},
 		&gtsmodel.Emoji{},
@@ -156,6 +229,10 @@ func (b *basicDB) CreateAllTables(ctx context.Context) db.Error {
 }
 
 func (b *basicDB) DropTable(ctx context.Context, i interface{}) db.Error {
+	if err := b.conn.CheckWritable(); err != nil {
+		return err
+	}
+
 	_, err := b.conn.NewDropTable().Model(i).IfExists().Exec(ctx)
 	return b.conn.ProcessError(err)
 }
@@ -168,3 +245,11 @@ func (b *basicDB) Stop(ctx context.Context) db.Error {
 	logrus.Info("closing db connection")
 	return b.conn.Close()
 }
+
+func (b *basicDB) SetReadOnly(readOnly bool) {
+	b.conn.SetReadOnly(readOnly)
+}
+
+func (b *basicDB) IsReadOnly() bool {
+	return b.conn.IsReadOnly()
+}
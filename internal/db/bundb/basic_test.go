@@ -64,6 +64,92 @@ func (suite *BasicTestSuite) TestGetAllNotNull() {
 	}
 }
 
+func (suite *BasicTestSuite) TestReadOnly() {
+	testAccount := suite.testAccounts["local_account_1"]
+
+	suite.db.SetReadOnly(true)
+	defer suite.db.SetReadOnly(false)
+	suite.True(suite.db.IsReadOnly())
+
+	// reads should work as normal
+	a := &gtsmodel.Account{}
+	err := suite.db.GetByID(context.Background(), testAccount.ID, a)
+	suite.NoError(err)
+	suite.Equal(testAccount.Username, a.Username)
+
+	// writes should be rejected
+	a.DisplayName = "this should never be stored"
+	err = suite.db.UpdateByPrimaryKey(context.Background(), a)
+	suite.ErrorIs(err, db.ErrReadOnly)
+
+	_, err = suite.db.UpdateAccount(context.Background(), a)
+	suite.ErrorIs(err, db.ErrReadOnly)
+
+	err = suite.db.DeleteByID(context.Background(), testAccount.ID, &gtsmodel.Account{})
+	suite.ErrorIs(err, db.ErrReadOnly)
+
+	// nothing should have changed in the db
+	dbAccount := &gtsmodel.Account{}
+	err = suite.db.GetByID(context.Background(), testAccount.ID, dbAccount)
+	suite.NoError(err)
+	suite.Equal(testAccount.DisplayName, dbAccount.DisplayName)
+
+	// once read-only is turned off again, writes should go through
+	suite.db.SetReadOnly(false)
+	suite.False(suite.db.IsReadOnly())
+	err = suite.db.UpdateByPrimaryKey(context.Background(), a)
+	suite.NoError(err)
+}
+
+func (suite *BasicTestSuite) TestPutIfNew() {
+	ctx := context.Background()
+	testAccount := suite.testAccounts["remote_account_1"]
+
+	// an account we already have, received again under a new ID
+	duplicate := &gtsmodel.Account{}
+	*duplicate = *testAccount
+	duplicate.ID = "01FVXGQ4H6WQ7TYB0RZ3D8CE4K"
+
+	inserted, err := suite.db.PutIfNew(ctx, duplicate)
+	suite.NoError(err)
+	suite.False(inserted)
+
+	dbAccount, err := suite.db.GetAccountByURI(ctx, testAccount.URI)
+	suite.NoError(err)
+	suite.Equal(testAccount.ID, dbAccount.ID)
+
+	// a brand new account
+	duplicate.URI = "http://example.org/users/someone_new"
+	duplicate.URL = "http://example.org/@someone_new"
+	duplicate.Username = "someone_new"
+	duplicate.InboxURI = "http://example.org/users/someone_new/inbox"
+	duplicate.OutboxURI = "http://example.org/users/someone_new/outbox"
+	duplicate.FollowersURI = "http://example.org/users/someone_new/followers"
+	duplicate.FollowingURI = "http://example.org/users/someone_new/following"
+	duplicate.FeaturedCollectionURI = "http://example.org/users/someone_new/collections/featured"
+	duplicate.PublicKeyURI = "http://example.org/users/someone_new#main-key"
+
+	inserted, err = suite.db.PutIfNew(ctx, duplicate)
+	suite.NoError(err)
+	suite.True(inserted)
+
+	// notifications have no uri, so they're told apart by ID
+	notif := &gtsmodel.Notification{
+		ID:               "01FVXGQE0B5TJ8WV3D2K9HMS6A",
+		NotificationType: gtsmodel.NotificationFollow,
+		TargetAccountID:  suite.testAccounts["local_account_1"].ID,
+		OriginAccountID:  testAccount.ID,
+	}
+
+	inserted, err = suite.db.PutIfNew(ctx, notif)
+	suite.NoError(err)
+	suite.True(inserted)
+
+	inserted, err = suite.db.PutIfNew(ctx, notif)
+	suite.NoError(err)
+	suite.False(inserted)
+}
+
 func TestBasicTestSuite(t *testing.T) {
 	suite.Run(t, new(BasicTestSuite))
 }
@@ -26,6 +26,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"runtime"
 	"strings"
@@ -42,7 +43,9 @@ import (
 	"github.com/superseriousbusiness/gotosocial/internal/db/bundb/migrations"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/internal/id"
+	"github.com/superseriousbusiness/gotosocial/internal/util"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/migrate"
@@ -54,6 +57,21 @@ const (
 	dbTypePostgres = "postgres"
 	dbTypeSqlite   = "sqlite"
 
+	// dbSqliteCachePrivate gives each sqlite connection its own page cache.
+	dbSqliteCachePrivate = "private"
+	// dbSqliteCacheShared shares one page cache between all sqlite connections.
+	dbSqliteCacheShared = "shared"
+	// dbSqliteCacheUnset means that the sqlite cache mode has not been set, which is treated as private.
+	dbSqliteCacheUnset = ""
+
+	// dbPostgresFlavorPostgres means the postgres database is actually postgres.
+	dbPostgresFlavorPostgres = "postgres"
+	// dbPostgresFlavorCockroach means the postgres database is cockroachdb,
+	// which speaks the postgres protocol but doesn't support everything postgres does.
+	dbPostgresFlavorCockroach = "cockroach"
+	// dbPostgresFlavorUnset means that the postgres flavor has not been set, which is treated as postgres.
+	dbPostgresFlavorUnset = ""
+
 	// dbTLSModeDisable does not attempt to make a TLS connection to the database.
 	dbTLSModeDisable = "disable"
 	// dbTLSModeEnable attempts to make a TLS connection to the database, but doesn't fail if
@@ -75,71 +93,209 @@ var registerTables = []interface{}{
 type bunDBService struct {
 	db.Account
 	db.Admin
+	db.Announcement
 	db.Basic
 	db.Domain
+	db.FeatureFlag
 	db.Instance
 	db.Media
 	db.Mention
 	db.Notification
+	db.PubSub
 	db.Relationship
 	db.Session
 	db.Status
 	db.Timeline
-	conn *DBConn
+	conn        *DBConn
+	pubsub      *pubSub
+	poolSampler *poolSampler
+	backups     *backupScheduler
+}
+
+// Stop stops listening for events, sampling the connection pool and taking backups, then closes the database connection.
+func (ps *bunDBService) Stop(ctx context.Context) db.Error {
+	ps.pubsub.stop()
+	ps.poolSampler.stop()
+	ps.backups.stop()
+	return ps.Basic.Stop(ctx)
+}
+
+// migrationContext returns a context for running migrations in, which is bounded only by
+// the configured migration timeout. Migrations can legitimately take a very long time on
+// large tables, so this is deliberately kept separate from any limits on normal queries.
+func migrationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := viper.GetDuration(config.Keys.DbMigrationTimeout); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	// don't wrap the context at all if there's no timeout: the sqlite driver interrupts
+	// the connection when a context is cancelled, which can catch whatever query
+	// happens to run next on that connection if it's cancelled right after a migration
+	return ctx, func() {}
+}
+
+// migrationLockID is the key of the postgres advisory lock held while migrating,
+// so that instances sharing a database don't try to run migrations at the same time.
+const migrationLockID int64 = 0x6774736d696772 // "gtsmigr"
+
+// lockMigrations makes sure that only one instance runs migrations against the database
+// at a time. If another instance is already migrating, it blocks until that's finished.
+// The returned func releases the lock again, and should always be called when done.
+func lockMigrations(ctx context.Context, db *bun.DB) (func(), error) {
+	l := logrus.WithField("func", "lockMigrations")
+
+	if db.Dialect().Name() != dialect.PG {
+		// sqlite databases are a single file that shouldn't be shared between
+		// instances in the first place, and its writes are serialized anyway
+		return func() {}, nil
+	}
+
+	if usingCockroach() {
+		// cockroachdb doesn't do advisory locks, so just rely
+		// on the lock table that the migrator itself uses
+		return func() {}, nil
+	}
+
+	// advisory locks belong to a session, so lock and unlock on one connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if !locked {
+		l.Info("another instance is migrating the database, waiting for it to finish")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error waiting for migration lock: %s", err)
+		}
+		l.Info("got migration lock")
+	}
+
+	return func() {
+		// ctx may well be done by now, but the lock still needs releasing
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			l.Errorf("error releasing migration lock: %s", err)
+		}
+		conn.Close()
+	}, nil
 }
 
 func doMigration(ctx context.Context, db *bun.DB) error {
+	checksums, err := migrations.Checksums()
+	if err != nil {
+		return fmt.Errorf("error computing migration checksums: %s", err)
+	}
+	return runMigrations(ctx, db, migrations.Migrations, checksums, migrations.Dialects())
+}
+
+// runMigrations applies any of ms that haven't been applied to db yet. Before doing so, it
+// checks that none of the migrations that were applied before have changed since, going by
+// checksums (keyed by migration name), and that the pending ones support db's dialect, going
+// by dialects (likewise keyed); afterwards, it records checksums of newly applied ones.
+func runMigrations(ctx context.Context, db *bun.DB, ms *migrate.Migrations, checksums map[string]string, dialects map[string][]dialect.Name) error {
 	l := logrus.WithField("func", "doMigration")
 
-	migrator := migrate.NewMigrator(db, migrations.Migrations)
+	ctx, cancel := migrationContext(ctx)
+	defer cancel()
+
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("error locking database for migration: %s", err)
+	}
+	defer unlock()
+
+	migrator := migrate.NewMigrator(db, ms)
 
 	if err := migrator.Init(ctx); err != nil {
 		return err
 	}
 
+	if err := checkMigrationChecksums(ctx, db, checksums); err != nil {
+		if viper.GetBool(config.Keys.DbMigrationVerifyChecksums) {
+			return err
+		}
+		l.Warn(err)
+	}
+
+	if err := checkMigrationDialects(ctx, db, migrator, dialects); err != nil {
+		return err
+	}
+
 	group, err := migrator.Migrate(ctx)
 	if err != nil {
 		if err.Error() == "migrate: there are no any migrations" {
 			return nil
 		}
+		if group != nil {
+			// the group only comes back if one of its migrations failed
+			return newMigrationError(ctx, migrator, err)
+		}
 		return err
 	}
 
+	if err := recordMigrationChecksums(ctx, db, migrator, checksums); err != nil {
+		return fmt.Errorf("error recording migration checksums: %s", err)
+	}
+
 	if group.ID == 0 {
 		l.Info("there are no new migrations to run")
 		return nil
 	}
 
 	l.Infof("MIGRATED DATABASE TO %s", group)
+
+	if viper.GetBool(config.Keys.DbMigrationAnalyze) {
+		// migrations may have added indexes or shifted data around,
+		// so make sure the query planner isn't left working from stale
+		// statistics until the next autovacuum / optimize comes along
+		l.Info("refreshing query planner statistics, this may take a while on large databases")
+		if err := analyze(ctx, db); err != nil {
+			// not fatal: everything still works, it just might be slow for a bit
+			l.Warnf("error refreshing query planner statistics: %s", err)
+		}
+	}
+
 	return nil
 }
 
+// analyze refreshes the statistics used by the query planner of the given database.
+func analyze(ctx context.Context, db *bun.DB) error {
+	var q string
+	switch db.Dialect().Name() {
+	case dialect.PG:
+		if usingCockroach() {
+			// cockroachdb only analyzes one table at a time, and
+			// refreshes statistics by itself after schema changes anyway
+			return nil
+		}
+		q = "ANALYZE"
+	case dialect.SQLite:
+		q = "PRAGMA optimize"
+	default:
+		return fmt.Errorf("analyze not supported for dialect %s", db.Dialect().Name())
+	}
+
+	_, err := db.ExecContext(ctx, q)
+	return err
+}
+
 // NewBunDBService returns a bunDB derived from the provided config, which implements the go-fed DB interface.
 // Under the hood, it uses https://github.com/uptrace/bun to create and maintain a database connection.
 func NewBunDBService(ctx context.Context) (db.DB, error) {
-	var conn *DBConn
-	var err error
-	dbType := strings.ToLower(viper.GetString(config.Keys.DbType))
-
-	switch dbType {
-	case dbTypePostgres:
-		conn, err = pgConn(ctx)
-		if err != nil {
-			return nil, err
-		}
-	case dbTypeSqlite:
-		conn, err = sqliteConn(ctx)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		return nil, fmt.Errorf("database type %s not supported for bundb", dbType)
+	conn, err := openConn(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// add a hook to just log queries and the time they take
 	// only do this for trace logging where performance isn't 1st concern
 	if logrus.GetLevel() >= logrus.TraceLevel {
-		conn.DB.AddQueryHook(newDebugQueryHook())
+		conn.DB.AddQueryHook(newDebugQueryHook(viper.GetFloat64(config.Keys.DbQueryLogSampleRate)))
 	}
 
 	// table registration is needed for many-to-many, see:
@@ -150,59 +306,115 @@ func NewBunDBService(ctx context.Context) (db.DB, error) {
 
 	// perform any pending database migrations: this includes
 	// the very first 'migration' on startup which just creates
-	// necessary tables
-	if err := doMigration(ctx, conn.DB); err != nil {
+	// necessary tables; if the schema is managed elsewhere, just
+	// make sure it's up to date instead
+	if !viper.GetBool(config.Keys.DbMigrateOnStart) {
+		if err := verifyMigrations(ctx, conn.DB, migrations.Migrations); err != nil {
+			return nil, fmt.Errorf("db migration check error: %s", err)
+		}
+	} else if conn.lockedReadOnly {
+		logrus.Warn("database opened read-only, skipping migrations")
+	} else if err := doMigration(ctx, conn.DB); err != nil {
 		return nil, fmt.Errorf("db migration error: %s", err)
 	}
 
+	// read-only mode can be toggled later at runtime,
+	// but start in whatever mode we've been configured
+	conn.SetReadOnly(viper.GetBool(config.Keys.DbReadOnly) || conn.lockedReadOnly)
+
+	// keep an eye on the connection pool, so that operators
+	// find out when it's saturated before their users do
+	poolSampler := newPoolSampler(
+		conn.DB.DB,
+		viper.GetDuration(config.Keys.DbPoolSampleInterval),
+		viper.GetDuration(config.Keys.DbPoolWaitThreshold),
+	)
+	poolSampler.start()
+
+	// take scheduled backups of sqlite databases, if configured
+	backupInterval := viper.GetDuration(config.Keys.DbSqliteBackupInterval)
+	backupPath := viper.GetString(config.Keys.DbSqliteBackupPath)
+	if backupInterval > 0 && backupPath == "" {
+		poolSampler.stop()
+		return nil, fmt.Errorf("%s is set, but %s is not", config.Keys.DbSqliteBackupInterval, config.Keys.DbSqliteBackupPath)
+	}
+	backups := newBackupScheduler(conn.DB, backupPath, backupInterval)
+	backups.start()
+
+	pubsub := newPubSub(conn)
 	accounts := &accountDB{conn: conn, cache: cache.NewAccountCache()}
+	mentions := &mentionDB{conn: conn, cache: ttlcache.NewCache(), statusMentions: newExpiringCache(statusMentionsCacheTTL)}
+	statuses := &statusDB{conn: conn, cache: cache.NewStatusCache(), accounts: accounts, mentions: mentions, pubsub: pubsub, trendingTags: newExpiringCache(trendingTagsCacheTTL)}
 
 	ps := &bunDBService{
 		Account: accounts,
 		Admin: &adminDB{
 			conn: conn,
 		},
-		Basic: &basicDB{
+		Announcement: &announcementDB{
 			conn: conn,
 		},
+		Basic: &basicDB{
+			conn:     conn,
+			mentions: mentions,
+		},
 		Domain: &domainDB{
-			conn: conn,
+			conn:      conn,
+			blocklist: newExpiringCache(blocklistCacheTTL),
+		},
+		FeatureFlag: &featureFlagDB{
+			conn:  conn,
+			flags: newExpiringCache(featureFlagCacheTTL),
 		},
 		Instance: &instanceDB{
-			conn: conn,
+			conn:       conn,
+			localStats: newExpiringCache(localInstanceStatsCacheTTL),
 		},
 		Media: &mediaDB{
 			conn: conn,
 		},
-		Mention: &mentionDB{
-			conn:  conn,
-			cache: ttlcache.NewCache(),
-		},
+		Mention: mentions,
 		Notification: &notificationDB{
-			conn:  conn,
-			cache: ttlcache.NewCache(),
+			conn:   conn,
+			cache:  ttlcache.NewCache(),
+			pubsub: pubsub,
 		},
+		PubSub: pubsub,
 		Relationship: &relationshipDB{
 			conn: conn,
 		},
 		Session: &sessionDB{
 			conn: conn,
 		},
-		Status: &statusDB{
-			conn:     conn,
-			cache:    cache.NewStatusCache(),
-			accounts: accounts,
-		},
+		Status: statuses,
 		Timeline: &timelineDB{
-			conn: conn,
+			conn:     conn,
+			statuses: statuses,
 		},
-		conn: conn,
+		conn:        conn,
+		pubsub:      pubsub,
+		poolSampler: poolSampler,
+		backups:     backups,
 	}
 
 	// we can confidently return this useable service now
 	return ps, nil
 }
 
+// openConn opens a connection to whichever type of database is configured.
+func openConn(ctx context.Context) (*DBConn, error) {
+	dbType := strings.ToLower(viper.GetString(config.Keys.DbType))
+
+	switch dbType {
+	case dbTypePostgres:
+		return pgConn(ctx)
+	case dbTypeSqlite:
+		return sqliteConn(ctx)
+	default:
+		return nil, fmt.Errorf("database type %s not supported for bundb", dbType)
+	}
+}
+
 func sqliteConn(ctx context.Context) (*DBConn, error) {
 	dbAddress := viper.GetString(config.Keys.DbAddress)
 
@@ -210,11 +422,51 @@ func sqliteConn(ctx context.Context) (*DBConn, error) {
 	dbAddress = strings.Split(dbAddress, "?")[0]
 	dbAddress = strings.TrimPrefix(dbAddress, "file:")
 
+	inMemory := dbAddress == ":memory:"
+
+	// in-memory databases only exist for as long as there's a connection
+	// to them, and each connection would get its own without a shared cache
+	cache := viper.GetString(config.Keys.DbSqliteCache)
+	switch {
+	case inMemory:
+		cache = dbSqliteCacheShared
+	case cache == dbSqliteCacheUnset:
+		cache = dbSqliteCachePrivate
+	case cache != dbSqliteCachePrivate && cache != dbSqliteCacheShared:
+		return nil, fmt.Errorf("sqlite cache mode %s not recognised, expected %s or %s", cache, dbSqliteCachePrivate, dbSqliteCacheShared)
+	}
+
 	// Append our own SQLite preferences
-	dbAddress = "file:" + dbAddress + "?cache=shared"
+	dbAddress = "file:" + dbAddress + "?cache=" + cache
+
+	// make locked connections wait their turn for a while
+	// rather than immediately failing with SQLITE_BUSY
+	busyTimeout := viper.GetDuration(config.Keys.DbSqliteBusyTimeout)
+	dbAddress += fmt.Sprintf("&_pragma=busy_timeout(%d)", busyTimeout.Milliseconds())
+
+	// sqlite only enforces foreign keys when asked to, per connection;
+	// ask, so that it refuses the same orphaned rows as postgres does
+	if viper.GetBool(config.Keys.DbSqliteForeignKeys) {
+		dbAddress += "&_pragma=foreign_keys(1)"
+	}
+
+	// when opening read-only, have sqlite itself refuse writes, and
+	// treat the file as immutable so that nothing (not even a journal) is
+	// written next to it: this makes it safe to inspect a copy of a broken
+	// database, but the file mustn't be changed by anything else meanwhile
+	openReadOnly := viper.GetBool(config.Keys.DbOpenReadOnly) && !inMemory
+	if openReadOnly {
+		dbAddress += "&mode=ro&immutable=1"
+	}
 
 	// Open new DB instance
-	sqldb, err := sql.Open("sqlite", dbAddress)
+	var sqldb *sql.DB
+	var err error
+	if opts := driverOptionsFromConfig(); opts.enabled() {
+		sqldb, err = openDB(&sqlite.Driver{}, dbAddress, opts)
+	} else {
+		sqldb, err = sql.Open("sqlite", dbAddress)
+	}
 	if err != nil {
 		if errWithCode, ok := err.(*sqlite.Error); ok {
 			err = errors.New(sqlite.ErrorCodeString[errWithCode.Code()])
@@ -224,7 +476,7 @@ func sqliteConn(ctx context.Context) (*DBConn, error) {
 
 	tweakConnectionValues(sqldb)
 
-	if dbAddress == "file::memory:?cache=shared" {
+	if inMemory {
 		logrus.Warn("sqlite in-memory database should only be used for debugging")
 		// don't close connections on disconnect -- otherwise
 		// the SQLite database will be deleted when there
@@ -233,6 +485,7 @@ func sqliteConn(ctx context.Context) (*DBConn, error) {
 	}
 
 	conn := WrapDBConn(bun.NewDB(sqldb, sqlitedialect.New()))
+	conn.lockedReadOnly = openReadOnly
 
 	// ping to check the db is there and listening
 	if err := conn.PingContext(ctx); err != nil {
@@ -252,17 +505,51 @@ func pgConn(ctx context.Context) (*DBConn, error) {
 		return nil, fmt.Errorf("could not create bundb postgres options: %s", err)
 	}
 
-	sqldb := stdlib.OpenDB(*opts)
+	var sqldb *sql.DB
+	if driverOpts := driverOptionsFromConfig(); driverOpts.enabled() {
+		sqldb, err = openDB(stdlib.GetDefaultDriver(), stdlib.RegisterConnConfig(opts), driverOpts)
+		if err != nil {
+			return nil, fmt.Errorf("could not open postgres db: %s", err)
+		}
+	} else {
+		sqldb = stdlib.OpenDB(*opts)
+	}
 
 	tweakConnectionValues(sqldb)
 
 	conn := WrapDBConn(bun.NewDB(sqldb, pgdialect.New()))
 
+	// cockroachdb runs transactions at serializable isolation, and
+	// expects clients to retry them when they conflict with each other
+	conn.retryTx = usingCockroach()
+
+	// read-only sessions were asked for in the connection options
+	conn.lockedReadOnly = viper.GetBool(config.Keys.DbOpenReadOnly)
+
 	// ping to check the db is there and listening
-	if err := conn.PingContext(ctx); err != nil {
+	if err := pingDB(ctx, conn); err != nil {
 		return nil, fmt.Errorf("postgres ping: %s", err)
 	}
 
+	// postgres quietly skips search_path entries that don't exist, which
+	// would leave migrations with nowhere to create tables, so check first
+	if schema := viper.GetString(config.Keys.DbPostgresSchema); schema != "" {
+		var current sql.NullString
+		if err := conn.QueryRowContext(ctx, "SELECT current_schema()").Scan(&current); err != nil {
+			return nil, fmt.Errorf("error checking postgres schema: %s", err)
+		}
+		if current.String != schema {
+			return nil, fmt.Errorf("postgres schema %s doesn't exist, or the database user can't use it", schema)
+		}
+	}
+
+	if viper.GetBool(config.Keys.DbWarmup) {
+		if err := warmUpPool(ctx, sqldb, maxConns()); err != nil {
+			return nil, fmt.Errorf("postgres warm-up: %s", err)
+		}
+		logrus.Infof("opened %d postgres connections", maxConns())
+	}
+
 	logrus.Info("connected to POSTGRES database")
 	return conn, nil
 }
@@ -271,6 +558,33 @@ func pgConn(ctx context.Context) (*DBConn, error) {
 	HANDY STUFF
 */
 
+// pingDB pings the database to check it's there and listening, giving up
+// with a clear error if it can't be reached within the configured dial timeout.
+//
+// Don't use this for sqlite: there's nothing to dial, and cancelling the context
+// of a ping interrupts whatever the connection happens to be doing next.
+func pingDB(ctx context.Context, conn *DBConn) error {
+	dialTimeout := viper.GetDuration(config.Keys.DbDialTimeout)
+	if dialTimeout <= 0 {
+		return conn.PingContext(ctx)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	err := conn.PingContext(pingCtx)
+	if err != nil && pingCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return fmt.Errorf("could not reach database within %s", dialTimeout)
+	}
+	return err
+}
+
+// usingCockroach returns true if the configured postgres database is actually cockroachdb.
+func usingCockroach() bool {
+	return strings.EqualFold(viper.GetString(config.Keys.DbType), dbTypePostgres) &&
+		viper.GetString(config.Keys.DbPostgresFlavor) == dbPostgresFlavorCockroach
+}
+
 // deriveBunDBPGOptions takes an application config and returns either a ready-to-use set of options
 // with sensible defaults, or an error if it's not satisfied by the provided config.
 func deriveBunDBPGOptions() (*pgx.ConnConfig, error) {
@@ -280,6 +594,14 @@ func deriveBunDBPGOptions() (*pgx.ConnConfig, error) {
 		return nil, fmt.Errorf("expected db type of %s but got %s", db.DBTypePostgres, viper.GetString(keys.DbType))
 	}
 
+	// validate flavor
+	switch flavor := viper.GetString(keys.DbPostgresFlavor); flavor {
+	case dbPostgresFlavorPostgres, dbPostgresFlavorCockroach, dbPostgresFlavorUnset:
+		break // nothing to do
+	default:
+		return nil, fmt.Errorf("postgres flavor %s not recognised, expected %s or %s", flavor, dbPostgresFlavorPostgres, dbPostgresFlavorCockroach)
+	}
+
 	// validate port
 	port := viper.GetInt(keys.DbPort)
 	if port == 0 {
@@ -298,9 +620,11 @@ func deriveBunDBPGOptions() (*pgx.ConnConfig, error) {
 		return nil, errors.New("no user set")
 	}
 
-	// validate that there's a password
+	// validate that there's a password, unless we've been told we don't need one
+	// or we're connecting over a unix socket (pgx treats addresses starting with / as socket dirs),
+	// since postgres can authenticate those with trust or peer auth instead
 	password := viper.GetString(keys.DbPassword)
-	if password == "" {
+	if password == "" && !viper.GetBool(keys.DbAllowNoPassword) && !strings.HasPrefix(address, "/") {
 		return nil, errors.New("no password set")
 	}
 
@@ -329,11 +653,21 @@ func deriveBunDBPGOptions() (*pgx.ConnConfig, error) {
 	}
 
 	caCertPath := viper.GetString(keys.DbTLSCACert)
+	if tlsMode == dbTLSModeRequire && caCertPath == "" {
+		// with nothing else to go on the server cert can only be verified against
+		// the system pool, which minimal container images often don't have
+		if _, ok := systemCertPool(); !ok {
+			logrus.Warnf("%s is %s but no system CA certificates were found, so the database server's certificate can't be verified: set %s to the CA certificate to trust", keys.DbTLSMode, dbTLSModeRequire, keys.DbTLSCACert)
+		}
+	}
+
 	if tlsConfig != nil && caCertPath != "" {
-		// load the system cert pool first -- we'll append the given CA cert to this
-		certPool, err := x509.SystemCertPool()
-		if err != nil {
-			return nil, fmt.Errorf("error fetching system CA cert pool: %s", err)
+		// load the system cert pool first -- we'll append the given CA cert to this,
+		// or to an empty pool if there's no system pool, so the given CA alone is trusted
+		certPool, ok := systemCertPool()
+		if !ok {
+			logrus.Infof("no system CA certificates found, trusting only the CA certificate at %s", caCertPath)
+			certPool = x509.NewCertPool()
 		}
 
 		// open the file itself and make sure there's something in it
@@ -372,14 +706,95 @@ func deriveBunDBPGOptions() (*pgx.ConnConfig, error) {
 	cfg.PreferSimpleProtocol = true
 	cfg.RuntimeParams["application_name"] = viper.GetString(keys.ApplicationName)
 
+	// when opening read-only, have postgres refuse writes too
+	if viper.GetBool(keys.DbOpenReadOnly) {
+		cfg.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+
+	// send tcp keepalives on our own schedule, so that connections silently
+	// dropped by a firewall or NAT while idle are noticed and replaced by the
+	// pool, rather than failing the next query that happens to use them
+	keepalive := viper.GetDuration(keys.DbKeepaliveInterval)
+	if keepalive <= 0 {
+		// a negative value tells the dialer to disable keepalives
+		keepalive = -1
+	}
+	dialer := &net.Dialer{KeepAlive: keepalive}
+	cfg.DialFunc = dialer.DialContext
+
+	// don't let an unreachable host hang connecting forever
+	if dialTimeout := viper.GetDuration(keys.DbDialTimeout); dialTimeout > 0 {
+		dialer.Timeout = dialTimeout
+		cfg.ConnectTimeout = dialTimeout
+	}
+
+	// set the session timezone explicitly so that timestamp conversion
+	// doesn't depend on whatever the server's TimeZone happens to be
+	if timezone := viper.GetString(keys.DbTimezone); timezone != "" {
+		cfg.RuntimeParams["timezone"] = timezone
+	}
+
+	// keep everything in the configured schema, including the migration
+	// tables, so that gotosocial can share a database with other things
+	if schema := viper.GetString(keys.DbPostgresSchema); schema != "" {
+		cfg.RuntimeParams["search_path"] = pgIdent(schema)
+	}
+
 	return cfg, nil
 }
 
+// pgIdent quotes the given name as a postgres identifier.
+func pgIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// systemCertPool returns a copy of the system cert pool, and whether it's there and
+// has anything in it. Scratch and distroless images often have no CA bundle at all.
+func systemCertPool() (*x509.CertPool, bool) {
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		logrus.Debugf("error fetching system CA cert pool: %s", err)
+		return nil, false
+	}
+	return certPool, len(certPool.Subjects()) != 0
+}
+
+// maxConns returns the maximum number of open (and idle) connections to keep in the pool.
 // https://bun.uptrace.dev/postgres/running-bun-in-production.html#database-sql
+func maxConns() int {
+	return 4 * runtime.GOMAXPROCS(0)
+}
+
 func tweakConnectionValues(sqldb *sql.DB) {
-	maxOpenConns := 4 * runtime.GOMAXPROCS(0)
-	sqldb.SetMaxOpenConns(maxOpenConns)
-	sqldb.SetMaxIdleConns(maxOpenConns)
+	sqldb.SetMaxOpenConns(maxConns())
+	sqldb.SetMaxIdleConns(maxConns())
+}
+
+// warmUpPool opens n connections to the database at once, pings them, and then puts them back
+// in the pool as idle connections, so that they're ready to go when the first queries come in.
+func warmUpPool(ctx context.Context, sqldb *sql.DB, n int) error {
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+
+	// hold on to each connection until all of them are open,
+	// otherwise the pool would just hand back the same one
+	for i := 0; i < n; i++ {
+		c, err := sqldb.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, c)
+
+		if err := c.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 /*
@@ -388,12 +803,25 @@ func tweakConnectionValues(sqldb *sql.DB) {
 
 // TODO: move these to the type converter, it's bananas that they're here and not there
 
+const (
+	// mentionMaxUsernameLength is the longest username that will be looked up when resolving mentions.
+	// Local usernames are much shorter than this, but other software may allow longer ones.
+	mentionMaxUsernameLength = 256
+	// mentionMaxDomainLength is the longest that a domain name can be, see RFC 1035.
+	mentionMaxDomainLength = 253
+)
+
 func (ps *bunDBService) MentionStringsToMentions(ctx context.Context, targetAccounts []string, originAccountID string, statusID string) ([]*gtsmodel.Mention, error) {
 	ogAccount := &gtsmodel.Account{}
 	if err := ps.conn.NewSelect().Model(ogAccount).Where("id = ?", originAccountID).Scan(ctx); err != nil {
 		return nil, err
 	}
 
+	// the same account can be mentioned more than once, and usernames and domains
+	// are case insensitive, so keep track of who's been mentioned already
+	seenNames := make(map[string]struct{}, len(targetAccounts))
+	seenAccounts := make(map[string]struct{}, len(targetAccounts))
+
 	menchies := []*gtsmodel.Mention{}
 	for _, a := range targetAccounts {
 		// A mentioned account looks like "@test@example.org" or just "@test" for a local account
@@ -403,6 +831,12 @@ func (ps *bunDBService) MentionStringsToMentions(ctx context.Context, targetAcco
 		// 1.  trim off the first @
 		t := strings.TrimPrefix(a, "@")
 
+		// don't even bother splitting anything that's too long to be valid,
+		// and don't echo it back in the error either, since it might be huge
+		if maxLength := mentionMaxUsernameLength + 1 + mentionMaxDomainLength; len(t) > maxLength {
+			return nil, fmt.Errorf("mentioned account was %d characters long, more than the maximum of %d", len(t), maxLength)
+		}
+
 		// 2. split the username and domain
 		s := strings.Split(t, "@")
 
@@ -428,6 +862,21 @@ func (ps *bunDBService) MentionStringsToMentions(ctx context.Context, targetAcco
 			return nil, fmt.Errorf("username or domain for '%s' was nil", a)
 		}
 
+		// 5. check the username and domain aren't too long before we go querying with them
+		if len(username) > mentionMaxUsernameLength {
+			return nil, fmt.Errorf("username of mentioned account was %d characters long, more than the maximum of %d", len(username), mentionMaxUsernameLength)
+		}
+		if len(domain) > mentionMaxDomainLength {
+			return nil, fmt.Errorf("domain of mentioned account was %d characters long, more than the maximum of %d", len(domain), mentionMaxDomainLength)
+		}
+
+		// 6. skip names we've already looked up, no matter how they were written
+		name := strings.ToLower(username + "@" + domain)
+		if _, ok := seenNames[name]; ok {
+			continue
+		}
+		seenNames[name] = struct{}{}
+
 		// okay we're good now, we can start pulling accounts out of the database
 		mentionedAccount := &gtsmodel.Account{}
 		var err error
@@ -451,6 +900,13 @@ func (ps *bunDBService) MentionStringsToMentions(ctx context.Context, targetAcco
 			return nil, fmt.Errorf("error getting account with username '%s' and domain '%s': %s", username, domain, err)
 		}
 
+		// the database lowercases names its own way, which needn't match strings.ToLower
+		// for every character, so make sure the account itself hasn't been mentioned yet
+		if _, ok := seenAccounts[mentionedAccount.ID]; ok {
+			continue
+		}
+		seenAccounts[mentionedAccount.ID] = struct{}{}
+
 		// id, createdAt and updatedAt will be populated by the db, so we have everything we need!
 		menchies = append(menchies, &gtsmodel.Mention{
 			StatusID:         statusID,
@@ -523,3 +979,66 @@ func (ps *bunDBService) EmojiStringsToEmojis(ctx context.Context, emojis []strin
 	}
 	return newEmojis, nil
 }
+
+func (ps *bunDBService) RepairStatusJoins(ctx context.Context, status *gtsmodel.Status) (int, error) {
+	if err := ps.conn.CheckWritable(); err != nil {
+		return 0, err
+	}
+
+	// prefer the plaintext of the status, but imported
+	// statuses might only have html content to go on
+	text := status.Text
+	if text == "" {
+		text = status.Content
+	}
+
+	tags, err := ps.TagStringsToTags(ctx, util.DeriveHashtagsFromText(text), status.AccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	emojis, err := ps.EmojiStringsToEmojis(ctx, util.DeriveEmojisFromText(text))
+	if err != nil {
+		return 0, err
+	}
+
+	var created int
+	if err := ps.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		for _, tag := range tags {
+			// make sure the tag exists, it might be brand new
+			if _, err := insertIfNew(ctx, tx, tag); err != nil {
+				return err
+			}
+
+			inserted, err := insertIfNew(ctx, tx, &gtsmodel.StatusToTag{
+				StatusID: status.ID,
+				TagID:    tag.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if inserted {
+				created++
+			}
+		}
+
+		for _, emoji := range emojis {
+			inserted, err := insertIfNew(ctx, tx, &gtsmodel.StatusToEmoji{
+				StatusID: status.ID,
+				EmojiID:  emoji.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if inserted {
+				created++
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return 0, ps.conn.ProcessError(err)
+	}
+
+	return created, nil
+}
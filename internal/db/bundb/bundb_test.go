@@ -31,15 +31,17 @@ type BunDBStandardTestSuite struct {
 	db db.DB
 
 	// standard suite models
-	testTokens       map[string]*gtsmodel.Token
-	testClients      map[string]*gtsmodel.Client
-	testApplications map[string]*gtsmodel.Application
-	testUsers        map[string]*gtsmodel.User
-	testAccounts     map[string]*gtsmodel.Account
-	testAttachments  map[string]*gtsmodel.MediaAttachment
-	testStatuses     map[string]*gtsmodel.Status
-	testTags         map[string]*gtsmodel.Tag
-	testMentions     map[string]*gtsmodel.Mention
+	testTokens        map[string]*gtsmodel.Token
+	testClients       map[string]*gtsmodel.Client
+	testApplications  map[string]*gtsmodel.Application
+	testUsers         map[string]*gtsmodel.User
+	testAccounts      map[string]*gtsmodel.Account
+	testAttachments   map[string]*gtsmodel.MediaAttachment
+	testStatuses      map[string]*gtsmodel.Status
+	testTags          map[string]*gtsmodel.Tag
+	testMentions      map[string]*gtsmodel.Mention
+	testNotifications map[string]*gtsmodel.Notification
+	testFollows       map[string]*gtsmodel.Follow
 }
 
 func (suite *BunDBStandardTestSuite) SetupSuite() {
@@ -52,11 +54,15 @@ func (suite *BunDBStandardTestSuite) SetupSuite() {
 	suite.testStatuses = testrig.NewTestStatuses()
 	suite.testTags = testrig.NewTestTags()
 	suite.testMentions = testrig.NewTestMentions()
+	suite.testNotifications = testrig.NewTestNotifications()
+	suite.testFollows = testrig.NewTestFollows()
 }
 
 func (suite *BunDBStandardTestSuite) SetupTest() {
-	testrig.InitTestLog()
+	// config first, so that the log level is trace
+	// and the db is created with the query logging hook
 	testrig.InitTestConfig()
+	testrig.InitTestLog()
 
 	suite.db = testrig.NewTestDB()
 	testrig.StandardDBSetup(suite.db, suite.testAccounts)
@@ -0,0 +1,40 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+)
+
+// queryComment returns the sqlcommenter comment to prepend to queries run
+// with the given context, or an empty string if there's nothing to say.
+// See https://google.github.io/sqlcommenter/spec/ for the format.
+func queryComment(ctx context.Context) string {
+	subsystem := db.SubsystemFromContext(ctx)
+	if subsystem == "" {
+		return ""
+	}
+
+	// values are url encoded, which also takes care of quotes
+	// and of anything else that could end the comment early
+	return "/*subsystem='" + url.PathEscape(subsystem) + "'*/ "
+}
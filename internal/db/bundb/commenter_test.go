@@ -0,0 +1,116 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"modernc.org/sqlite"
+)
+
+// recordingDriver opens sqlite connections that record the queries they're given.
+type recordingDriver struct {
+	sqlite.Driver
+	queries []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, driver: d}, nil
+}
+
+type recordingConn struct {
+	driver.Conn
+	driver *recordingDriver
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.queries = append(c.driver.queries, query)
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.queries = append(c.driver.queries, query)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+type CommenterTestSuite struct {
+	suite.Suite
+	driver *recordingDriver
+	sqldb  *sql.DB
+}
+
+func (suite *CommenterTestSuite) SetupTest() {
+	suite.driver = &recordingDriver{}
+
+	sqldb, err := openDB(suite.driver, "file:"+filepath.Join(suite.T().TempDir(), "sqlite.db"), driverOptions{comment: true})
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.sqldb = sqldb
+}
+
+func (suite *CommenterTestSuite) TearDownTest() {
+	suite.sqldb.Close()
+}
+
+func (suite *CommenterTestSuite) TestCommentsQueries() {
+	ctx := db.WithSubsystem(context.Background(), "timeline")
+
+	_, err := suite.sqldb.ExecContext(ctx, "CREATE TABLE things (id INTEGER)")
+	suite.NoError(err)
+
+	var count int
+	suite.NoError(suite.sqldb.QueryRowContext(ctx, "SELECT COUNT(*) FROM things").Scan(&count))
+	suite.Zero(count)
+
+	suite.Equal([]string{
+		"/*subsystem='timeline'*/ CREATE TABLE things (id INTEGER)",
+		"/*subsystem='timeline'*/ SELECT COUNT(*) FROM things",
+	}, suite.driver.queries)
+}
+
+func (suite *CommenterTestSuite) TestLeavesUnlabelledQueriesAlone() {
+	var one int
+	suite.NoError(suite.sqldb.QueryRowContext(context.Background(), "SELECT 1").Scan(&one))
+	suite.Equal(1, one)
+	suite.Equal([]string{"SELECT 1"}, suite.driver.queries)
+}
+
+func (suite *CommenterTestSuite) TestQueryCommentEscaping() {
+	suite.Equal("", queryComment(context.Background()))
+	suite.Equal("/*subsystem='federator'*/ ", queryComment(db.WithSubsystem(context.Background(), "federator")))
+
+	// nothing in the label should be able to end the comment early
+	suite.Equal("/*subsystem='a%20%2A%2F%20b'*/ ", queryComment(db.WithSubsystem(context.Background(), "a */ b")))
+	suite.Equal("/*subsystem='it%27s'*/ ", queryComment(db.WithSubsystem(context.Background(), "it's")))
+}
+
+func TestCommenterTestSuite(t *testing.T) {
+	suite.Run(t, new(CommenterTestSuite))
+}
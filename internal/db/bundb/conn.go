@@ -3,18 +3,31 @@ package bundb
 import (
 	"context"
 	"database/sql"
+	"sync/atomic"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect"
 )
 
+const (
+	// txMaxAttempts is how many times a transaction is attempted in total when retrying is enabled.
+	txMaxAttempts = 5
+	// txRetryBackoff is how long to wait before retrying a transaction, multiplied by the attempt number.
+	txRetryBackoff = 20 * time.Millisecond
+)
+
 // DBConn wrapps a bun.DB conn to provide SQL-type specific additional functionality
 type DBConn struct {
 	// TODO: move *Config here, no need to be in each struct type
 
-	errProc func(error) db.Error // errProc is the SQL-type specific error processor
-	*bun.DB                      // DB is the underlying bun.DB connection
+	errProc        func(error) db.Error // errProc is the SQL-type specific error processor
+	readOnly       uint32               // readOnly is 1 when writes should be rejected, accessed atomically
+	lockedReadOnly bool                 // lockedReadOnly is true when the database itself was opened read-only, so read-only mode can't be turned off
+	retryTx        bool                 // retryTx is true when transactions failing on serialization should be retried
+	*bun.DB                             // DB is the underlying bun.DB connection
 }
 
 // WrapDBConn @TODO
@@ -35,22 +48,72 @@ func WrapDBConn(dbConn *bun.DB) *DBConn {
 }
 
 // RunInTx wraps execution of the supplied transaction function.
+//
+// If the connection is set to retry transactions (ie., for cockroachdb), a transaction
+// that's aborted with a serialization failure will be run again from the start, so fn
+// shouldn't have side effects outside of the transaction.
 func (conn *DBConn) RunInTx(ctx context.Context, fn func(bun.Tx) error) db.Error {
+	for attempt := 1; ; attempt++ {
+		err := conn.runInTx(ctx, fn)
+		if !conn.retryTx || attempt >= txMaxAttempts || !isSerializationFailure(err) {
+			return conn.ProcessError(err)
+		}
+
+		// back off a little before trying again, so that
+		// whatever we conflicted with has a chance to finish
+		select {
+		case <-ctx.Done():
+			return conn.ProcessError(err)
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+}
+
+// runInTx runs fn in a single transaction, without processing the error.
+func (conn *DBConn) runInTx(ctx context.Context, fn func(bun.Tx) error) error {
 	// Acquire a new transaction
 	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return conn.ProcessError(err)
+		return err
 	}
 
 	// Perform supplied transaction
 	if err = fn(tx); err != nil {
 		tx.Rollback() //nolint
-		return conn.ProcessError(err)
+		return err
 	}
 
 	// Finally, commit transaction
-	err = tx.Commit()
-	return conn.ProcessError(err)
+	return tx.Commit()
+}
+
+// SetReadOnly turns read-only mode on or off for this connection. Safe to call at any time.
+// If the database was opened read-only, read-only mode stays on regardless.
+func (conn *DBConn) SetReadOnly(readOnly bool) {
+	if !readOnly && conn.lockedReadOnly {
+		logrus.Warn("database was opened read-only, so read-only mode can't be turned off without a restart")
+		return
+	}
+
+	var v uint32
+	if readOnly {
+		v = 1
+	}
+	atomic.StoreUint32(&conn.readOnly, v)
+}
+
+// IsReadOnly returns whether this connection is currently in read-only mode.
+func (conn *DBConn) IsReadOnly() bool {
+	return atomic.LoadUint32(&conn.readOnly) == 1
+}
+
+// CheckWritable returns db.ErrReadOnly if the connection is in read-only mode, or nil otherwise.
+// Functions that write to the database should call this before doing anything else.
+func (conn *DBConn) CheckWritable() db.Error {
+	if conn.IsReadOnly() {
+		return db.ErrReadOnly
+	}
+	return nil
 }
 
 // ProcessError processes an error to replace any known values with our own db.Error types,
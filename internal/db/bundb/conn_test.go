@@ -0,0 +1,172 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/uptrace/bun"
+)
+
+type ConnTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+	conn          *DBConn
+}
+
+func (suite *ConnTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+		config.Keys.DbPort,
+		config.Keys.DbUser,
+		config.Keys.DbPassword,
+		config.Keys.DbDatabase,
+		config.Keys.DbPostgresFlavor,
+		config.Keys.DbPostgresSchema,
+		config.Keys.DbDialTimeout,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, ":memory:")
+
+	conn, err := sqliteConn(context.Background())
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.conn = conn
+}
+
+func (suite *ConnTestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.restoreConfig()
+}
+
+// serializationFailureTx returns a transaction func that fails
+// with a serialization failure the given number of times.
+func serializationFailureTx(failures int, calls *int) func(bun.Tx) error {
+	return func(tx bun.Tx) error {
+		*calls++
+		if *calls <= failures {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	}
+}
+
+func (suite *ConnTestSuite) TestRunInTxNoRetry() {
+	calls := 0
+	err := suite.conn.RunInTx(context.Background(), serializationFailureTx(1, &calls))
+	suite.Error(err)
+	suite.Equal(1, calls)
+}
+
+func (suite *ConnTestSuite) TestRunInTxRetry() {
+	suite.conn.retryTx = true
+
+	calls := 0
+	err := suite.conn.RunInTx(context.Background(), serializationFailureTx(2, &calls))
+	suite.NoError(err)
+	suite.Equal(3, calls)
+}
+
+func (suite *ConnTestSuite) TestRunInTxRetryGivesUp() {
+	suite.conn.retryTx = true
+
+	calls := 0
+	err := suite.conn.RunInTx(context.Background(), serializationFailureTx(txMaxAttempts, &calls))
+	suite.True(isSerializationFailure(err))
+	suite.Equal(txMaxAttempts, calls)
+}
+
+func (suite *ConnTestSuite) TestPostgresFlavor() {
+	viper.Set(config.Keys.DbType, "postgres")
+	viper.Set(config.Keys.DbAddress, "localhost")
+	viper.Set(config.Keys.DbPort, 26257)
+	viper.Set(config.Keys.DbUser, "root")
+	viper.Set(config.Keys.DbPassword, "cockroach")
+	viper.Set(config.Keys.DbDatabase, "gotosocial")
+
+	viper.Set(config.Keys.DbPostgresFlavor, "cockroach")
+	_, err := deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.True(usingCockroach())
+
+	viper.Set(config.Keys.DbPostgresFlavor, "postgres")
+	suite.False(usingCockroach())
+
+	viper.Set(config.Keys.DbPostgresFlavor, "mysql")
+	_, err = deriveBunDBPGOptions()
+	suite.EqualError(err, "postgres flavor mysql not recognised, expected postgres or cockroach")
+}
+
+func (suite *ConnTestSuite) TestPostgresSchema() {
+	viper.Set(config.Keys.DbType, "postgres")
+	viper.Set(config.Keys.DbAddress, "localhost")
+	viper.Set(config.Keys.DbPort, 5432)
+	viper.Set(config.Keys.DbUser, "postgres")
+	viper.Set(config.Keys.DbPassword, "postgres")
+	viper.Set(config.Keys.DbDatabase, "postgres")
+
+	viper.Set(config.Keys.DbPostgresSchema, "")
+	opts, err := deriveBunDBPGOptions()
+	suite.NoError(err)
+	_, ok := opts.RuntimeParams["search_path"]
+	suite.False(ok)
+
+	viper.Set(config.Keys.DbPostgresSchema, "gotosocial")
+	opts, err = deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.Equal(`"gotosocial"`, opts.RuntimeParams["search_path"])
+
+	// awkward names are quoted rather than mangled
+	viper.Set(config.Keys.DbPostgresSchema, `my "schema"`)
+	opts, err = deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.Equal(`"my ""schema"""`, opts.RuntimeParams["search_path"])
+}
+
+func (suite *ConnTestSuite) TestPostgresDialTimeout() {
+	viper.Set(config.Keys.DbType, "postgres")
+	viper.Set(config.Keys.DbAddress, "localhost")
+	viper.Set(config.Keys.DbPort, 5432)
+	viper.Set(config.Keys.DbUser, "postgres")
+	viper.Set(config.Keys.DbPassword, "postgres")
+	viper.Set(config.Keys.DbDatabase, "postgres")
+
+	viper.Set(config.Keys.DbDialTimeout, 5*time.Second)
+	opts, err := deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.Equal(5*time.Second, opts.ConnectTimeout)
+
+	viper.Set(config.Keys.DbDialTimeout, 0)
+	opts, err = deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.Zero(opts.ConnectTimeout)
+}
+
+func TestConnTestSuite(t *testing.T) {
+	suite.Run(t, new(ConnTestSuite))
+}
@@ -0,0 +1,73 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/sirupsen/logrus"
+)
+
+// connLogger logs database connections as they're opened and closed, along with how many are
+// open in total. Closes include connections that database/sql recycles because they've been
+// open or idle for too long, so this shows churn that the pool statistics don't.
+type connLogger struct {
+	open int64
+}
+
+// connOpened is what's known about a connection when it's opened.
+type connOpened struct {
+	at  time.Time
+	pid uint32
+}
+
+// opened logs that the given connection has just been opened.
+func (l *connLogger) opened(conn driver.Conn) connOpened {
+	o := connOpened{at: time.Now()}
+
+	// postgres connections are served by their own backend process on
+	// the server, which is how they show up in pg_stat_activity
+	if pc, ok := conn.(*stdlib.Conn); ok {
+		o.pid = pc.Conn().PgConn().PID()
+	}
+
+	l.entry(atomic.AddInt64(&l.open, 1), o.pid).Debug("opened database connection")
+	return o
+}
+
+// closed logs that the connection opened as given has just been closed.
+func (l *connLogger) closed(o connOpened, err error) {
+	entry := l.entry(atomic.AddInt64(&l.open, -1), o.pid).WithField("age", time.Since(o.at))
+	if err != nil {
+		entry.Debugf("closed database connection with error: %s", err)
+		return
+	}
+	entry.Debug("closed database connection")
+}
+
+func (l *connLogger) entry(open int64, pid uint32) *logrus.Entry {
+	fields := logrus.Fields{"open": open}
+	if pid != 0 {
+		fields["pid"] = pid
+	}
+	return logrus.WithFields(fields)
+}
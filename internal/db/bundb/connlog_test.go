@@ -0,0 +1,96 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/suite"
+	"modernc.org/sqlite"
+)
+
+type ConnLogTestSuite struct {
+	suite.Suite
+	level logrus.Level
+	hooks logrus.LevelHooks
+	hook  *test.Hook
+}
+
+func (suite *ConnLogTestSuite) SetupTest() {
+	// capture log entries without losing whatever hooks were there before
+	suite.level = logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	suite.hooks = logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	suite.hook = test.NewGlobal()
+}
+
+func (suite *ConnLogTestSuite) TearDownTest() {
+	logrus.StandardLogger().ReplaceHooks(suite.hooks)
+	logrus.SetLevel(suite.level)
+}
+
+func (suite *ConnLogTestSuite) TestLogConnections() {
+	ctx := context.Background()
+
+	sqldb, err := openDB(&sqlite.Driver{}, "file:"+filepath.Join(suite.T().TempDir(), "sqlite.db"), driverOptions{logConnections: true})
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	// hold one connection while opening another
+	held, err := sqldb.Conn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.NoError(sqldb.PingContext(ctx))
+	suite.NoError(held.Close())
+	suite.NoError(sqldb.Close())
+
+	entries := suite.hook.AllEntries()
+	if suite.Len(entries, 4) {
+		suite.Equal("opened database connection", entries[0].Message)
+		suite.EqualValues(1, entries[0].Data["open"])
+		suite.Equal("opened database connection", entries[1].Message)
+		suite.EqualValues(2, entries[1].Data["open"])
+		suite.Equal("closed database connection", entries[2].Message)
+		suite.EqualValues(1, entries[2].Data["open"])
+		suite.Contains(entries[2].Data, "age")
+		suite.Equal("closed database connection", entries[3].Message)
+		suite.EqualValues(0, entries[3].Data["open"])
+	}
+}
+
+func (suite *ConnLogTestSuite) TestNoLogging() {
+	sqldb, err := openDB(&sqlite.Driver{}, "file:"+filepath.Join(suite.T().TempDir(), "sqlite.db"), driverOptions{})
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	suite.NoError(sqldb.PingContext(context.Background()))
+	suite.NoError(sqldb.Close())
+	suite.Empty(suite.hook.AllEntries())
+}
+
+func TestConnLogTestSuite(t *testing.T) {
+	suite.Run(t, new(ConnLogTestSuite))
+}
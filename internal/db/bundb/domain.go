@@ -20,15 +20,56 @@ package bundb
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/ReneKroon/ttlcache"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/internal/util"
+	"github.com/uptrace/bun"
 )
 
 type domainDB struct {
 	conn *DBConn
+
+	// blocklist caches the severity of every blocked domain
+	blocklist *ttlcache.Cache
+}
+
+// blocklistCacheTTL is how long the blocked domains are cached for.
+const blocklistCacheTTL = 30 * time.Second
+
+// blocklistCacheKey is the key under which the blocked domains are cached.
+const blocklistCacheKey = "blocklist"
+
+// getBlocklist returns the severity of every blocked domain, keyed by lowercased
+// domain, from the cache if possible and from the database otherwise.
+func (d *domainDB) getBlocklist(ctx context.Context) (map[string]gtsmodel.DomainBlockSeverity, db.Error) {
+	if cached, ok := d.blocklist.Get(blocklistCacheKey); ok {
+		return cached.(map[string]gtsmodel.DomainBlockSeverity), nil
+	}
+
+	blocks := []*gtsmodel.DomainBlock{}
+	if err := d.conn.
+		NewSelect().
+		Model(&blocks).
+		Column("domain", "severity").
+		Scan(ctx); err != nil {
+		return nil, d.conn.ProcessError(err)
+	}
+
+	blocklist := make(map[string]gtsmodel.DomainBlockSeverity, len(blocks))
+	for _, block := range blocks {
+		blocklist[strings.ToLower(block.Domain)] = block.Severity
+	}
+
+	d.blocklist.Set(blocklistCacheKey, blocklist)
+	return blocklist, nil
 }
 
 func (d *domainDB) IsDomainBlocked(ctx context.Context, domain string) (bool, db.Error) {
@@ -36,29 +77,60 @@ func (d *domainDB) IsDomainBlocked(ctx context.Context, domain string) (bool, db
 		return false, nil
 	}
 
-	q := d.conn.
-		NewSelect().
-		Model(&gtsmodel.DomainBlock{}).
-		Where("LOWER(domain) = LOWER(?)", domain).
-		Limit(1)
+	blocked, err := d.GetBlockedDomains(ctx, []string{domain})
+	if err != nil {
+		return false, err
+	}
 
-	return d.conn.Exists(ctx, q)
+	return len(blocked) != 0, nil
 }
 
 func (d *domainDB) AreDomainsBlocked(ctx context.Context, domains []string) (bool, db.Error) {
+	blocked, err := d.GetBlockedDomains(ctx, domains)
+	if err != nil {
+		return false, err
+	}
+
+	return len(blocked) != 0, nil
+}
+
+func (d *domainDB) GetBlockedDomains(ctx context.Context, domains []string) ([]string, db.Error) {
+	blocklist, err := d.getBlocklist(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// filter out any doubles
 	uniqueDomains := util.UniqueStrings(domains)
 
+	// only suspensions cut a domain off completely,
+	// domains with rejected media are still federated with
+	blocked := []string{}
 	for _, domain := range uniqueDomains {
-		if blocked, err := d.IsDomainBlocked(ctx, domain); err != nil {
-			return false, err
-		} else if blocked {
-			return blocked, nil
+		if blocklist[strings.ToLower(domain)] == gtsmodel.DomainBlockSeveritySuspend {
+			blocked = append(blocked, domain)
 		}
 	}
 
-	// no blocks found
-	return false, nil
+	return blocked, nil
+}
+
+func (d *domainDB) DomainMediaRejected(ctx context.Context, domain string) (bool, db.Error) {
+	if domain == "" {
+		return false, nil
+	}
+
+	blocklist, err := d.getBlocklist(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	switch blocklist[strings.ToLower(domain)] {
+	case gtsmodel.DomainBlockSeveritySuspend, gtsmodel.DomainBlockSeverityRejectMedia:
+		return true, nil
+	default:
+		return false, nil
+	}
 }
 
 func (d *domainDB) IsURIBlocked(ctx context.Context, uri *url.URL) (bool, db.Error) {
@@ -74,3 +146,106 @@ func (d *domainDB) AreURIsBlocked(ctx context.Context, uris []*url.URL) (bool, d
 
 	return d.AreDomainsBlocked(ctx, domains)
 }
+
+func (d *domainDB) ExportDomainBlocks(ctx context.Context, w io.Writer, format string) db.Error {
+	if format != db.DomainBlocksFormatMastodonCSV {
+		return fmt.Errorf("unsupported domain block export format %s", format)
+	}
+
+	rows, err := d.conn.
+		NewSelect().
+		Model((*gtsmodel.DomainBlock)(nil)).
+		Column("domain", "severity", "public_comment").
+		Order("domain ASC").
+		Rows(ctx)
+	if err != nil {
+		return d.conn.ProcessError(err)
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"#domain", "#severity", "#reject_media", "#public_comment"}); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		block := &gtsmodel.DomainBlock{}
+		if err := d.conn.ScanRow(ctx, rows, block); err != nil {
+			return d.conn.ProcessError(err)
+		}
+
+		// mastodon has no separate severity for only rejecting
+		// media, it's a flag on a block that otherwise does nothing
+		severity := string(block.Severity)
+		if block.Severity == gtsmodel.DomainBlockSeverityRejectMedia {
+			severity = "noop"
+		}
+
+		if err := cw.Write([]string{block.Domain, severity, "true", block.PublicComment}); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return d.conn.ProcessError(err)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (d *domainDB) PutDomainBlock(ctx context.Context, block *gtsmodel.DomainBlock) db.Error {
+	if err := d.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	err := d.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		if _, err := tx.
+			NewInsert().
+			Model(block).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertAdminAction(ctx, tx, &gtsmodel.AdminAction{
+			AccountID:    block.CreatedByAccountID,
+			Type:         gtsmodel.AdminActionDomainBlock,
+			TargetDomain: block.Domain,
+			Text:         block.PrivateComment,
+		})
+	})
+	if err != nil {
+		return d.conn.ProcessError(err)
+	}
+
+	d.blocklist.Remove(blocklistCacheKey)
+	return nil
+}
+
+func (d *domainDB) DeleteDomainBlock(ctx context.Context, block *gtsmodel.DomainBlock, moderatorID string) db.Error {
+	if err := d.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	err := d.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		if _, err := tx.
+			NewDelete().
+			Model((*gtsmodel.DomainBlock)(nil)).
+			Where("id = ?", block.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertAdminAction(ctx, tx, &gtsmodel.AdminAction{
+			AccountID:    moderatorID,
+			Type:         gtsmodel.AdminActionDomainUnblock,
+			TargetDomain: block.Domain,
+		})
+	})
+	if err != nil {
+		return d.conn.ProcessError(err)
+	}
+
+	d.blocklist.Remove(blocklistCacheKey)
+	return nil
+}
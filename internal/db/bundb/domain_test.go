@@ -0,0 +1,182 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type DomainTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *DomainTestSuite) TestExportDomainBlocks() {
+	err := suite.db.Put(context.Background(), &gtsmodel.DomainBlock{
+		ID:                 "01FVBF6W7ZVQSK7Z5YQ7T6NKEX",
+		Domain:             "bad.example.org",
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+		PrivateComment:     "this should never be exported",
+		PublicComment:      "spam, \"trolling\"",
+	})
+	suite.NoError(err)
+
+	buf := &bytes.Buffer{}
+	err = suite.db.ExportDomainBlocks(context.Background(), buf, db.DomainBlocksFormatMastodonCSV)
+	suite.NoError(err)
+
+	suite.Equal("#domain,#severity,#reject_media,#public_comment\n"+
+		"bad.example.org,suspend,true,\"spam, \"\"trolling\"\"\"\n"+
+		"replyguys.com,suspend,true,reply-guying to tech posts\n", buf.String())
+	suite.NotContains(buf.String(), "never be exported")
+}
+
+func (suite *DomainTestSuite) TestExportDomainBlocksUnknownFormat() {
+	buf := &bytes.Buffer{}
+	err := suite.db.ExportDomainBlocks(context.Background(), buf, "some_other_format")
+	suite.Error(err)
+	suite.Empty(buf.String())
+}
+
+func (suite *DomainTestSuite) TestPutAndDeleteDomainBlock() {
+	ctx := context.Background()
+	admin := suite.testAccounts["admin_account"]
+
+	block := &gtsmodel.DomainBlock{
+		ID:                 "01FVBF6W7ZVQSK7Z5YQ7T6NKEX",
+		Domain:             "bad.example.org",
+		CreatedByAccountID: admin.ID,
+		PrivateComment:     "spam",
+	}
+	err := suite.db.PutDomainBlock(ctx, block)
+	suite.NoError(err)
+
+	blocked, err := suite.db.IsDomainBlocked(ctx, "bad.example.org")
+	suite.NoError(err)
+	suite.True(blocked)
+
+	err = suite.db.DeleteDomainBlock(ctx, block, admin.ID)
+	suite.NoError(err)
+
+	blocked, err = suite.db.IsDomainBlocked(ctx, "bad.example.org")
+	suite.NoError(err)
+	suite.False(blocked)
+
+	// both changes were recorded in the audit log
+	for _, actionType := range []gtsmodel.AdminActionType{gtsmodel.AdminActionDomainBlock, gtsmodel.AdminActionDomainUnblock} {
+		actions, err := suite.db.GetAdminActions(ctx, db.AdminActionFilter{Type: actionType}, "", 10)
+		suite.NoError(err)
+		suite.Len(actions, 1)
+		suite.Equal(admin.ID, actions[0].AccountID)
+		suite.Equal("bad.example.org", actions[0].TargetDomain)
+	}
+}
+
+func (suite *DomainTestSuite) TestGetBlockedDomains() {
+	ctx := context.Background()
+
+	blocked, err := suite.db.GetBlockedDomains(ctx, []string{"example.org", "ReplyGuys.com", "replyguys.com", "localhost:8080"})
+	suite.NoError(err)
+	suite.Equal([]string{"ReplyGuys.com", "replyguys.com"}, blocked)
+
+	blocked, err = suite.db.GetBlockedDomains(ctx, nil)
+	suite.NoError(err)
+	suite.Empty(blocked)
+
+	areBlocked, err := suite.db.AreDomainsBlocked(ctx, []string{"example.org", "replyguys.com"})
+	suite.NoError(err)
+	suite.True(areBlocked)
+
+	areBlocked, err = suite.db.AreDomainsBlocked(ctx, []string{"example.org"})
+	suite.NoError(err)
+	suite.False(areBlocked)
+}
+
+func (suite *DomainTestSuite) TestDomainBlockInvalidatesBlocklist() {
+	ctx := context.Background()
+
+	// load the blocklist into the cache
+	blocked, err := suite.db.IsDomainBlocked(ctx, "bad.example.org")
+	suite.NoError(err)
+	suite.False(blocked)
+
+	block := &gtsmodel.DomainBlock{
+		ID:                 "01FW5Q2E3WJ0ZP9JX2T0N6A8M4",
+		Domain:             "bad.example.org",
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+	}
+	suite.NoError(suite.db.PutDomainBlock(ctx, block))
+
+	blocked, err = suite.db.IsDomainBlocked(ctx, "bad.example.org")
+	suite.NoError(err)
+	suite.True(blocked)
+
+	suite.NoError(suite.db.DeleteDomainBlock(ctx, block, block.CreatedByAccountID))
+
+	blocked, err = suite.db.IsDomainBlocked(ctx, "bad.example.org")
+	suite.NoError(err)
+	suite.False(blocked)
+
+	// the block itself should be gone too, not just uncached
+	err = suite.db.GetByID(ctx, block.ID, &gtsmodel.DomainBlock{})
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *DomainTestSuite) TestDomainMediaRejected() {
+	ctx := context.Background()
+
+	suite.NoError(suite.db.PutDomainBlock(ctx, &gtsmodel.DomainBlock{
+		ID:                 "01FW8E4Y4S7ZJ6RFR1QHWQ7K8T",
+		Domain:             "media.example.org",
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+		Severity:           gtsmodel.DomainBlockSeverityRejectMedia,
+	}))
+
+	for domain, rejected := range map[string]bool{
+		"media.example.org": true,
+		"Media.Example.org": true,
+		"replyguys.com":     true, // suspended in the testrig
+		"example.org":       false,
+		"":                  false,
+	} {
+		mediaRejected, err := suite.db.DomainMediaRejected(ctx, domain)
+		suite.NoError(err)
+		suite.Equal(rejected, mediaRejected, domain)
+	}
+
+	// only the suspension cuts the domain off completely
+	blocked, err := suite.db.GetBlockedDomains(ctx, []string{"media.example.org", "replyguys.com"})
+	suite.NoError(err)
+	suite.Equal([]string{"replyguys.com"}, blocked)
+
+	buf := &bytes.Buffer{}
+	suite.NoError(suite.db.ExportDomainBlocks(ctx, buf, db.DomainBlocksFormatMastodonCSV))
+	suite.Equal("#domain,#severity,#reject_media,#public_comment\n"+
+		"media.example.org,noop,true,\n"+
+		"replyguys.com,suspend,true,reply-guying to tech posts\n", buf.String())
+}
+
+func TestDomainTestSuite(t *testing.T) {
+	suite.Run(t, new(DomainTestSuite))
+}
@@ -0,0 +1,194 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+// driverOptions are the optional features that are implemented by wrapping driver connections,
+// for things that bun query hooks can't do: they only get to look at queries, not change them,
+// and they don't see connections being opened or closed at all.
+type driverOptions struct {
+	// comment prepends a sqlcommenter comment to queries, see queryComment
+	comment bool
+	// logConnections logs connections as they're opened and closed, see connLogger
+	logConnections bool
+}
+
+// driverOptionsFromConfig returns the driver options set in the config.
+func driverOptionsFromConfig() driverOptions {
+	return driverOptions{
+		comment:        viper.GetBool(config.Keys.DbSqlCommenterEnabled),
+		logConnections: viper.GetBool(config.Keys.DbLogConnections),
+	}
+}
+
+// enabled returns true if any of the options need connections to be wrapped.
+func (o driverOptions) enabled() bool {
+	return o.comment || o.logConnections
+}
+
+// openDB opens a *sql.DB on the given driver and data source name, whose connections are wrapped
+// to implement the given driver options.
+func openDB(d driver.Driver, dsn string, opts driverOptions) (*sql.DB, error) {
+	var connector driver.Connector
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connector = c
+	} else {
+		connector = &dsnConnector{driver: d, dsn: dsn}
+	}
+
+	wc := &wrappingConnector{Connector: connector, comment: opts.comment}
+	if opts.logConnections {
+		wc.logger = &connLogger{}
+	}
+
+	return sql.OpenDB(wc), nil
+}
+
+// dsnConnector is a driver.Connector for drivers that don't provide their own.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c *dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// wrappingConnector wraps the connections of a driver.Connector in wrappedConn.
+type wrappingConnector struct {
+	driver.Connector
+	comment bool
+	logger  *connLogger
+}
+
+func (c *wrappingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := &wrappedConn{Conn: conn, comment: c.comment, logger: c.logger}
+	if c.logger != nil {
+		wrapped.opened = c.logger.opened(conn)
+	}
+	return wrapped, nil
+}
+
+// wrappedConn implements the driver options on top of the wrapped driver.Conn.
+// The optional interfaces database/sql looks for are passed through to the wrapped connection, or
+// answered with driver.ErrSkip where that makes database/sql fall back to something else.
+type wrappedConn struct {
+	driver.Conn
+	comment bool
+	logger  *connLogger
+	opened  connOpened
+}
+
+// query returns the query to pass on to the wrapped connection.
+func (c *wrappedConn) query(ctx context.Context, query string) string {
+	if !c.comment {
+		return query
+	}
+	return queryComment(ctx) + query
+}
+
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	query = c.query(ctx, query)
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return pc.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if ec, ok := c.Conn.(driver.ExecerContext); ok {
+		return ec.ExecContext(ctx, c.query(ctx, query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if qc, ok := c.Conn.(driver.QueryerContext); ok {
+		return qc.QueryContext(ctx, c.query(ctx, query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("driver doesn't support transaction options")
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *wrappedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *wrappedConn) Close() error {
+	err := c.Conn.Close()
+	if c.logger != nil {
+		c.logger.closed(c.opened, err)
+	}
+	return err
+}
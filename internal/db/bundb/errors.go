@@ -1,6 +1,8 @@
 package bundb
 
 import (
+	"errors"
+
 	"github.com/jackc/pgconn"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"modernc.org/sqlite"
@@ -25,6 +27,13 @@ func processPostgresError(err error) db.Error {
 	}
 }
 
+// isSerializationFailure returns true if err is a postgres serialization failure,
+// meaning the transaction was aborted because of a concurrent one and can be retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001" /* serialization_failure */
+}
+
 // processSQLiteError processes an error, replacing any sqlite specific errors with our own error type
 func processSQLiteError(err error) db.Error {
 	// Attempt to cast as sqlite
@@ -0,0 +1,177 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
+)
+
+type featureFlagDB struct {
+	conn *DBConn
+
+	// flags caches the JSON value of every flag that's set, by name
+	flags *ttlcache.Cache
+}
+
+// featureFlagCacheTTL is how long flags are cached for, and so how long it
+// takes for a change made by another process to be picked up.
+const featureFlagCacheTTL = 10 * time.Second
+
+// featureFlagCacheKey is the key under which the flags are cached.
+const featureFlagCacheKey = "flags"
+
+// getFlags returns the JSON value of every flag that's set, keyed by name,
+// from the cache if possible and from the database otherwise.
+func (f *featureFlagDB) getFlags(ctx context.Context) (map[string]string, db.Error) {
+	if cached, ok := f.flags.Get(featureFlagCacheKey); ok {
+		return cached.(map[string]string), nil
+	}
+
+	flags := []*gtsmodel.FeatureFlag{}
+	if err := f.conn.
+		NewSelect().
+		Model(&flags).
+		Column("name", "value").
+		Scan(ctx); err != nil {
+		return nil, f.conn.ProcessError(err)
+	}
+
+	values := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		values[flag.Name] = flag.Value
+	}
+
+	f.flags.Set(featureFlagCacheKey, values)
+	return values, nil
+}
+
+func (f *featureFlagDB) GetFeatureFlags(ctx context.Context) ([]*gtsmodel.FeatureFlag, db.Error) {
+	flags := []*gtsmodel.FeatureFlag{}
+
+	if err := f.conn.
+		NewSelect().
+		Model(&flags).
+		Order("feature_flag.name ASC").
+		Scan(ctx); err != nil {
+		return nil, f.conn.ProcessError(err)
+	}
+
+	if len(flags) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return flags, nil
+}
+
+func (f *featureFlagDB) IsFeatureEnabled(ctx context.Context, name string) (bool, db.Error) {
+	values, err := f.getFlags(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := values[name]
+	if !ok {
+		return false, nil
+	}
+
+	// anything other than a plain true, including
+	// a value that isn't a bool at all, is disabled
+	enabled := false
+	if err := json.Unmarshal([]byte(value), &enabled); err != nil {
+		return false, nil
+	}
+
+	return enabled, nil
+}
+
+func (f *featureFlagDB) GetFeatureFlagValue(ctx context.Context, name string, v interface{}) db.Error {
+	values, err := f.getFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, ok := values[name]
+	if !ok {
+		return db.ErrNoEntries
+	}
+
+	return json.Unmarshal([]byte(value), v)
+}
+
+func (f *featureFlagDB) SetFeatureFlag(ctx context.Context, name string, value interface{}, accountID string) db.Error {
+	if err := f.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	flagID, err := id.NewULID()
+	if err != nil {
+		return err
+	}
+
+	flag := &gtsmodel.FeatureFlag{
+		ID:                 flagID,
+		UpdatedAt:          time.Now(),
+		Name:               name,
+		Value:              string(b),
+		UpdatedByAccountID: accountID,
+	}
+
+	if _, err := f.conn.
+		NewInsert().
+		Model(flag).
+		On("CONFLICT (name) DO UPDATE").
+		Set("value = EXCLUDED.value").
+		Set("updated_at = EXCLUDED.updated_at").
+		Set("updated_by_account_id = EXCLUDED.updated_by_account_id").
+		Exec(ctx); err != nil {
+		return f.conn.ProcessError(err)
+	}
+
+	f.flags.Remove(featureFlagCacheKey)
+	return nil
+}
+
+func (f *featureFlagDB) DeleteFeatureFlag(ctx context.Context, name string) db.Error {
+	if err := f.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if _, err := f.conn.
+		NewDelete().
+		Model((*gtsmodel.FeatureFlag)(nil)).
+		Where("name = ?", name).
+		Exec(ctx); err != nil {
+		return f.conn.ProcessError(err)
+	}
+
+	f.flags.Remove(featureFlagCacheKey)
+	return nil
+}
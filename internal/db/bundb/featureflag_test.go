@@ -0,0 +1,88 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+)
+
+type FeatureFlagTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *FeatureFlagTestSuite) TestSetAndGetFeatureFlags() {
+	ctx := context.Background()
+	adminID := suite.testAccounts["admin_account"].ID
+
+	// nothing is set to begin with
+	_, err := suite.db.GetFeatureFlags(ctx)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	enabled, err := suite.db.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.False(enabled)
+
+	suite.NoError(suite.db.SetFeatureFlag(ctx, "local_only_posts", true, adminID))
+	suite.NoError(suite.db.SetFeatureFlag(ctx, "max_pinned", map[string]int{"statuses": 5}, adminID))
+
+	enabled, err = suite.db.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.True(enabled)
+
+	// a flag that isn't a bool is never enabled
+	enabled, err = suite.db.IsFeatureEnabled(ctx, "max_pinned")
+	suite.NoError(err)
+	suite.False(enabled)
+
+	maxPinned := map[string]int{}
+	suite.NoError(suite.db.GetFeatureFlagValue(ctx, "max_pinned", &maxPinned))
+	suite.Equal(map[string]int{"statuses": 5}, maxPinned)
+
+	err = suite.db.GetFeatureFlagValue(ctx, "not_set", &maxPinned)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// setting a flag again updates it rather than adding another one
+	suite.NoError(suite.db.SetFeatureFlag(ctx, "local_only_posts", false, adminID))
+
+	enabled, err = suite.db.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.False(enabled)
+
+	flags, err := suite.db.GetFeatureFlags(ctx)
+	suite.NoError(err)
+	suite.Len(flags, 2)
+	suite.Equal("local_only_posts", flags[0].Name)
+	suite.Equal("false", flags[0].Value)
+	suite.Equal(adminID, flags[0].UpdatedByAccountID)
+	suite.Equal("max_pinned", flags[1].Name)
+
+	suite.NoError(suite.db.DeleteFeatureFlag(ctx, "max_pinned"))
+	suite.NoError(suite.db.DeleteFeatureFlag(ctx, "max_pinned"))
+
+	err = suite.db.GetFeatureFlagValue(ctx, "max_pinned", &maxPinned)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func TestFeatureFlagTestSuite(t *testing.T) {
+	suite.Run(t, new(FeatureFlagTestSuite))
+}
@@ -0,0 +1,94 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type FeatureFlagCacheTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+}
+
+func (suite *FeatureFlagCacheTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, filepath.Join(suite.T().TempDir(), "sqlite.db"))
+}
+
+func (suite *FeatureFlagCacheTestSuite) TearDownTest() {
+	suite.restoreConfig()
+}
+
+func (suite *FeatureFlagCacheTestSuite) TestCacheRefreshPicksUpChange() {
+	ctx := context.Background()
+
+	conn, err := sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	_, err = conn.NewCreateTable().Model(&gtsmodel.FeatureFlag{}).Exec(ctx)
+	suite.NoError(err)
+
+	// two instances sharing one database, each with its own cache
+	const ttl = 100 * time.Millisecond
+	reader := &featureFlagDB{conn: conn, flags: newExpiringCache(ttl)}
+	writer := &featureFlagDB{conn: conn, flags: newExpiringCache(ttl)}
+
+	enabled, err := reader.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.False(enabled)
+
+	suite.NoError(writer.SetFeatureFlag(ctx, "local_only_posts", true, ""))
+
+	// the writer sees its own change straight away...
+	enabled, err = writer.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.True(enabled)
+
+	// ...but the reader is still serving its cached flags
+	enabled, err = reader.IsFeatureEnabled(ctx, "local_only_posts")
+	suite.NoError(err)
+	suite.False(enabled)
+
+	// until its cache refreshes
+	suite.Eventually(func() bool {
+		enabled, err := reader.IsFeatureEnabled(ctx, "local_only_posts")
+		return err == nil && enabled
+	}, 10*ttl, ttl/10)
+}
+
+func TestFeatureFlagCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(FeatureFlagCacheTestSuite))
+}
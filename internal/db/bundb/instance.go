@@ -20,20 +20,34 @@ package bundb
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
+	"github.com/ReneKroon/ttlcache"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
 	"github.com/superseriousbusiness/gotosocial/internal/config"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
 	"github.com/uptrace/bun"
 )
 
 type instanceDB struct {
 	conn *DBConn
+
+	// localStats caches results of GetLocalInstanceStats
+	localStats *ttlcache.Cache
 }
 
+// localInstanceStatsCacheTTL is how long results of GetLocalInstanceStats are cached for.
+// The instance API gets polled a lot, and nobody needs these numbers to the second.
+const localInstanceStatsCacheTTL = time.Minute
+
+// localInstanceStatsCacheKey is the key that results of GetLocalInstanceStats are cached under.
+const localInstanceStatsCacheKey = "local"
+
 func (i *instanceDB) CountInstanceUsers(ctx context.Context, domain string) (int, db.Error) {
 	q := i.conn.
 		NewSelect().
@@ -78,6 +92,40 @@ func (i *instanceDB) CountInstanceStatuses(ctx context.Context, domain string) (
 	return count, nil
 }
 
+func (i *instanceDB) GetLocalInstanceStats(ctx context.Context) (*db.InstanceStats, db.Error) {
+	if cached, ok := i.localStats.Get(localInstanceStatsCacheKey); ok {
+		stats := *cached.(*db.InstanceStats)
+		return &stats, nil
+	}
+
+	host := viper.GetString(config.Keys.Host)
+
+	userCount, err := i.CountInstanceUsers(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCount, err := i.CountInstanceStatuses(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	domainCount, err := i.CountInstanceDomains(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &db.InstanceStats{
+		UserCount:   userCount,
+		StatusCount: statusCount,
+		DomainCount: domainCount,
+	}
+
+	cached := *stats
+	i.localStats.Set(localInstanceStatsCacheKey, &cached)
+	return stats, nil
+}
+
 func (i *instanceDB) CountInstanceDomains(ctx context.Context, domain string) (int, db.Error) {
 	q := i.conn.
 		NewSelect().
@@ -125,3 +173,90 @@ func (i *instanceDB) GetInstanceAccounts(ctx context.Context, domain string, max
 	}
 	return accounts, nil
 }
+
+func (i *instanceDB) PutThemeAsset(ctx context.Context, asset *gtsmodel.ThemeAsset) (string, db.Error) {
+	if err := i.conn.CheckWritable(); err != nil {
+		return "", err
+	}
+
+	var replacedPath string
+	if err := i.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		existing := &gtsmodel.ThemeAsset{}
+		err := tx.
+			NewSelect().
+			Model(existing).
+			Where("theme_asset.name = ?", asset.Name).
+			Scan(ctx)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if err == sql.ErrNoRows {
+			assetID, err := id.NewULID()
+			if err != nil {
+				return err
+			}
+			asset.ID = assetID
+			asset.CreatedAt = time.Now()
+			asset.UpdatedAt = asset.CreatedAt
+			asset.Version = 1
+
+			_, err = tx.
+				NewInsert().
+				Model(asset).
+				Exec(ctx)
+			return err
+		}
+
+		replacedPath = existing.Path
+		asset.ID = existing.ID
+		asset.CreatedAt = existing.CreatedAt
+		asset.UpdatedAt = time.Now()
+		asset.Version = existing.Version + 1
+
+		_, err = tx.
+			NewUpdate().
+			Model(asset).
+			Column("updated_at", "path", "content_type", "file_size", "version").
+			WherePK().
+			Exec(ctx)
+		return err
+	}); err != nil {
+		return "", i.conn.ProcessError(err)
+	}
+
+	if replacedPath == asset.Path {
+		// replaced in place, so there's nothing left over to remove
+		replacedPath = ""
+	}
+
+	return replacedPath, nil
+}
+
+func (i *instanceDB) GetThemeAsset(ctx context.Context, name string) (*gtsmodel.ThemeAsset, db.Error) {
+	asset := &gtsmodel.ThemeAsset{}
+
+	if err := i.conn.
+		NewSelect().
+		Model(asset).
+		Where("theme_asset.name = ?", name).
+		Scan(ctx); err != nil {
+		return nil, i.conn.ProcessError(err)
+	}
+
+	return asset, nil
+}
+
+func (i *instanceDB) GetThemeAssets(ctx context.Context) ([]*gtsmodel.ThemeAsset, db.Error) {
+	assets := []*gtsmodel.ThemeAsset{}
+
+	if err := i.conn.
+		NewSelect().
+		Model(&assets).
+		Order("theme_asset.name ASC").
+		Scan(ctx); err != nil {
+		return nil, i.conn.ProcessError(err)
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,236 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type InstanceTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *InstanceTestSuite) TestCountInstanceStatuses() {
+	ctx := context.Background()
+
+	// work out what the fixtures alone give us per domain
+	expected := map[string]int{}
+	for _, s := range suite.testStatuses {
+		for _, a := range suite.testAccounts {
+			if a.ID == s.AccountID {
+				expected[a.Domain]++
+			}
+		}
+	}
+
+	// seed a few more statuses from a couple of remote domains
+	for i, seed := range []struct {
+		id      string
+		account *gtsmodel.Account
+	}{
+		{"01FVZ1ZQ3JX0Z0K7A2H9T4C6BD", suite.testAccounts["remote_account_1"]},
+		{"01FVZ20AW5Q5XKZ3H1C2M9N8PE", suite.testAccounts["remote_account_1"]},
+		{"01FVZ20JGS6RT8JBN4D0V7X2QF", suite.testAccounts["remote_account_2"]},
+	} {
+		status := &gtsmodel.Status{
+			ID:                  seed.id,
+			URI:                 seed.account.URI + "/statuses/" + seed.id,
+			Text:                "status number " + string(rune('a'+i)),
+			AccountURI:          seed.account.URI,
+			AccountID:           seed.account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}
+		if err := suite.db.PutStatus(ctx, status); err != nil {
+			suite.FailNow(err.Error())
+		}
+		expected[seed.account.Domain]++
+	}
+
+	suite.NotZero(expected[""])
+	suite.Len(expected, 3)
+
+	for domain, count := range expected {
+		if domain == "" {
+			// local statuses are counted by our own host
+			domain = viper.GetString(config.Keys.Host)
+		}
+		actual, err := suite.db.CountInstanceStatuses(ctx, domain)
+		suite.NoError(err)
+		suite.Equal(count, actual, domain)
+	}
+
+	// nothing from a domain we've never heard of
+	count, err := suite.db.CountInstanceStatuses(ctx, "nowhere.example.org")
+	suite.NoError(err)
+	suite.Zero(count)
+}
+
+func (suite *InstanceTestSuite) TestGetLocalInstanceStats() {
+	ctx := context.Background()
+	host := "localhost:8080"
+
+	stats, err := suite.db.GetLocalInstanceStats(ctx)
+	suite.NoError(err)
+
+	userCount, err := suite.db.CountInstanceUsers(ctx, host)
+	suite.NoError(err)
+	statusCount, err := suite.db.CountInstanceStatuses(ctx, host)
+	suite.NoError(err)
+	domainCount, err := suite.db.CountInstanceDomains(ctx, host)
+	suite.NoError(err)
+
+	suite.Equal(userCount, stats.UserCount)
+	suite.Equal(statusCount, stats.StatusCount)
+	suite.Equal(domainCount, stats.DomainCount)
+	suite.NotZero(stats.UserCount)
+	suite.NotZero(stats.StatusCount)
+
+	// a new status doesn't show up until the cached stats expire
+	account := suite.testAccounts["local_account_1"]
+	suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+		ID:                  "01FW4K2QJX5AZ5X4PF0Q6SAV7M",
+		URI:                 account.URI + "/statuses/01FW4K2QJX5AZ5X4PF0Q6SAV7M",
+		Text:                "one more for the count",
+		AccountURI:          account.URI,
+		AccountID:           account.ID,
+		Local:               true,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}))
+
+	// changing what we got back shouldn't change what's cached either
+	stats.UserCount = 0
+
+	cached, err := suite.db.GetLocalInstanceStats(ctx)
+	suite.NoError(err)
+	suite.Equal(statusCount, cached.StatusCount)
+	suite.Equal(userCount, cached.UserCount)
+}
+
+func (suite *InstanceTestSuite) TestPutThemeAsset() {
+	ctx := context.Background()
+
+	asset := &gtsmodel.ThemeAsset{
+		Name:        "custom.css",
+		Path:        "theme/custom.css/01FWC4D8K2R7M5Q1T9V3X6Z0NB.css",
+		ContentType: "text/css",
+		FileSize:    1024,
+	}
+	replaced, err := suite.db.PutThemeAsset(ctx, asset)
+	suite.NoError(err)
+	suite.Empty(replaced)
+	suite.NotEmpty(asset.ID)
+	suite.Equal(1, asset.Version)
+
+	stored, err := suite.db.GetThemeAsset(ctx, "custom.css")
+	suite.NoError(err)
+	suite.Equal(asset.ID, stored.ID)
+	suite.Equal("text/css", stored.ContentType)
+	suite.Equal(1024, stored.FileSize)
+	suite.Equal(1, stored.Version)
+
+	_, err = suite.db.GetThemeAsset(ctx, "nothing.css")
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *InstanceTestSuite) TestReplaceThemeAsset() {
+	ctx := context.Background()
+
+	first := &gtsmodel.ThemeAsset{
+		Name:        "background.png",
+		Path:        "theme/background.png/01FWC4F0V6B2N8J4H1D7S3A9QX.png",
+		ContentType: "image/png",
+		FileSize:    2048,
+	}
+	_, err := suite.db.PutThemeAsset(ctx, first)
+	suite.NoError(err)
+
+	second := &gtsmodel.ThemeAsset{
+		Name:        "background.png",
+		Path:        "theme/background.png/01FWC4FQ3W8E5R1T6Y2U9I4O7P.webp",
+		ContentType: "image/webp",
+		FileSize:    512,
+	}
+	replaced, err := suite.db.PutThemeAsset(ctx, second)
+	suite.NoError(err)
+	suite.Equal(first.Path, replaced)
+
+	// same asset, next version
+	suite.Equal(first.ID, second.ID)
+	suite.Equal(2, second.Version)
+
+	stored, err := suite.db.GetThemeAsset(ctx, "background.png")
+	suite.NoError(err)
+	suite.Equal(2, stored.Version)
+	suite.Equal(second.Path, stored.Path)
+	suite.Equal("image/webp", stored.ContentType)
+	suite.Equal(512, stored.FileSize)
+
+	// replacing the file in place leaves nothing to clean up
+	replaced, err = suite.db.PutThemeAsset(ctx, &gtsmodel.ThemeAsset{
+		Name:        "background.png",
+		Path:        second.Path,
+		ContentType: "image/webp",
+		FileSize:    600,
+	})
+	suite.NoError(err)
+	suite.Empty(replaced)
+
+	stored, err = suite.db.GetThemeAsset(ctx, "background.png")
+	suite.NoError(err)
+	suite.Equal(3, stored.Version)
+}
+
+func (suite *InstanceTestSuite) TestGetThemeAssets() {
+	ctx := context.Background()
+
+	assets, err := suite.db.GetThemeAssets(ctx)
+	suite.NoError(err)
+	suite.Empty(assets)
+
+	for _, name := range []string{"logo.svg", "custom.css", "font.woff2"} {
+		_, err := suite.db.PutThemeAsset(ctx, &gtsmodel.ThemeAsset{
+			Name:        name,
+			Path:        "theme/" + name,
+			ContentType: "application/octet-stream",
+			FileSize:    1,
+		})
+		suite.NoError(err)
+	}
+
+	assets, err = suite.db.GetThemeAssets(ctx)
+	suite.NoError(err)
+	names := []string{}
+	for _, a := range assets {
+		names = append(names, a.Name)
+	}
+	suite.Equal([]string{"custom.css", "font.woff2", "logo.svg"}, names)
+}
+
+func TestInstanceTestSuite(t *testing.T) {
+	suite.Run(t, new(InstanceTestSuite))
+}
@@ -0,0 +1,580 @@
+/*
+GoToSocial
+Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/superseriousbusiness/gotosocial/internal/ap"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
+	"github.com/superseriousbusiness/gotosocial/internal/uris"
+	"github.com/uptrace/bun"
+)
+
+// mastodonImportBatchSize is how many rows ImportMastodon writes in each transaction.
+const mastodonImportBatchSize = 500
+
+// mastodonAccountsQuery selects the columns of mastodon's accounts table that
+// we have a use for, along with the user of each local account.
+const mastodonAccountsQuery = `SELECT
+	a.id, a.username, a.domain, a.display_name, a.note, a.uri, a.url,
+	a.inbox_url, a.outbox_url, a.followers_url, a.featured_collection_url,
+	a.avatar_remote_url, a.header_remote_url, a.public_key, a.private_key,
+	a.actor_type, a.locked, a.discoverable, a.memorial,
+	a.created_at, a.updated_at, a.last_webfingered_at, a.silenced_at, a.suspended_at,
+	u.email, u.encrypted_password, u.locale, u.admin, u.moderator,
+	u.disabled, u.approved, u.confirmed_at, u.created_at
+FROM accounts AS a
+LEFT JOIN users AS u ON u.account_id = a.id
+ORDER BY a.id`
+
+// mastodonFollowsQuery selects the columns of mastodon's follows table that we have a use for.
+const mastodonFollowsQuery = `SELECT
+	id, account_id, target_account_id, uri, show_reblogs, notify, created_at, updated_at
+FROM follows
+ORDER BY id`
+
+// mastodonAccount is one row of mastodonAccountsQuery.
+type mastodonAccount struct {
+	id                int64
+	username          string
+	domain            sql.NullString
+	displayName       sql.NullString
+	note              sql.NullString
+	uri               sql.NullString
+	url               sql.NullString
+	inboxURL          sql.NullString
+	outboxURL         sql.NullString
+	followersURL      sql.NullString
+	featuredURL       sql.NullString
+	avatarRemoteURL   sql.NullString
+	headerRemoteURL   sql.NullString
+	publicKey         sql.NullString
+	privateKey        sql.NullString
+	actorType         sql.NullString
+	locked            sql.NullBool
+	discoverable      sql.NullBool
+	memorial          sql.NullBool
+	createdAt         time.Time
+	updatedAt         time.Time
+	lastWebfingeredAt sql.NullTime
+	silencedAt        sql.NullTime
+	suspendedAt       sql.NullTime
+
+	email             sql.NullString
+	encryptedPassword sql.NullString
+	locale            sql.NullString
+	admin             sql.NullBool
+	moderator         sql.NullBool
+	disabled          sql.NullBool
+	approved          sql.NullBool
+	confirmedAt       sql.NullTime
+	userCreatedAt     sql.NullTime
+}
+
+func (m *mastodonAccount) scan(rows *sql.Rows) error {
+	return rows.Scan(
+		&m.id, &m.username, &m.domain, &m.displayName, &m.note, &m.uri, &m.url,
+		&m.inboxURL, &m.outboxURL, &m.followersURL, &m.featuredURL,
+		&m.avatarRemoteURL, &m.headerRemoteURL, &m.publicKey, &m.privateKey,
+		&m.actorType, &m.locked, &m.discoverable, &m.memorial,
+		&m.createdAt, &m.updatedAt, &m.lastWebfingeredAt, &m.silencedAt, &m.suspendedAt,
+		&m.email, &m.encryptedPassword, &m.locale, &m.admin, &m.moderator,
+		&m.disabled, &m.approved, &m.confirmedAt, &m.userCreatedAt,
+	)
+}
+
+// local returns true if this is an account on the mastodon instance itself.
+func (m *mastodonAccount) local() bool {
+	return m.domain.String == ""
+}
+
+// toAccount converts m into an account, and for local accounts the user that goes with it, if there is one.
+func (m *mastodonAccount) toAccount() (*gtsmodel.Account, *gtsmodel.User, error) {
+	accountID, err := id.NewULIDFromTime(m.createdAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actorType := m.actorType.String
+	if actorType == "" {
+		actorType = ap.ActorPerson
+	}
+
+	account := &gtsmodel.Account{
+		ID:              accountID,
+		CreatedAt:       m.createdAt,
+		UpdatedAt:       m.updatedAt,
+		Username:        m.username,
+		Domain:          m.domain.String,
+		AvatarRemoteURL: m.avatarRemoteURL.String,
+		HeaderRemoteURL: m.headerRemoteURL.String,
+		DisplayName:     m.displayName.String,
+		Note:            m.note.String,
+		Memorial:        m.memorial.Bool,
+		Bot:             actorType == ap.ActorService || actorType == ap.ActorApplication,
+		Locked:          m.locked.Bool,
+		Discoverable:    m.discoverable.Bool,
+		ActorType:       actorType,
+		SilencedAt:      m.silencedAt.Time,
+		SuspendedAt:     m.suspendedAt.Time,
+	}
+
+	if !m.local() {
+		publicKey, err := parseMastodonPublicKey(m.publicKey.String)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing public key: %s", err)
+		}
+
+		if m.uri.String == "" {
+			return nil, nil, errors.New("remote account has no uri")
+		}
+
+		account.URI = m.uri.String
+		account.URL = m.url.String
+		account.InboxURI = m.inboxURL.String
+		account.OutboxURI = m.outboxURL.String
+		account.FollowersURI = m.followersURL.String
+		account.FeaturedCollectionURI = m.featuredURL.String
+		account.PublicKey = publicKey
+		// mastodon doesn't store key ids, since they're all the same shape
+		account.PublicKeyURI = m.uri.String + "#main-key"
+		account.LastWebfingeredAt = m.lastWebfingeredAt.Time
+		if account.LastWebfingeredAt.IsZero() {
+			account.LastWebfingeredAt = m.updatedAt
+		}
+		return account, nil, nil
+	}
+
+	privateKey, err := parseMastodonPrivateKey(m.privateKey.String)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing private key: %s", err)
+	}
+
+	// mastodon doesn't store uris for its own accounts,
+	// it works them out the same way we do
+	accountURIs := uris.GenerateURIsForAccount(m.username)
+	account.URI = accountURIs.UserURI
+	account.URL = accountURIs.UserURL
+	account.InboxURI = accountURIs.InboxURI
+	account.OutboxURI = accountURIs.OutboxURI
+	account.FollowingURI = accountURIs.FollowingURI
+	account.FollowersURI = accountURIs.FollowersURI
+	account.FeaturedCollectionURI = accountURIs.CollectionURI
+	account.PrivateKey = privateKey
+	account.PublicKey = &privateKey.PublicKey
+	account.PublicKeyURI = accountURIs.PublicKeyURI
+	account.Privacy = gtsmodel.VisibilityDefault
+
+	// the instance actor and deleted users have no user
+	if m.encryptedPassword.String == "" {
+		return account, nil, nil
+	}
+
+	userCreatedAt := m.userCreatedAt.Time
+	if userCreatedAt.IsZero() {
+		userCreatedAt = m.createdAt
+	}
+
+	userID, err := id.NewULIDFromTime(userCreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// mastodon hashes passwords with bcrypt too, so they carry straight over
+	user := &gtsmodel.User{
+		ID:                userID,
+		CreatedAt:         userCreatedAt,
+		AccountID:         accountID,
+		EncryptedPassword: m.encryptedPassword.String,
+		Locale:            m.locale.String,
+		Moderator:         m.moderator.Bool,
+		Admin:             m.admin.Bool,
+		Disabled:          m.disabled.Bool,
+		Approved:          m.approved.Bool,
+	}
+	if m.confirmedAt.Valid {
+		user.Email = m.email.String
+		user.ConfirmedAt = m.confirmedAt.Time
+	} else {
+		user.UnconfirmedEmail = m.email.String
+	}
+
+	return account, user, nil
+}
+
+// mastodonFollow is one row of mastodonFollowsQuery.
+type mastodonFollow struct {
+	id              int64
+	accountID       int64
+	targetAccountID int64
+	uri             sql.NullString
+	showReblogs     sql.NullBool
+	notify          sql.NullBool
+	createdAt       time.Time
+	updatedAt       time.Time
+}
+
+func (m *mastodonFollow) scan(rows *sql.Rows) error {
+	return rows.Scan(&m.id, &m.accountID, &m.targetAccountID, &m.uri, &m.showReblogs, &m.notify, &m.createdAt, &m.updatedAt)
+}
+
+// mastodonImportedAccount is what we need to remember about an imported account to import follows.
+type mastodonImportedAccount struct {
+	id       string
+	username string
+	local    bool
+}
+
+// mastodonImport holds the state of one ImportMastodon run.
+type mastodonImport struct {
+	conn     *DBConn
+	source   *sql.DB
+	progress func(db.MastodonImportProgress)
+
+	// accounts maps mastodon account ids to what they became here
+	accounts map[int64]mastodonImportedAccount
+	counts   db.MastodonImportProgress
+}
+
+func (a *adminDB) ImportMastodon(ctx context.Context, source *sql.DB, progress func(db.MastodonImportProgress)) db.Error {
+	if err := a.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	i := &mastodonImport{
+		conn:     a.conn,
+		source:   source,
+		progress: progress,
+		accounts: make(map[int64]mastodonImportedAccount),
+	}
+
+	if err := i.importAccounts(ctx); err != nil {
+		return err
+	}
+
+	return i.importFollows(ctx)
+}
+
+func (i *mastodonImport) importAccounts(ctx context.Context) error {
+	rows, err := i.source.QueryContext(ctx, mastodonAccountsQuery)
+	if err != nil {
+		return fmt.Errorf("ImportMastodon: error selecting accounts: %s", err)
+	}
+	defer rows.Close()
+
+	batch := make([]*mastodonAccount, 0, mastodonImportBatchSize)
+	for rows.Next() {
+		m := &mastodonAccount{}
+		if err := m.scan(rows); err != nil {
+			return fmt.Errorf("ImportMastodon: error scanning account: %s", err)
+		}
+
+		if batch = append(batch, m); len(batch) == mastodonImportBatchSize {
+			if err := i.putAccounts(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ImportMastodon: error selecting accounts: %s", err)
+	}
+
+	return i.putAccounts(ctx, batch)
+}
+
+func (i *mastodonImport) putAccounts(ctx context.Context, batch []*mastodonAccount) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// only remember what's been imported once it's committed
+	imported := make(map[int64]mastodonImportedAccount, len(batch))
+	counts := i.counts
+
+	if err := i.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		for _, m := range batch {
+			account, user, err := m.toAccount()
+			if err != nil {
+				logrus.Warnf("ImportMastodon: skipping account %d (%s): %s", m.id, m.username, err)
+				counts.Skipped++
+				continue
+			}
+
+			res, err := tx.
+				NewInsert().
+				Model(account).
+				On("CONFLICT DO NOTHING").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			if n, err := res.RowsAffected(); err != nil {
+				return err
+			} else if n == 0 {
+				// the account may already be here from an earlier import,
+				// in which case point at that one and leave its user alone;
+				// anything else with the same name is a different account
+				account.ID, err = importedAccountID(ctx, tx, account)
+				if err != nil {
+					if !errors.Is(err, sql.ErrNoRows) {
+						return err
+					}
+					logrus.Warnf("ImportMastodon: skipping account %d (%s): it clashes with a different existing account", m.id, m.username)
+					counts.Skipped++
+					continue
+				}
+				user = nil
+			}
+
+			if user != nil {
+				res, err = tx.
+					NewInsert().
+					Model(user).
+					On("CONFLICT DO NOTHING").
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+
+				if n, err := res.RowsAffected(); err != nil {
+					return err
+				} else if n == 0 {
+					// a local account can't be used without its user,
+					// so take the account back out again too
+					if _, err := tx.
+						NewDelete().
+						Model(account).
+						WherePK().
+						Exec(ctx); err != nil {
+						return err
+					}
+					logrus.Warnf("ImportMastodon: skipping account %d (%s): email address is already in use", m.id, m.username)
+					counts.Skipped++
+					continue
+				}
+				counts.Users++
+			}
+
+			imported[m.id] = mastodonImportedAccount{id: account.ID, username: account.Username, local: m.local()}
+			counts.Accounts++
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("ImportMastodon: error putting accounts: %s", err)
+	}
+
+	for mastodonID, account := range imported {
+		i.accounts[mastodonID] = account
+	}
+	i.counts = counts
+	i.report()
+	return nil
+}
+
+func (i *mastodonImport) importFollows(ctx context.Context) error {
+	rows, err := i.source.QueryContext(ctx, mastodonFollowsQuery)
+	if err != nil {
+		return fmt.Errorf("ImportMastodon: error selecting follows: %s", err)
+	}
+	defer rows.Close()
+
+	batch := make([]*mastodonFollow, 0, mastodonImportBatchSize)
+	for rows.Next() {
+		m := &mastodonFollow{}
+		if err := m.scan(rows); err != nil {
+			return fmt.Errorf("ImportMastodon: error scanning follow: %s", err)
+		}
+
+		if batch = append(batch, m); len(batch) == mastodonImportBatchSize {
+			if err := i.putFollows(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ImportMastodon: error selecting follows: %s", err)
+	}
+
+	return i.putFollows(ctx, batch)
+}
+
+func (i *mastodonImport) putFollows(ctx context.Context, batch []*mastodonFollow) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	counts := i.counts
+
+	if err := i.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		for _, m := range batch {
+			account, ok := i.accounts[m.accountID]
+			if !ok {
+				logrus.Warnf("ImportMastodon: skipping follow %d: account %d wasn't imported", m.id, m.accountID)
+				counts.Skipped++
+				continue
+			}
+
+			targetAccount, ok := i.accounts[m.targetAccountID]
+			if !ok {
+				logrus.Warnf("ImportMastodon: skipping follow %d: target account %d wasn't imported", m.id, m.targetAccountID)
+				counts.Skipped++
+				continue
+			}
+
+			followID, err := id.NewULIDFromTime(m.createdAt)
+			if err != nil {
+				return err
+			}
+
+			uri := m.uri.String
+			if uri == "" {
+				if !account.local {
+					logrus.Warnf("ImportMastodon: skipping follow %d: remote follow has no uri", m.id)
+					counts.Skipped++
+					continue
+				}
+				uri = uris.GenerateURIForFollow(account.username, followID)
+			}
+
+			// showing reblogs is the default, so don't
+			// switch it off just because it's not set
+			showReblogs := !m.showReblogs.Valid || m.showReblogs.Bool
+
+			if _, err := tx.
+				NewInsert().
+				Model(&gtsmodel.Follow{
+					ID:              followID,
+					CreatedAt:       m.createdAt,
+					UpdatedAt:       m.updatedAt,
+					URI:             uri,
+					AccountID:       account.id,
+					TargetAccountID: targetAccount.id,
+					ShowReblogs:     showReblogs,
+					Notify:          m.notify.Bool,
+				}).
+				On("CONFLICT DO NOTHING").
+				Exec(ctx); err != nil {
+				return err
+			}
+			counts.Follows++
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("ImportMastodon: error putting follows: %s", err)
+	}
+
+	i.counts = counts
+	i.report()
+	return nil
+}
+
+func (i *mastodonImport) report() {
+	logrus.Infof("ImportMastodon: imported %d accounts, %d users and %d follows so far, skipped %d", i.counts.Accounts, i.counts.Users, i.counts.Follows, i.counts.Skipped)
+	if i.progress != nil {
+		i.progress(i.counts)
+	}
+}
+
+// importedAccountID returns the id of the existing account with the same username and domain as
+// account, as long as an earlier import put it there. Remote accounts are matched on their uri too.
+// Local uris only depend on the username, so local accounts are matched on the key that was
+// imported along with them instead. If there's no such account, sql.ErrNoRows is returned.
+func importedAccountID(ctx context.Context, tx bun.Tx, account *gtsmodel.Account) (string, error) {
+	existing := &gtsmodel.Account{}
+
+	q := tx.
+		NewSelect().
+		Model(existing).
+		Column("id", "uri", "public_key").
+		Where("username = ?", account.Username)
+
+	if account.Domain == "" {
+		q = q.Where("? IS NULL", bun.Ident("domain"))
+	} else {
+		q = q.Where("domain = ?", account.Domain)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return "", err
+	}
+
+	if account.Domain != "" && existing.URI != account.URI {
+		return "", sql.ErrNoRows
+	}
+
+	if account.Domain == "" && (existing.PublicKey == nil || !existing.PublicKey.Equal(account.PublicKey)) {
+		return "", sql.ErrNoRows
+	}
+
+	return existing.ID, nil
+}
+
+// parseMastodonPublicKey parses a PEM encoded RSA public key, as mastodon stores them.
+func parseMastodonPublicKey(s string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	// mastodon writes PKIX keys, but some
+	// other servers send PKCS1 ones instead
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+
+	return publicKey, nil
+}
+
+// parseMastodonPrivateKey parses a PEM encoded PKCS1 RSA private key, as mastodon stores them.
+func parseMastodonPrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
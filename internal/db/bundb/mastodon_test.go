@@ -0,0 +1,241 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type MastodonImportTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+// newMastodonDB returns a sqlite database laid out like the parts of a mastodon database that get imported.
+func (suite *MastodonImportTestSuite) newMastodonDB() *sql.DB {
+	source, err := sql.Open("sqlite", "file:"+filepath.Join(suite.T().TempDir(), "mastodon.db"))
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.T().Cleanup(func() { source.Close() })
+
+	for _, stmt := range []string{
+		`CREATE TABLE accounts (
+			id INTEGER PRIMARY KEY, username TEXT NOT NULL, domain TEXT, display_name TEXT, note TEXT, uri TEXT, url TEXT,
+			inbox_url TEXT, outbox_url TEXT, followers_url TEXT, featured_collection_url TEXT,
+			avatar_remote_url TEXT, header_remote_url TEXT, public_key TEXT, private_key TEXT,
+			actor_type TEXT, locked BOOLEAN, discoverable BOOLEAN, memorial BOOLEAN,
+			created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL, last_webfingered_at DATETIME, silenced_at DATETIME, suspended_at DATETIME
+		)`,
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY, account_id INTEGER, email TEXT, encrypted_password TEXT, locale TEXT,
+			admin BOOLEAN, moderator BOOLEAN, disabled BOOLEAN, approved BOOLEAN, confirmed_at DATETIME, created_at DATETIME
+		)`,
+		`CREATE TABLE follows (
+			id INTEGER PRIMARY KEY, account_id INTEGER NOT NULL, target_account_id INTEGER NOT NULL, uri TEXT,
+			show_reblogs BOOLEAN, notify BOOLEAN, created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL
+		)`,
+	} {
+		if _, err := source.Exec(stmt); err != nil {
+			suite.FailNow(err.Error())
+		}
+	}
+
+	return source
+}
+
+func (suite *MastodonImportTestSuite) TestImportMastodon() {
+	ctx := context.Background()
+	source := suite.newMastodonDB()
+	createdAt := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	suite.NoError(err)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	suite.NoError(err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+
+	for _, account := range [][]interface{}{
+		// a local account with a user
+		{1, "mastodon_user", nil, nil, privateKeyPEM, true},
+		// a remote account
+		{3, "remote_friend", "remote.example.org", "https://remote.example.org/users/remote_friend", nil, false},
+		// a remote account that can't be imported
+		{4, "broken", "remote.example.org", "https://remote.example.org/users/broken", nil, false},
+	} {
+		publicKey := publicKeyPEM
+		if account[1] == "broken" {
+			publicKey = "not a key"
+		}
+		_, err := source.Exec(`INSERT INTO accounts (id, username, domain, uri, private_key, locked, public_key, display_name, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'Display Name', ?, ?)`, append(account, publicKey, createdAt, createdAt)...)
+		suite.NoError(err)
+	}
+
+	_, err = source.Exec(`INSERT INTO users (id, account_id, email, encrypted_password, locale, admin, moderator, disabled, approved, confirmed_at, created_at)
+		VALUES (1, 1, 'mastodon_user@example.org', '$2a$10$KIc1pbQ5Z8d5ygQ4nA0WwOHcn3t0RmHqAwZgtr7PxX0P3e9cYJ0Ji', 'de', 0, 1, 0, 1, ?, ?)`, createdAt, createdAt)
+	suite.NoError(err)
+
+	for _, follow := range [][]interface{}{
+		{1, 1, 3, nil},
+		{2, 3, 1, "https://remote.example.org/follows/1"},
+		// broken wasn't imported
+		{4, 1, 4, nil},
+	} {
+		_, err := source.Exec(`INSERT INTO follows (id, account_id, target_account_id, uri, show_reblogs, notify, created_at, updated_at)
+			VALUES (?, ?, ?, ?, NULL, 0, ?, ?)`, append(follow, createdAt, createdAt)...)
+		suite.NoError(err)
+	}
+
+	var progress db.MastodonImportProgress
+	suite.NoError(suite.db.ImportMastodon(ctx, source, func(p db.MastodonImportProgress) { progress = p }))
+	suite.Equal(db.MastodonImportProgress{Accounts: 2, Users: 1, Follows: 2, Skipped: 2}, progress)
+
+	local, err := suite.db.GetLocalAccountByUsername(ctx, "mastodon_user")
+	suite.NoError(err)
+	suite.Equal("http://localhost:8080/users/mastodon_user", local.URI)
+	suite.Equal("Display Name", local.DisplayName)
+	suite.Equal(createdAt, local.CreatedAt.UTC())
+	suite.True(local.Locked)
+	suite.True(privateKey.Equal(local.PrivateKey))
+
+	user := &gtsmodel.User{}
+	suite.NoError(suite.db.GetWhere(ctx, []db.Where{{Key: "account_id", Value: local.ID}}, user))
+	suite.Equal("mastodon_user@example.org", user.Email)
+	suite.Equal("de", user.Locale)
+	suite.True(user.Moderator)
+	suite.False(user.Admin)
+
+	remote, err := suite.db.GetAccountByURI(ctx, "https://remote.example.org/users/remote_friend")
+	suite.NoError(err)
+	suite.Equal("remote.example.org", remote.Domain)
+	suite.Equal("https://remote.example.org/users/remote_friend#main-key", remote.PublicKeyURI)
+	suite.False(remote.Locked)
+
+	for _, follow := range [][2]*gtsmodel.Account{{local, remote}, {remote, local}} {
+		following, err := suite.db.IsFollowing(ctx, follow[0], follow[1])
+		suite.NoError(err)
+		suite.True(following, "%s should follow %s", follow[0].Username, follow[1].Username)
+	}
+
+	// importing again finds everything already here
+	suite.NoError(suite.db.ImportMastodon(ctx, source, func(p db.MastodonImportProgress) { progress = p }))
+	suite.Equal(db.MastodonImportProgress{Accounts: 2, Users: 0, Follows: 2, Skipped: 2}, progress)
+
+	follows, err := suite.db.GetAccountFollows(ctx, local.ID)
+	suite.NoError(err)
+	suite.Len(follows, 1)
+}
+
+func (suite *MastodonImportTestSuite) TestImportMastodonUsernameClash() {
+	ctx := context.Background()
+	source := suite.newMastodonDB()
+	createdAt := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	zork := suite.testAccounts["local_account_1"]
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	suite.NoError(err)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	suite.NoError(err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+
+	for _, account := range [][]interface{}{
+		// a local account with the same username as a different account here
+		{1, zork.Username, nil, nil, privateKeyPEM, publicKeyPEM},
+		{2, "remote_friend", "remote.example.org", "https://remote.example.org/users/remote_friend", nil, publicKeyPEM},
+	} {
+		_, err := source.Exec(`INSERT INTO accounts (id, username, domain, uri, private_key, public_key, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, append(account, createdAt, createdAt)...)
+		suite.NoError(err)
+	}
+
+	_, err = source.Exec(`INSERT INTO users (id, account_id, email, encrypted_password, confirmed_at, created_at)
+		VALUES (1, 1, 'zork@mastodon.example.org', '$2a$10$KIc1pbQ5Z8d5ygQ4nA0WwOHcn3t0RmHqAwZgtr7PxX0P3e9cYJ0Ji', ?, ?)`, createdAt, createdAt)
+	suite.NoError(err)
+
+	_, err = source.Exec(`INSERT INTO follows (id, account_id, target_account_id, created_at, updated_at) VALUES (1, 1, 2, ?, ?)`, createdAt, createdAt)
+	suite.NoError(err)
+
+	// the clashing account and its follow are skipped, rather than taking over zork
+	var progress db.MastodonImportProgress
+	suite.NoError(suite.db.ImportMastodon(ctx, source, func(p db.MastodonImportProgress) { progress = p }))
+	suite.Equal(db.MastodonImportProgress{Accounts: 1, Users: 0, Follows: 0, Skipped: 2}, progress)
+
+	existing, err := suite.db.GetLocalAccountByUsername(ctx, zork.Username)
+	suite.NoError(err)
+	suite.Equal(zork.ID, existing.ID)
+	suite.True(zork.PublicKey.Equal(existing.PublicKey))
+
+	remote, err := suite.db.GetAccountByURI(ctx, "https://remote.example.org/users/remote_friend")
+	suite.NoError(err)
+	following, err := suite.db.IsFollowing(ctx, zork, remote)
+	suite.NoError(err)
+	suite.False(following)
+
+	err = suite.db.GetWhere(ctx, []db.Where{{Key: "email", Value: "zork@mastodon.example.org"}}, &gtsmodel.User{})
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *MastodonImportTestSuite) TestImportMastodonEmailClash() {
+	ctx := context.Background()
+	source := suite.newMastodonDB()
+	createdAt := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	zorkUser := suite.testUsers["local_account_1"]
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	suite.NoError(err)
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	suite.NoError(err)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+
+	_, err = source.Exec(`INSERT INTO accounts (id, username, private_key, public_key, created_at, updated_at)
+		VALUES (1, 'newcomer', ?, ?, ?, ?)`, privateKeyPEM, publicKeyPEM, createdAt, createdAt)
+	suite.NoError(err)
+
+	// a user with the same email address as zork's
+	_, err = source.Exec(`INSERT INTO users (id, account_id, email, encrypted_password, confirmed_at, created_at)
+		VALUES (1, 1, ?, '$2a$10$KIc1pbQ5Z8d5ygQ4nA0WwOHcn3t0RmHqAwZgtr7PxX0P3e9cYJ0Ji', ?, ?)`, zorkUser.Email, createdAt, createdAt)
+	suite.NoError(err)
+
+	// the account is skipped along with its user, rather than left behind without one
+	var progress db.MastodonImportProgress
+	suite.NoError(suite.db.ImportMastodon(ctx, source, func(p db.MastodonImportProgress) { progress = p }))
+	suite.Equal(db.MastodonImportProgress{Accounts: 0, Users: 0, Follows: 0, Skipped: 1}, progress)
+
+	_, err = suite.db.GetLocalAccountByUsername(ctx, "newcomer")
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func TestMastodonImportTestSuite(t *testing.T) {
+	suite.Run(t, new(MastodonImportTestSuite))
+}
@@ -20,10 +20,13 @@ package bundb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 )
 
 type mediaDB struct {
@@ -48,3 +51,445 @@ func (m *mediaDB) GetAttachmentByID(ctx context.Context, id string) (*gtsmodel.M
 	}
 	return attachment, nil
 }
+
+func (m *mediaDB) GetMediaByIDs(ctx context.Context, ids []string) ([]*gtsmodel.MediaAttachment, db.Error) {
+	if len(ids) == 0 {
+		return []*gtsmodel.MediaAttachment{}, nil
+	}
+
+	attachments := make([]*gtsmodel.MediaAttachment, 0, len(ids))
+
+	q := m.newMediaQ(&attachments).
+		Where("media_attachment.id IN (?)", bun.In(ids))
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	// put the attachments back in the order they were asked for
+	byID := make(map[string]*gtsmodel.MediaAttachment, len(attachments))
+	for _, a := range attachments {
+		byID[a.ID] = a
+	}
+
+	ordered := make([]*gtsmodel.MediaAttachment, 0, len(attachments))
+	for _, id := range ids {
+		if a, ok := byID[id]; ok {
+			ordered = append(ordered, a)
+		}
+	}
+
+	return ordered, nil
+}
+
+func (m *mediaDB) UpdateAttachmentDescription(ctx context.Context, attachmentID string, description string) db.Error {
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := m.conn.
+		NewUpdate().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Set("description = ?", description).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", attachmentID).
+		Exec(ctx)
+	return m.conn.ProcessError(err)
+}
+
+func (m *mediaDB) UpdateAttachmentFocus(ctx context.Context, attachmentID string, focusX float32, focusY float32) db.Error {
+	if focusX < -1 || focusX > 1 || focusY < -1 || focusY > 1 {
+		return fmt.Errorf("focus %f,%f out of range: x and y must be between -1 and 1", focusX, focusY)
+	}
+
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	// the focus lives inside the file_meta json, so read it back
+	// and rewrite it in the same transaction to avoid clobbering
+	// any concurrent change to the rest of the metadata
+	return m.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		attachment := &gtsmodel.MediaAttachment{}
+		if err := tx.
+			NewSelect().
+			Model(attachment).
+			Column("id", "file_meta").
+			Where("id = ?", attachmentID).
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		attachment.FileMeta.Focus.X = focusX
+		attachment.FileMeta.Focus.Y = focusY
+		attachment.UpdatedAt = time.Now()
+
+		_, err := tx.
+			NewUpdate().
+			Model(attachment).
+			Column("file_meta", "updated_at").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+}
+
+func (m *mediaDB) GetAccountMedia(ctx context.Context, accountID string, onlyImages bool, maxID string, limit int) ([]*gtsmodel.MediaAttachment, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	attachments := make([]*gtsmodel.MediaAttachment, 0, limit)
+
+	fileTypes := []gtsmodel.FileType{gtsmodel.FileTypeImage}
+	if !onlyImages {
+		fileTypes = append(fileTypes, gtsmodel.FileTypeGif, gtsmodel.FileTypeVideo)
+	}
+
+	q := m.conn.
+		NewSelect().
+		Model(&attachments).
+		Where("media_attachment.account_id = ?", accountID).
+		Where("media_attachment.type IN (?)", bun.In(fileTypes)).
+		Where("media_attachment.processing = ?", gtsmodel.ProcessingStatusProcessed).
+		// skip anything that hasn't been attached to a status (yet), eg., avatars and headers
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("media_attachment.status_id")).
+		Order("media_attachment.id DESC")
+
+	if maxID != "" {
+		q = q.Where("media_attachment.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(attachments) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return attachments, nil
+}
+
+func (m *mediaDB) GetMediaStats(ctx context.Context, since time.Time) (map[gtsmodel.FileType]*db.MediaStats, db.Error) {
+	// file and thumbnail are stored as json,
+	// so their sizes have to be dug out of there
+	var sizeExpr string
+	switch m.conn.Dialect().Name() {
+	case dialect.PG:
+		sizeExpr = "COALESCE(SUM((?->>'FileSize')::BIGINT), 0)"
+	case dialect.SQLite:
+		sizeExpr = "COALESCE(SUM(json_extract(?, '$.FileSize')), 0)"
+	default:
+		return nil, fmt.Errorf("media stats not supported for dialect %s", m.conn.Dialect().Name())
+	}
+
+	rows := []struct {
+		Type           gtsmodel.FileType
+		Count          int
+		FileBytes      int64
+		ThumbnailBytes int64
+	}{}
+
+	q := m.conn.
+		NewSelect().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Column("type").
+		ColumnExpr("COUNT(*) AS ?", bun.Ident("count")).
+		ColumnExpr(sizeExpr+" AS ?", bun.Ident("file"), bun.Ident("file_bytes")).
+		ColumnExpr(sizeExpr+" AS ?", bun.Ident("thumbnail"), bun.Ident("thumbnail_bytes")).
+		Group("type")
+
+	if !since.IsZero() {
+		q = q.Where("created_at >= ?", since)
+	}
+
+	if err := q.Scan(ctx, &rows); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	stats := make(map[gtsmodel.FileType]*db.MediaStats, len(rows))
+	for _, r := range rows {
+		stats[r.Type] = &db.MediaStats{
+			Count:          r.Count,
+			FileBytes:      r.FileBytes,
+			ThumbnailBytes: r.ThumbnailBytes,
+		}
+	}
+
+	return stats, nil
+}
+
+func (m *mediaDB) GetAttachmentsWithoutFileHash(ctx context.Context, maxID string, limit int) ([]*gtsmodel.MediaAttachment, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	attachments := make([]*gtsmodel.MediaAttachment, 0, limit)
+
+	q := m.conn.
+		NewSelect().
+		Model(&attachments).
+		WhereGroup(" AND ", whereEmptyOrNull("media_attachment.file_hash")).
+		Order("media_attachment.id DESC")
+
+	if maxID != "" {
+		q = q.Where("media_attachment.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(attachments) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return attachments, nil
+}
+
+func (m *mediaDB) SetAttachmentFileHash(ctx context.Context, attachmentID string, fileHash string) db.Error {
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := m.conn.
+		NewUpdate().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Set("file_hash = ?", fileHash).
+		Where("id = ?", attachmentID).
+		Exec(ctx)
+	return m.conn.ProcessError(err)
+}
+
+func (m *mediaDB) GetMediaMissingBlurhash(ctx context.Context, force bool, maxID string, limit int) ([]*gtsmodel.MediaAttachment, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	attachments := make([]*gtsmodel.MediaAttachment, 0, limit)
+
+	q := m.conn.
+		NewSelect().
+		Model(&attachments).
+		Where("media_attachment.type IN (?)", bun.In([]gtsmodel.FileType{gtsmodel.FileTypeImage, gtsmodel.FileTypeGif})).
+		Where("media_attachment.processing = ?", gtsmodel.ProcessingStatusProcessed).
+		Where("? IS NULL", bun.Ident("media_attachment.pruned_at")).
+		Order("media_attachment.id DESC")
+
+	if !force {
+		q = q.WhereGroup(" AND ", whereEmptyOrNull("media_attachment.blurhash"))
+	}
+
+	if maxID != "" {
+		q = q.Where("media_attachment.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(attachments) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return attachments, nil
+}
+
+func (m *mediaDB) SetAttachmentBlurhash(ctx context.Context, attachmentID string, blurhash string) db.Error {
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := m.conn.
+		NewUpdate().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Set("blurhash = ?", blurhash).
+		Where("id = ?", attachmentID).
+		Exec(ctx)
+	return m.conn.ProcessError(err)
+}
+
+func (m *mediaDB) GetDuplicateMediaByHash(ctx context.Context, maxHash string, limit int) ([]*db.MediaDuplicates, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	hashes := make([]string, 0, limit)
+
+	q := m.conn.
+		NewSelect().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Column("media_attachment.file_hash").
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("media_attachment.file_hash")).
+		Group("media_attachment.file_hash").
+		Having("COUNT(*) > 1").
+		Order("media_attachment.file_hash DESC")
+
+	if maxHash != "" {
+		q = q.Where("media_attachment.file_hash < ?", maxHash)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx, &hashes); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(hashes) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	// fetch the attachments for the whole page in one go
+	attachments := []*gtsmodel.MediaAttachment{}
+	if err := m.conn.
+		NewSelect().
+		Model(&attachments).
+		Where("media_attachment.file_hash IN (?)", bun.In(hashes)).
+		Order("media_attachment.id ASC").
+		Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	duplicates := make([]*db.MediaDuplicates, 0, len(hashes))
+	byHash := make(map[string]*db.MediaDuplicates, len(hashes))
+	for _, hash := range hashes {
+		d := &db.MediaDuplicates{FileHash: hash}
+		duplicates = append(duplicates, d)
+		byHash[hash] = d
+	}
+
+	for _, a := range attachments {
+		d := byHash[a.FileHash]
+		d.Attachments = append(d.Attachments, a)
+	}
+
+	return duplicates, nil
+}
+
+func (m *mediaDB) GetRemoteMediaOlderThan(ctx context.Context, cutoff time.Time, maxID string, limit int) ([]*gtsmodel.MediaAttachment, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	attachments := make([]*gtsmodel.MediaAttachment, 0, limit)
+
+	q := m.conn.
+		NewSelect().
+		Model(&attachments).
+		WhereGroup(" AND ", whereNotEmptyAndNotNull("media_attachment.remote_url")).
+		Where("? IS NULL", bun.Ident("media_attachment.pruned_at")).
+		// covered by media_attachments_last_accessed_idx
+		Where("COALESCE(?, ?) < ?", bun.Ident("media_attachment.last_accessed_at"), bun.Ident("media_attachment.created_at"), cutoff).
+		Order("media_attachment.id DESC")
+
+	if maxID != "" {
+		q = q.Where("media_attachment.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(attachments) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return attachments, nil
+}
+
+func (m *mediaDB) SetAttachmentAccessedAt(ctx context.Context, attachmentID string, accessedAt time.Time) db.Error {
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := m.conn.
+		NewUpdate().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Set("last_accessed_at = ?", accessedAt).
+		Where("id = ?", attachmentID).
+		Exec(ctx)
+	return m.conn.ProcessError(err)
+}
+
+func (m *mediaDB) SetAttachmentPruned(ctx context.Context, attachmentID string) db.Error {
+	if err := m.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := m.conn.
+		NewUpdate().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Set("pruned_at = ?", time.Now()).
+		Where("id = ?", attachmentID).
+		Exec(ctx)
+	return m.conn.ProcessError(err)
+}
+
+func (m *mediaDB) GetRemoteEmojiNeedingFetch(ctx context.Context, domain string, limit int) ([]*gtsmodel.Emoji, db.Error) {
+	if domain == "" {
+		return nil, fmt.Errorf("GetRemoteEmojiNeedingFetch: domain must be set, local emoji are always cached")
+	}
+
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	emojis := make([]*gtsmodel.Emoji, 0, limit)
+
+	q := m.conn.
+		NewSelect().
+		Model(&emojis).
+		Where("LOWER(emoji.domain) = LOWER(?)", domain).
+		Where("emoji.disabled = ?", false).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereGroup(" OR ", whereEmptyOrNull("emoji.image_url")).
+				WhereGroup(" OR ", whereEmptyOrNull("emoji.image_static_url"))
+		}).
+		Order("emoji.id DESC")
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	if len(emojis) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return emojis, nil
+}
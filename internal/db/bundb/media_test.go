@@ -0,0 +1,410 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
+)
+
+type MediaTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *MediaTestSuite) TestGetAttachmentByID() {
+	testAttachment := suite.testAttachments["admin_account_status_1_attachment_1"]
+
+	attachment, err := suite.db.GetAttachmentByID(context.Background(), testAttachment.ID)
+	suite.NoError(err)
+	suite.Equal(testAttachment.ID, attachment.ID)
+}
+
+func (suite *MediaTestSuite) TestGetMediaStatsAllTime() {
+	stats, err := suite.db.GetMediaStats(context.Background(), time.Time{})
+	suite.NoError(err)
+
+	total := 0
+	for _, s := range stats {
+		total += s.Count
+	}
+	suite.Equal(len(suite.testAttachments), total)
+
+	var imageBytes, imageThumbBytes int64
+	for _, a := range suite.testAttachments {
+		if a.Type == gtsmodel.FileTypeImage {
+			imageBytes += int64(a.File.FileSize)
+			imageThumbBytes += int64(a.Thumbnail.FileSize)
+		}
+	}
+	suite.Equal(imageBytes, stats[gtsmodel.FileTypeImage].FileBytes)
+	suite.Equal(imageThumbBytes, stats[gtsmodel.FileTypeImage].ThumbnailBytes)
+}
+
+func (suite *MediaTestSuite) TestGetMediaStatsSince() {
+	since := time.Now().Add(-2 * time.Hour)
+
+	stats, err := suite.db.GetMediaStats(context.Background(), since)
+	suite.NoError(err)
+
+	expected := map[gtsmodel.FileType]int{}
+	for _, a := range suite.testAttachments {
+		if !a.CreatedAt.Before(since) {
+			expected[a.Type]++
+		}
+	}
+
+	suite.Len(stats, len(expected))
+	for fileType, count := range expected {
+		suite.Equal(count, stats[fileType].Count)
+	}
+}
+
+// putGalleryMedia stores a few extra attachments for the given account,
+// on top of the standard test attachments, so there's something to page through.
+func (suite *MediaTestSuite) putGalleryMedia(accountID string) {
+	testStatus := suite.testStatuses["local_account_1_status_1"]
+
+	for _, a := range []struct {
+		fileType   gtsmodel.FileType
+		processing gtsmodel.ProcessingStatus
+		statusID   string
+	}{
+		{gtsmodel.FileTypeImage, gtsmodel.ProcessingStatusProcessed, testStatus.ID},
+		{gtsmodel.FileTypeVideo, gtsmodel.ProcessingStatusProcessed, testStatus.ID},
+		{gtsmodel.FileTypeImage, gtsmodel.ProcessingStatusProcessed, testStatus.ID},
+		{gtsmodel.FileTypeAudio, gtsmodel.ProcessingStatusProcessed, testStatus.ID},
+		{gtsmodel.FileTypeImage, gtsmodel.ProcessingStatusProcessing, testStatus.ID},
+		{gtsmodel.FileTypeImage, gtsmodel.ProcessingStatusProcessed, ""},
+	} {
+		attachmentID, err := id.NewULID()
+		suite.NoError(err)
+
+		err = suite.db.Put(context.Background(), &gtsmodel.MediaAttachment{
+			ID:         attachmentID,
+			StatusID:   a.statusID,
+			AccountID:  accountID,
+			Type:       a.fileType,
+			Processing: a.processing,
+			File:       gtsmodel.File{Path: "whatever", ContentType: "image/jpeg", FileSize: 1},
+			Thumbnail:  gtsmodel.Thumbnail{Path: "whatever", ContentType: "image/jpeg", FileSize: 1},
+		})
+		suite.NoError(err)
+	}
+}
+
+func (suite *MediaTestSuite) TestGetAccountMediaPaging() {
+	testAccount := suite.testAccounts["local_account_1"]
+	suite.putGalleryMedia(testAccount.ID)
+
+	// page through two at a time
+	all := []*gtsmodel.MediaAttachment{}
+	maxID := ""
+	for {
+		attachments, err := suite.db.GetAccountMedia(context.Background(), testAccount.ID, false, maxID, 2)
+		if err == db.ErrNoEntries {
+			break
+		}
+		suite.NoError(err)
+		suite.LessOrEqual(len(attachments), 2)
+
+		all = append(all, attachments...)
+		maxID = attachments[len(attachments)-1].ID
+	}
+
+	// 3 of the extra attachments are images/videos that are attached and processed,
+	// plus the gif from the standard test attachments
+	suite.Len(all, 4)
+	for i, a := range all {
+		suite.Equal(testAccount.ID, a.AccountID)
+		suite.NotEmpty(a.StatusID)
+		suite.Equal(gtsmodel.ProcessingStatusProcessed, a.Processing)
+		suite.NotEqual(gtsmodel.FileTypeAudio, a.Type)
+		if i > 0 {
+			suite.Less(a.ID, all[i-1].ID)
+		}
+	}
+}
+
+func (suite *MediaTestSuite) TestGetAccountMediaOnlyImages() {
+	testAccount := suite.testAccounts["local_account_1"]
+	suite.putGalleryMedia(testAccount.ID)
+
+	attachments, err := suite.db.GetAccountMedia(context.Background(), testAccount.ID, true, "", 20)
+	suite.NoError(err)
+	suite.Len(attachments, 2)
+	for _, a := range attachments {
+		suite.Equal(gtsmodel.FileTypeImage, a.Type)
+	}
+}
+
+func (suite *MediaTestSuite) TestGetAttachmentsWithoutFileHash() {
+	attachments, err := suite.db.GetAttachmentsWithoutFileHash(context.Background(), "", 0)
+	suite.NoError(err)
+	suite.Len(attachments, len(suite.testAttachments))
+
+	hashed := suite.testAttachments["admin_account_status_1_attachment_1"]
+	err = suite.db.SetAttachmentFileHash(context.Background(), hashed.ID, strings.Repeat("a", 64))
+	suite.NoError(err)
+
+	attachments, err = suite.db.GetAttachmentsWithoutFileHash(context.Background(), "", 0)
+	suite.NoError(err)
+	suite.Len(attachments, len(suite.testAttachments)-1)
+	for _, a := range attachments {
+		suite.NotEqual(hashed.ID, a.ID)
+	}
+
+	attachment, err := suite.db.GetAttachmentByID(context.Background(), hashed.ID)
+	suite.NoError(err)
+	suite.Equal(strings.Repeat("a", 64), attachment.FileHash)
+}
+
+func (suite *MediaTestSuite) TestGetMediaMissingBlurhash() {
+	ctx := context.Background()
+
+	// all the test attachments have a blurhash already
+	attachments, err := suite.db.GetMediaMissingBlurhash(ctx, false, "", 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(attachments)
+
+	// unless we want to redo them all anyway
+	attachments, err = suite.db.GetMediaMissingBlurhash(ctx, true, "", 0)
+	suite.NoError(err)
+	suite.Len(attachments, len(suite.testAttachments))
+
+	missing := suite.testAttachments["local_account_1_status_4_attachment_1"]
+	suite.NoError(suite.db.SetAttachmentBlurhash(ctx, missing.ID, ""))
+
+	// pruned attachments have nothing to work a blurhash out from
+	pruned := suite.testAttachments["admin_account_status_1_attachment_1"]
+	suite.NoError(suite.db.SetAttachmentBlurhash(ctx, pruned.ID, ""))
+	suite.NoError(suite.db.SetAttachmentPruned(ctx, pruned.ID))
+
+	attachments, err = suite.db.GetMediaMissingBlurhash(ctx, false, "", 0)
+	suite.NoError(err)
+	if suite.Len(attachments, 1) {
+		suite.Equal(missing.ID, attachments[0].ID)
+	}
+
+	suite.NoError(suite.db.SetAttachmentBlurhash(ctx, missing.ID, "LNJRdVM{00Rj%Mayt7j[4nWBofRj"))
+
+	attachments, err = suite.db.GetMediaMissingBlurhash(ctx, false, "", 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(attachments)
+
+	attachment, err := suite.db.GetAttachmentByID(ctx, missing.ID)
+	suite.NoError(err)
+	suite.Equal("LNJRdVM{00Rj%Mayt7j[4nWBofRj", attachment.Blurhash)
+}
+
+func (suite *MediaTestSuite) TestGetDuplicateMediaByHash() {
+	hashA := strings.Repeat("a", 64)
+	hashB := strings.Repeat("b", 64)
+	hashC := strings.Repeat("c", 64)
+
+	// no hashes at all yet, so no duplicates either
+	duplicates, err := suite.db.GetDuplicateMediaByHash(context.Background(), "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(duplicates)
+
+	for key, hash := range map[string]string{
+		"admin_account_status_1_attachment_1":   hashA,
+		"local_account_1_status_4_attachment_1": hashA,
+		"local_account_1_unattached_1":          hashB,
+		"local_account_1_avatar":                hashB,
+		"local_account_1_header":                hashC,
+	} {
+		err := suite.db.SetAttachmentFileHash(context.Background(), suite.testAttachments[key].ID, hash)
+		suite.NoError(err)
+	}
+
+	duplicates, err = suite.db.GetDuplicateMediaByHash(context.Background(), "", 1)
+	suite.NoError(err)
+	suite.Len(duplicates, 1)
+	suite.Equal(hashB, duplicates[0].FileHash)
+	suite.Len(duplicates[0].Attachments, 2)
+	suite.Equal(suite.testAttachments["local_account_1_avatar"].ID, duplicates[0].Attachments[0].ID)
+	suite.Equal(suite.testAttachments["local_account_1_unattached_1"].ID, duplicates[0].Attachments[1].ID)
+
+	duplicates, err = suite.db.GetDuplicateMediaByHash(context.Background(), duplicates[0].FileHash, 1)
+	suite.NoError(err)
+	suite.Len(duplicates, 1)
+	suite.Equal(hashA, duplicates[0].FileHash)
+	suite.Len(duplicates[0].Attachments, 2)
+	suite.Equal(suite.testAttachments["admin_account_status_1_attachment_1"].ID, duplicates[0].Attachments[0].ID)
+	suite.Equal(suite.testAttachments["local_account_1_status_4_attachment_1"].ID, duplicates[0].Attachments[1].ID)
+
+	duplicates, err = suite.db.GetDuplicateMediaByHash(context.Background(), duplicates[0].FileHash, 1)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(duplicates)
+}
+
+func (suite *MediaTestSuite) TestGetMediaByIDs() {
+	ids := []string{
+		suite.testAttachments["local_account_1_header"].ID,
+		"01FVW2X1A8BXZ4N2GH3CB2RG6E", // doesn't exist
+		suite.testAttachments["admin_account_status_1_attachment_1"].ID,
+		suite.testAttachments["local_account_1_avatar"].ID,
+	}
+
+	attachments, err := suite.db.GetMediaByIDs(context.Background(), ids)
+	suite.NoError(err)
+	suite.Len(attachments, 3)
+	suite.Equal(ids[0], attachments[0].ID)
+	suite.Equal(ids[2], attachments[1].ID)
+	suite.Equal(ids[3], attachments[2].ID)
+
+	attachments, err = suite.db.GetMediaByIDs(context.Background(), nil)
+	suite.NoError(err)
+	suite.Empty(attachments)
+}
+
+func (suite *MediaTestSuite) TestUpdateAttachmentDescriptionAndFocus() {
+	testAttachment := suite.testAttachments["local_account_1_unattached_1"]
+
+	err := suite.db.UpdateAttachmentDescription(context.Background(), testAttachment.ID, "a cool picture of a cat")
+	suite.NoError(err)
+
+	err = suite.db.UpdateAttachmentFocus(context.Background(), testAttachment.ID, -0.5, 0.25)
+	suite.NoError(err)
+
+	err = suite.db.UpdateAttachmentFocus(context.Background(), testAttachment.ID, 1.5, 0)
+	suite.Error(err)
+
+	attachment, err := suite.db.GetAttachmentByID(context.Background(), testAttachment.ID)
+	suite.NoError(err)
+	suite.Equal("a cool picture of a cat", attachment.Description)
+	suite.EqualValues(-0.5, attachment.FileMeta.Focus.X)
+	suite.EqualValues(0.25, attachment.FileMeta.Focus.Y)
+
+	// the rest of the file meta should be left alone
+	suite.Equal(testAttachment.FileMeta.Original, attachment.FileMeta.Original)
+	suite.Equal(testAttachment.FileMeta.Small, attachment.FileMeta.Small)
+}
+
+func (suite *MediaTestSuite) TestGetRemoteMediaOlderThan() {
+	ctx := context.Background()
+	remoteAccount := suite.testAccounts["remote_account_1"]
+	now := time.Now()
+	cutoff := now.Add(-24 * time.Hour)
+
+	putRemote := func(createdAt time.Time) string {
+		attachmentID, err := id.NewULIDFromTime(createdAt)
+		suite.NoError(err)
+		suite.NoError(suite.db.Put(ctx, &gtsmodel.MediaAttachment{
+			ID:        attachmentID,
+			CreatedAt: createdAt,
+			RemoteURL: "http://fossbros-anonymous.io/attachments/" + attachmentID + ".jpg",
+			AccountID: remoteAccount.ID,
+			Type:      gtsmodel.FileTypeImage,
+			File:      gtsmodel.File{Path: "whatever", ContentType: "image/jpeg", FileSize: 1},
+			Thumbnail: gtsmodel.Thumbnail{Path: "whatever", ContentType: "image/jpeg", FileSize: 1},
+		}))
+		return attachmentID
+	}
+
+	neverServed := putRemote(now.Add(-72 * time.Hour))
+	servedLongAgo := putRemote(now.Add(-60 * time.Hour))
+	servedRecently := putRemote(now.Add(-48 * time.Hour))
+	pruned := putRemote(now.Add(-36 * time.Hour))
+	putRemote(now.Add(-time.Hour))
+
+	suite.NoError(suite.db.SetAttachmentAccessedAt(ctx, servedLongAgo, now.Add(-30*time.Hour)))
+	suite.NoError(suite.db.SetAttachmentAccessedAt(ctx, servedRecently, now.Add(-time.Minute)))
+	suite.NoError(suite.db.SetAttachmentPruned(ctx, pruned))
+
+	// local media (all of the test attachments) is never pruned
+	attachments, err := suite.db.GetRemoteMediaOlderThan(ctx, cutoff, "", 10)
+	suite.NoError(err)
+	suite.Len(attachments, 2)
+	suite.Equal(servedLongAgo, attachments[0].ID)
+	suite.Equal(neverServed, attachments[1].ID)
+
+	attachments, err = suite.db.GetRemoteMediaOlderThan(ctx, cutoff, servedLongAgo, 10)
+	suite.NoError(err)
+	suite.Len(attachments, 1)
+	suite.Equal(neverServed, attachments[0].ID)
+
+	_, err = suite.db.GetRemoteMediaOlderThan(ctx, cutoff, neverServed, 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *MediaTestSuite) TestGetRemoteEmojiNeedingFetch() {
+	ctx := context.Background()
+
+	putEmoji := func(shortcode string, domain string, cached bool, disabled bool) string {
+		emojiID, err := id.NewULID()
+		suite.NoError(err)
+
+		emoji := &gtsmodel.Emoji{
+			ID:                     emojiID,
+			Shortcode:              shortcode,
+			Domain:                 domain,
+			ImageRemoteURL:         "http://" + domain + "/emoji/" + shortcode + ".png",
+			ImageStaticRemoteURL:   "http://" + domain + "/emoji/" + shortcode + "_static.png",
+			ImagePath:              "/gotosocial/emoji/original/" + emojiID + ".png",
+			ImageStaticPath:        "/gotosocial/emoji/static/" + emojiID + ".png",
+			ImageContentType:       "image/png",
+			ImageStaticContentType: "image/png",
+			ImageFileSize:          1,
+			ImageStaticFileSize:    1,
+			Disabled:               disabled,
+			URI:                    "http://" + domain + "/emoji/" + emojiID,
+		}
+		if cached {
+			emoji.ImageURL = "http://localhost:8080/fileserver/emoji/original/" + emojiID + ".png"
+			emoji.ImageStaticURL = "http://localhost:8080/fileserver/emoji/static/" + emojiID + ".png"
+		}
+
+		suite.NoError(suite.db.Put(ctx, emoji))
+		return emojiID
+	}
+
+	putEmoji("cached", "fossbros-anonymous.io", true, false)
+	uncached1 := putEmoji("uncached_1", "fossbros-anonymous.io", false, false)
+	uncached2 := putEmoji("uncached_2", "fossbros-anonymous.io", false, false)
+	putEmoji("disabled", "fossbros-anonymous.io", false, true)
+	putEmoji("elsewhere", "example.org", false, false)
+
+	emojis, err := suite.db.GetRemoteEmojiNeedingFetch(ctx, "fossbros-anonymous.io", 10)
+	suite.NoError(err)
+	suite.Len(emojis, 2)
+	suite.ElementsMatch([]string{uncached1, uncached2}, []string{emojis[0].ID, emojis[1].ID})
+
+	emojis, err = suite.db.GetRemoteEmojiNeedingFetch(ctx, "fossbros-anonymous.io", 1)
+	suite.NoError(err)
+	suite.Len(emojis, 1)
+
+	_, err = suite.db.GetRemoteEmojiNeedingFetch(ctx, "unknown.example.org", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// local emoji are always cached
+	_, err = suite.db.GetRemoteEmojiNeedingFetch(ctx, "", 10)
+	suite.Error(err)
+}
+
+func TestMediaTestSuite(t *testing.T) {
+	suite.Run(t, new(MediaTestSuite))
+}
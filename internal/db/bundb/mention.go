@@ -20,6 +20,7 @@ package bundb
 
 import (
 	"context"
+	"time"
 
 	"github.com/ReneKroon/ttlcache"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
@@ -30,8 +31,15 @@ import (
 type mentionDB struct {
 	conn  *DBConn
 	cache *ttlcache.Cache
+
+	// statusMentions caches results of GetStatusMentions, keyed by status ID
+	statusMentions *ttlcache.Cache
 }
 
+// statusMentionsCacheTTL is how long results of GetStatusMentions are cached for. Edits and deletes of a
+// status drop its entry straight away, so this only bounds how long mentions of quiet statuses hang around.
+const statusMentionsCacheTTL = 5 * time.Minute
+
 func (m *mentionDB) newMentionQ(i interface{}) *bun.SelectQuery {
 	return m.conn.
 		NewSelect().
@@ -96,3 +104,56 @@ func (m *mentionDB) GetMentions(ctx context.Context, ids []string) ([]*gtsmodel.
 
 	return mentions, nil
 }
+
+func (m *mentionDB) GetStatusMentions(ctx context.Context, statusID string) ([]*gtsmodel.Mention, db.Error) {
+	if v, ok := m.statusMentions.Get(statusID); ok {
+		return copyMentions(v.([]*gtsmodel.Mention)), nil
+	}
+
+	mentions := []*gtsmodel.Mention{}
+
+	if err := m.newMentionQ(&mentions).
+		Where("mention.status_id = ?", statusID).
+		Order("mention.id ASC").
+		Scan(ctx); err != nil {
+		return nil, m.conn.ProcessError(err)
+	}
+
+	m.statusMentions.Set(statusID, copyMentions(mentions))
+
+	for _, mention := range mentions {
+		m.putMentionCache(mention)
+	}
+
+	return mentions, nil
+}
+
+// copyMentions copies the given mentions, along with the status and accounts attached to each,
+// so that what's cached and what's handed to callers can be changed without affecting each other.
+// Anything attached further down, such as an account's avatar, is still shared.
+func copyMentions(mentions []*gtsmodel.Mention) []*gtsmodel.Mention {
+	copied := make([]*gtsmodel.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		c := *mention
+		if mention.Status != nil {
+			status := *mention.Status
+			c.Status = &status
+		}
+		if mention.OriginAccount != nil {
+			originAccount := *mention.OriginAccount
+			c.OriginAccount = &originAccount
+		}
+		if mention.TargetAccount != nil {
+			targetAccount := *mention.TargetAccount
+			c.TargetAccount = &targetAccount
+		}
+		copied = append(copied, &c)
+	}
+	return copied
+}
+
+// invalidateStatusMentions drops the cached mentions of the given status,
+// for when it's been edited or deleted.
+func (m *mentionDB) invalidateStatusMentions(statusID string) {
+	m.statusMentions.Remove(statusID)
+}
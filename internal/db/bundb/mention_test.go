@@ -20,9 +20,11 @@ package bundb_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
 
 type MentionTestSuite struct {
@@ -60,6 +62,119 @@ func (suite *MentionTestSuite) TestGetMentions() {
 	suite.NotNil(dbMention.Status)
 }
 
+func (suite *MentionTestSuite) TestGetStatusMentions() {
+	ctx := context.Background()
+	m := suite.testMentions["local_user_2_mention_zork"]
+	admin := suite.testAccounts["admin_account"]
+
+	mentions, err := suite.db.GetStatusMentions(ctx, m.StatusID)
+	suite.NoError(err)
+	suite.Len(mentions, 1)
+	suite.Equal(m.ID, mentions[0].ID)
+	suite.NotNil(mentions[0].TargetAccount)
+
+	// changing what was handed out doesn't change what's cached
+	mentions[0].OriginAccountURI = "http://example.org/users/someone_else"
+	mentions[0].TargetAccount.Username = "someone_else"
+	mentions, err = suite.db.GetStatusMentions(ctx, m.StatusID)
+	suite.NoError(err)
+	suite.Equal(m.OriginAccountURI, mentions[0].OriginAccountURI)
+	suite.Equal(suite.testAccounts["local_account_1"].Username, mentions[0].TargetAccount.Username)
+
+	// a mention added behind the cache's back isn't seen yet
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Mention{
+		ID:               "01FWD5H2K8N4R7T1V3X6Z9B0CE",
+		StatusID:         m.StatusID,
+		OriginAccountID:  m.OriginAccountID,
+		OriginAccountURI: m.OriginAccountURI,
+		TargetAccountID:  admin.ID,
+		NameString:       "@admin",
+		TargetAccountURI: admin.URI,
+		TargetAccountURL: admin.URL,
+	}))
+
+	mentions, err = suite.db.GetStatusMentions(ctx, m.StatusID)
+	suite.NoError(err)
+	suite.Len(mentions, 1)
+
+	// until the status is edited
+	suite.NoError(suite.db.CreateStatusEdit(ctx, &gtsmodel.StatusEdit{
+		ID:       "01FWD5JB6M0Q3S5U8W1Y4A7C9D",
+		StatusID: m.StatusID,
+		Content:  "hi zork",
+		Text:     "hi zork",
+	}))
+
+	mentions, err = suite.db.GetStatusMentions(ctx, m.StatusID)
+	suite.NoError(err)
+	suite.Len(mentions, 2)
+
+	// statuses without mentions get an empty slice
+	mentions, err = suite.db.GetStatusMentions(ctx, "01FWD5KQ3E7G9J2L4N6P8R0T1V")
+	suite.NoError(err)
+	suite.Empty(mentions)
+}
+
+func (suite *MentionTestSuite) TestMentionStringsToMentions() {
+	originAccount := suite.testAccounts["local_account_2"]
+
+	mentions, err := suite.db.MentionStringsToMentions(context.Background(), []string{"@the_mighty_zork", "@nobody@example.org"}, originAccount.ID, "")
+	suite.NoError(err)
+	suite.Len(mentions, 1)
+	suite.Equal(suite.testAccounts["local_account_1"].ID, mentions[0].TargetAccountID)
+
+	// right on the limits is fine, there's just nobody there
+	mentions, err = suite.db.MentionStringsToMentions(context.Background(), []string{"@" + strings.Repeat("a", 256) + "@" + strings.Repeat("b", 249) + ".org"}, originAccount.ID, "")
+	suite.NoError(err)
+	suite.Empty(mentions)
+}
+
+func (suite *MentionTestSuite) TestMentionStringsToMentionsDuplicates() {
+	originAccount := suite.testAccounts["admin_account"]
+
+	mentions, err := suite.db.MentionStringsToMentions(context.Background(), []string{
+		"@the_mighty_zork",
+		"@1happyturtle",
+		"@The_Mighty_Zork",
+		"@foss_satan@fossbros-anonymous.io",
+		"@the_mighty_zork",
+		"@FOSS_SATAN@FossBros-Anonymous.io",
+	}, originAccount.ID, "")
+	suite.NoError(err)
+	if suite.Len(mentions, 3) {
+		suite.Equal(suite.testAccounts["local_account_1"].ID, mentions[0].TargetAccountID)
+		suite.Equal("@the_mighty_zork", mentions[0].NameString)
+		suite.Equal(suite.testAccounts["local_account_2"].ID, mentions[1].TargetAccountID)
+		suite.Equal(suite.testAccounts["remote_account_1"].ID, mentions[2].TargetAccountID)
+		suite.Equal("@foss_satan@fossbros-anonymous.io", mentions[2].NameString)
+	}
+}
+
+func (suite *MentionTestSuite) TestMentionStringsToMentionsPathological() {
+	originAccount := suite.testAccounts["local_account_2"]
+
+	for _, mention := range []string{
+		"@",
+		"@@",
+		"@someone@",
+		"@someone@example.org@example.org",
+		"@" + strings.Repeat("a", 257),
+		"@" + strings.Repeat("a", 1<<20) + "@example.org",
+		"@someone@" + strings.Repeat("b", 250) + ".org",
+		"@someone@" + strings.Repeat("b.", 1<<19) + "org",
+		"@someone@example.org@" + strings.Repeat("c", 1<<20),
+	} {
+		mentions, err := suite.db.MentionStringsToMentions(context.Background(), []string{mention}, originAccount.ID, "")
+		suite.Error(err)
+		suite.Nil(mentions)
+
+		// the error shouldn't repeat whatever enormous thing it was given
+		if err != nil {
+			suite.Less(len(err.Error()), 512)
+		}
+	}
+}
+
 func TestMentionTestSuite(t *testing.T) {
 	suite.Run(t, new(MentionTestSuite))
 }
@@ -0,0 +1,311 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/db/bundb/migrations"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate"
+	"modernc.org/sqlite"
+)
+
+// MigrationError is returned when a database migration fails.
+type MigrationError struct {
+	// Name of the migration that failed.
+	Name string
+	// Err is what the migration returned, usually an error from the database.
+	Err error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %s failed: %s", e.Name, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// newMigrationError works out which migration just failed with err, logs what's
+// known about the failure, and returns a MigrationError describing it.
+func newMigrationError(ctx context.Context, migrator *migrate.Migrator, err error) error {
+	// migrations are marked as applied one by one as they succeed,
+	// so the failed one is the first that's still left unapplied
+	ms, statusErr := migrator.MigrationsWithStatus(ctx)
+	if statusErr != nil {
+		return fmt.Errorf("error finding failed migration: %s (migration error: %s)", statusErr, err)
+	}
+
+	unapplied := ms.Unapplied()
+	if len(unapplied) == 0 {
+		return err
+	}
+
+	migErr := &MigrationError{Name: unapplied[0].Name, Err: err}
+	logMigrationError(migErr)
+	return migErr
+}
+
+// logMigrationError logs everything we know about a failed migration, and how to retry it.
+func logMigrationError(migErr *MigrationError) {
+	l := logrus.WithField("migration", migErr.Name)
+
+	var pgErr *pgconn.PgError
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.As(migErr.Err, &pgErr):
+		l = l.WithFields(logrus.Fields{
+			"code":   pgErr.Code,
+			"detail": pgErr.Detail,
+			"hint":   pgErr.Hint,
+			"where":  pgErr.Where,
+			"table":  pgErr.TableName,
+			"column": pgErr.ColumnName,
+		})
+	case errors.As(migErr.Err, &sqliteErr):
+		l = l.WithField("code", sqliteErr.Code())
+	}
+
+	l.Errorf("database migration failed: %s", migErr.Err)
+	l.Errorf("nothing from this migration was kept; once the cause is fixed, "+
+		"either start GoToSocial again or run just this migration with: "+
+		"gotosocial admin migration retry --name %s", migErr.Name)
+}
+
+// RetryMigration runs the pending migration with the given name on its own, for trying
+// again after a migration failed on startup. All migrations before it must have been
+// applied already, and it must not have been applied itself.
+func RetryMigration(ctx context.Context, name string) error {
+	conn, err := openConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return retryMigration(ctx, conn.DB, migrations.Migrations, name)
+}
+
+func retryMigration(ctx context.Context, db *bun.DB, ms *migrate.Migrations, name string) error {
+	ctx, cancel := migrationContext(ctx)
+	defer cancel()
+
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("error locking database for migration: %s", err)
+	}
+	defer unlock()
+
+	migrator := migrate.NewMigrator(db, ms)
+
+	if err := migrator.Init(ctx); err != nil {
+		return err
+	}
+
+	if err := migrator.Lock(ctx); err != nil {
+		return err
+	}
+	defer migrator.Unlock(ctx) //nolint
+
+	withStatus, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	for i < len(withStatus) && withStatus[i].Name != name {
+		i++
+	}
+
+	if i == len(withStatus) {
+		return fmt.Errorf("migration %s not found", name)
+	}
+
+	migration := &withStatus[i]
+	if migration.IsApplied() {
+		return fmt.Errorf("migration %s has already been applied", name)
+	}
+
+	for _, earlier := range withStatus[:i] {
+		if !earlier.IsApplied() {
+			return fmt.Errorf("migration %s comes before %s and hasn't been applied yet", earlier.Name, name)
+		}
+	}
+
+	if migration.Up != nil {
+		if err := migration.Up(ctx, db); err != nil {
+			migErr := &MigrationError{Name: name, Err: err}
+			logMigrationError(migErr)
+			return migErr
+		}
+	}
+
+	migration.GroupID = withStatus.LastGroupID() + 1
+	if err := migrator.MarkApplied(ctx, migration); err != nil {
+		return err
+	}
+
+	logrus.Infof("applied migration %s", name)
+	return nil
+}
+
+// verifyMigrations checks that every migration in ms has already been applied to db,
+// without applying anything itself. This is for deployments where the database schema
+// is managed outside of GoToSocial, so the migrations table must not be created either.
+func verifyMigrations(ctx context.Context, db *bun.DB, ms *migrate.Migrations) error {
+	migrator := migrate.NewMigrator(db, ms)
+
+	withStatus, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking which migrations have been applied (has the database been migrated at all?): %s", err)
+	}
+
+	if unapplied := withStatus.Unapplied(); len(unapplied) != 0 {
+		return fmt.Errorf("database schema is out of date: %d migration(s) haven't been applied yet, starting with %s", len(unapplied), unapplied[0].Name)
+	}
+
+	return nil
+}
+
+// migrationChecksum is the checksum of a migration as it was when it was applied.
+type migrationChecksum struct {
+	bun.BaseModel `bun:"table:gts_migration_checksums"`
+
+	Name     string `bun:",pk,nullzero,notnull"`
+	Checksum string `bun:",nullzero,notnull"`
+}
+
+// checkMigrationChecksums returns an error naming the first migration that has already
+// been applied to db, but whose checksum no longer matches the one in checksums.
+func checkMigrationChecksums(ctx context.Context, db *bun.DB, checksums map[string]string) error {
+	if _, err := db.
+		NewCreateTable().
+		Model((*migrationChecksum)(nil)).
+		IfNotExists().
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	applied := []*migrationChecksum{}
+	if err := db.
+		NewSelect().
+		Model(&applied).
+		Order("name ASC").
+		Scan(ctx); err != nil {
+		return err
+	}
+
+	for _, a := range applied {
+		// migrations we don't know about are bun's business, not ours
+		if checksum, ok := checksums[a.Name]; ok && checksum != a.Checksum {
+			return fmt.Errorf("migration %s has been changed since it was applied to this database; "+
+				"applied migrations must never be edited, put the change in a new migration instead", a.Name)
+		}
+	}
+
+	return nil
+}
+
+// recordMigrationChecksums stores the checksum of each migration that has been applied to db,
+// unless one is stored already. For migrations applied before checksums were introduced, this
+// trusts whatever they look like the first time around.
+func recordMigrationChecksums(ctx context.Context, db *bun.DB, migrator *migrate.Migrator, checksums map[string]string) error {
+	withStatus, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range withStatus.Applied() {
+		checksum, ok := checksums[migration.Name]
+		if !ok {
+			continue
+		}
+
+		if _, err := insertIfNew(ctx, db, &migrationChecksum{Name: migration.Name, Checksum: checksum}, "name"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkMigrationDialects makes sure that every migration that's about to be applied to db supports
+// db's dialect, going by dialects (keyed by migration name; migrations not in there support them all).
+// If one doesn't, that's an error naming it, rather than whatever the database makes of SQL it doesn't
+// understand halfway through the run. With DbMigrationSkipIncompatible set, such migrations are marked
+// as applied without running instead, so that the ones after them can go ahead.
+func checkMigrationDialects(ctx context.Context, db *bun.DB, migrator *migrate.Migrator, dialects map[string][]dialect.Name) error {
+	if len(dialects) == 0 {
+		return nil
+	}
+
+	withStatus, err := migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := db.Dialect().Name()
+	groupID := withStatus.LastGroupID() + 1
+
+	for _, migration := range withStatus.Unapplied() {
+		supported, ok := dialects[migration.Name]
+		if !ok || supportsDialect(supported, current) {
+			continue
+		}
+
+		if !viper.GetBool(config.Keys.DbMigrationSkipIncompatible) {
+			return fmt.Errorf("migration %s only supports %s databases, not %s; "+
+				"set %s to true to skip it", migration.Name, dialectNames(supported), current, config.Keys.DbMigrationSkipIncompatible)
+		}
+
+		migration := migration
+		migration.GroupID = groupID
+		if err := migrator.MarkApplied(ctx, &migration); err != nil {
+			return err
+		}
+		logrus.Warnf("skipped migration %s, it only supports %s databases", migration.Name, dialectNames(supported))
+	}
+
+	return nil
+}
+
+func supportsDialect(supported []dialect.Name, d dialect.Name) bool {
+	for _, s := range supported {
+		if s == d {
+			return true
+		}
+	}
+	return false
+}
+
+func dialectNames(ds []dialect.Name) string {
+	names := make([]string, 0, len(ds))
+	for _, d := range ds {
+		names = append(names, d.String())
+	}
+	return strings.Join(names, "/")
+}
@@ -0,0 +1,259 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+	"github.com/superseriousbusiness/gotosocial/internal/db/bundb/migrations"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/migrate"
+)
+
+type MigrateTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+}
+
+func (suite *MigrateTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbMigrationTimeout,
+		config.Keys.DbMigrationVerifyChecksums,
+		config.Keys.DbMigrationSkipIncompatible,
+	)
+}
+
+func (suite *MigrateTestSuite) TearDownTest() {
+	suite.restoreConfig()
+}
+
+func (suite *MigrateTestSuite) TestMigrationContextTimeout() {
+	viper.Set(config.Keys.DbMigrationTimeout, 2*time.Hour)
+
+	ctx, cancel := migrationContext(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	suite.True(ok)
+	suite.WithinDuration(time.Now().Add(2*time.Hour), deadline, time.Minute)
+}
+
+func (suite *MigrateTestSuite) TestMigrationContextNoTimeout() {
+	viper.Set(config.Keys.DbMigrationTimeout, 0)
+
+	ctx, cancel := migrationContext(context.Background())
+	defer cancel()
+
+	_, ok := ctx.Deadline()
+	suite.False(ok)
+}
+
+// newTestMigrations returns migrations that create one table each; the
+// second one fails until fixed is set to true.
+func (suite *MigrateTestSuite) newTestMigrations(fixed *bool) *migrate.Migrations {
+	createTable := func(name string) migrate.MigrationFunc {
+		return func(ctx context.Context, db *bun.DB) error {
+			_, err := db.ExecContext(ctx, "CREATE TABLE ? (id INTEGER)", bun.Ident(name))
+			return err
+		}
+	}
+
+	ms := migrate.NewMigrations()
+	ms.Add(migrate.Migration{Name: "20220101000000_one", Up: createTable("one")})
+	ms.Add(migrate.Migration{Name: "20220102000000_two", Up: func(ctx context.Context, db *bun.DB) error {
+		if !*fixed {
+			return createTable("one")(ctx, db)
+		}
+		return createTable("two")(ctx, db)
+	}})
+	ms.Add(migrate.Migration{Name: "20220103000000_three", Up: createTable("three")})
+	return ms
+}
+
+func (suite *MigrateTestSuite) newTestDB() *bun.DB {
+	sqldb, err := sql.Open("sqlite", "file:"+filepath.Join(suite.T().TempDir(), "sqlite.db"))
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	return bun.NewDB(sqldb, sqlitedialect.New())
+}
+
+func (suite *MigrateTestSuite) TestFailedMigrationNamed() {
+	ctx := context.Background()
+	db := suite.newTestDB()
+	defer db.Close()
+
+	fixed := false
+	err := runMigrations(ctx, db, suite.newTestMigrations(&fixed), nil, nil)
+
+	var migErr *MigrationError
+	suite.True(errors.As(err, &migErr))
+	suite.Equal("20220102000000_two", migErr.Name)
+	suite.Contains(migErr.Error(), "migration 20220102000000_two failed: ")
+	suite.Contains(migErr.Err.Error(), "already exists")
+}
+
+func (suite *MigrateTestSuite) TestRetryMigration() {
+	ctx := context.Background()
+	db := suite.newTestDB()
+	defer db.Close()
+
+	fixed := false
+	ms := suite.newTestMigrations(&fixed)
+	suite.Error(runMigrations(ctx, db, ms, nil, nil))
+
+	// still broken
+	err := retryMigration(ctx, db, ms, "20220102000000_two")
+	var migErr *MigrationError
+	suite.True(errors.As(err, &migErr))
+
+	// can't skip ahead of the failed migration
+	err = retryMigration(ctx, db, ms, "20220103000000_three")
+	suite.EqualError(err, "migration 20220102000000_two comes before 20220103000000_three and hasn't been applied yet")
+
+	fixed = true
+	suite.NoError(retryMigration(ctx, db, ms, "20220102000000_two"))
+
+	err = retryMigration(ctx, db, ms, "20220102000000_two")
+	suite.EqualError(err, "migration 20220102000000_two has already been applied")
+
+	err = retryMigration(ctx, db, ms, "20220104000000_four")
+	suite.EqualError(err, "migration 20220104000000_four not found")
+
+	// the rest go through on a normal run
+	suite.NoError(runMigrations(ctx, db, ms, nil, nil))
+
+	var tables int
+	suite.NoError(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('one', 'two', 'three')").Scan(&tables))
+	suite.Equal(3, tables)
+}
+
+func (suite *MigrateTestSuite) TestVerifyMigrations() {
+	ctx := context.Background()
+	db := suite.newTestDB()
+	defer db.Close()
+
+	fixed := true
+	ms := suite.newTestMigrations(&fixed)
+
+	// nothing applied yet, not even the migrations table
+	suite.Error(verifyMigrations(ctx, db, ms))
+
+	var tables int
+	suite.NoError(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables))
+	suite.Zero(tables)
+
+	suite.NoError(runMigrations(ctx, db, ms, nil, nil))
+	suite.NoError(verifyMigrations(ctx, db, ms))
+
+	// a newer migration that hasn't been applied yet
+	ms.Add(migrate.Migration{Name: "20220104000000_four", Up: func(ctx context.Context, db *bun.DB) error { return nil }})
+	err := verifyMigrations(ctx, db, ms)
+	suite.EqualError(err, "database schema is out of date: 1 migration(s) haven't been applied yet, starting with 20220104000000_four")
+}
+
+func (suite *MigrateTestSuite) TestMigrationChecksumMismatch() {
+	viper.Set(config.Keys.DbMigrationVerifyChecksums, true)
+
+	ctx := context.Background()
+	db := suite.newTestDB()
+	defer db.Close()
+
+	fixed := true
+	ms := suite.newTestMigrations(&fixed)
+	checksums := map[string]string{
+		"20220101000000_one":   "aaaa",
+		"20220102000000_two":   "bbbb",
+		"20220103000000_three": "cccc",
+	}
+	suite.NoError(runMigrations(ctx, db, ms, checksums, nil))
+
+	// nothing has changed, so starting again is fine
+	suite.NoError(runMigrations(ctx, db, ms, checksums, nil))
+
+	// someone edits an applied migration
+	checksums["20220102000000_two"] = "dddd"
+	err := runMigrations(ctx, db, ms, checksums, nil)
+	suite.EqualError(err, "migration 20220102000000_two has been changed since it was applied to this database; "+
+		"applied migrations must never be edited, put the change in a new migration instead")
+
+	// with verification off, it's only a warning, and the original checksum is kept
+	viper.Set(config.Keys.DbMigrationVerifyChecksums, false)
+	suite.NoError(runMigrations(ctx, db, ms, checksums, nil))
+
+	viper.Set(config.Keys.DbMigrationVerifyChecksums, true)
+	suite.Error(runMigrations(ctx, db, ms, checksums, nil))
+}
+
+func (suite *MigrateTestSuite) TestMigrationIncompatibleDialect() {
+	viper.Set(config.Keys.DbMigrationSkipIncompatible, false)
+
+	ctx := context.Background()
+	db := suite.newTestDB()
+	defer db.Close()
+
+	fixed := true
+	ms := suite.newTestMigrations(&fixed)
+	dialects := map[string][]dialect.Name{
+		"20220101000000_one": {dialect.SQLite, dialect.PG},
+		"20220102000000_two": {dialect.PG},
+	}
+
+	// refuses before anything runs, naming the migration
+	err := runMigrations(ctx, db, ms, nil, dialects)
+	suite.EqualError(err, "migration 20220102000000_two only supports pg databases, not sqlite; "+
+		"set db-migration-skip-incompatible to true to skip it")
+
+	var tables int
+	suite.NoError(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('one', 'two', 'three')").Scan(&tables))
+	suite.Zero(tables)
+
+	// skipped migrations count as applied, and the rest go through
+	viper.Set(config.Keys.DbMigrationSkipIncompatible, true)
+	suite.NoError(runMigrations(ctx, db, ms, nil, dialects))
+	suite.NoError(verifyMigrations(ctx, db, ms))
+
+	suite.NoError(db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('one', 'two', 'three')").Scan(&tables))
+	suite.Equal(2, tables)
+}
+
+func (suite *MigrateTestSuite) TestRealMigrationChecksums() {
+	checksums, err := migrations.Checksums()
+	suite.NoError(err)
+
+	// every registered migration has a checksum, and nothing else does
+	suite.Len(checksums, len(migrations.Migrations.Sorted()))
+	for _, migration := range migrations.Migrations.Sorted() {
+		suite.Len(checksums[migration.Name], 64, migration.Name)
+	}
+}
+
+func TestMigrateTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateTestSuite))
+}
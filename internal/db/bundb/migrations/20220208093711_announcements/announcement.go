@@ -0,0 +1,41 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gtsmodel
+
+import "time"
+
+// Announcement represents an instance-wide announcement made by an admin, to be shown to all users of this instance.
+type Announcement struct {
+	ID                 string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`        // id of this item in the database
+	CreatedAt          time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item created
+	UpdatedAt          time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item last updated
+	Text               string    `validate:"required" bun:",nullzero,notnull"`                                    // text of the announcement
+	StartsAt           time.Time `validate:"-" bun:"type:timestamptz,nullzero"`                                   // don't show the announcement before this time; if not set, show it straight away
+	EndsAt             time.Time `validate:"-" bun:"type:timestamptz,nullzero"`                                   // don't show the announcement after this time; if not set, show it until it's unpublished
+	Published          bool      `validate:"-" bun:",notnull,default:false"`                                      // announcement is only shown when this is true, so admins can draft announcements
+	CreatedByAccountID string    `validate:"required,ulid" bun:"type:CHAR(26),nullzero,notnull"`                  // id of the admin account that created this announcement
+}
+
+// AnnouncementRead records that an account has read (ie., dismissed) an announcement.
+type AnnouncementRead struct {
+	ID             string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`                      // id of this item in the database
+	CreatedAt      time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`               // when was item created (ie., when was the announcement read)
+	AnnouncementID string    `validate:"required,ulid" bun:"type:CHAR(26),unique:announcementreadaccount,nullzero,notnull"` // id of the announcement that was read
+	AccountID      string    `validate:"required,ulid" bun:"type:CHAR(26),unique:announcementreadaccount,nullzero,notnull"` // id of the account that read the announcement
+}
@@ -0,0 +1,33 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gtsmodel
+
+import "time"
+
+// AdminAction records a moderation action taken by an admin or moderator, for the audit log.
+type AdminAction struct {
+	ID              string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`        // id of this item in the database
+	CreatedAt       time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item created (ie., when was the action taken)
+	UpdatedAt       time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item last updated
+	AccountID       string    `validate:"required,ulid" bun:"type:CHAR(26),nullzero,notnull"`                  // id of the account that took the action
+	Type            string    `validate:"required" bun:",nullzero,notnull"`                                    // what kind of action was taken
+	TargetAccountID string    `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                         // id of the account the action was taken against, if any
+	TargetDomain    string    `validate:"omitempty,fqdn" bun:",nullzero"`                                      // domain the action was taken against, if any
+	Text            string    `validate:"-" bun:""`                                                            // free-form note about the action, eg., a private comment
+}
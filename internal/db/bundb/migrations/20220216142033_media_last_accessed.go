@@ -0,0 +1,62 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	up := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			// remote media that hasn't been served for a while
+			// gets its files pruned from storage to save space
+			for _, column := range []string{"last_accessed_at", "pruned_at"} {
+				if _, err := tx.
+					NewAddColumn().
+					Table("media_attachments").
+					ColumnExpr("? TIMESTAMPTZ", bun.Ident(column)).
+					Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.
+				NewCreateIndex().
+				Table("media_attachments").
+				Index("media_attachments_last_accessed_idx").
+				IfNotExists().
+				ColumnExpr("COALESCE(?, ?)", bun.Ident("last_accessed_at"), bun.Ident("created_at")).
+				Exec(ctx)
+			return err
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	up := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			if _, err := tx.
+				NewAddColumn().
+				Table("statuses").
+				ColumnExpr("? TIMESTAMPTZ", bun.Ident("expires_at")).
+				Exec(ctx); err != nil {
+				return err
+			}
+
+			// hardly any statuses expire, so only index the ones that do;
+			// bun's create index query can't do partial indexes yet
+			_, err := tx.ExecContext(ctx,
+				"CREATE INDEX IF NOT EXISTS ? ON ? (?) WHERE ? IS NOT NULL",
+				bun.Ident("statuses_expires_at_idx"),
+				bun.Ident("statuses"),
+				bun.Ident("expires_at"),
+				bun.Ident("expires_at"),
+			)
+			return err
+		})
+	}
+
+	down := func(ctx context.Context, db *bun.DB) error {
+		return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return nil
+		})
+	}
+
+	if err := Migrations.Register(up, down); err != nil {
+		panic(err)
+	}
+}
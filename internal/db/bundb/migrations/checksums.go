@@ -0,0 +1,127 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// sources holds the source of every migration, along with the
+// frozen copies of models that some migrations keep in a
+// directory of the same name.
+//
+//go:embed *.go */*.go
+var sources embed.FS
+
+// migrationFileRegex matches the file name of a migration, see README.md.
+var migrationFileRegex = regexp.MustCompile(`^[0-9]{14}_[a-z0-9_]+\.go$`)
+
+// Checksums returns a checksum of the source of each migration, keyed by migration name.
+// If a migration is changed after it's been applied somewhere, its checksum changes too,
+// which lets that be caught on startup instead of leaving schemas quietly out of step.
+// Only the code counts: comments, blank lines and formatting can change freely.
+func Checksums() (map[string]string, error) {
+	entries, err := sources.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !migrationFileRegex.MatchString(entry.Name()) {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), ".go")
+
+		// bun only uses the timestamp as the migration's name
+		name := base[:14]
+
+		h := sha256.New()
+		b, err := normalizedSource(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		h.Write(b)
+
+		// models frozen for this migration are part of it too;
+		// ReadDir returns them sorted, so the checksum is stable
+		files, err := sources.ReadDir(base)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, file := range files {
+			b, err := normalizedSource(path.Join(base, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+			h.Write([]byte(file.Name()))
+			h.Write(b)
+		}
+
+		checksums[name] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return checksums, nil
+}
+
+// normalizedSource returns the Go source file at name, normalized with normalizeSource.
+func normalizedSource(name string) ([]byte, error) {
+	src, err := sources.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeSource(name, src)
+}
+
+// normalizeSource returns src with its comments stripped and its code printed
+// the way gofmt would print it, with blank lines left out.
+func normalizeSource(name string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, file); err != nil {
+		return nil, err
+	}
+
+	normalized := make([]byte, 0, buf.Len())
+	for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		if len(bytes.TrimSpace(line)) != 0 {
+			normalized = append(normalized, line...)
+			normalized = append(normalized, '\n')
+		}
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,70 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSource(t *testing.T) {
+	original := []byte(`package migrations
+
+import "fmt"
+
+// up does the thing
+func up() error {
+	return fmt.Errorf("oops")
+}
+`)
+
+	reformatted := []byte(`package migrations
+import "fmt"
+func up() error {
+
+		// this comment is new
+		return fmt.Errorf( "oops" ) // and so is this one
+}
+`)
+
+	changed := []byte(`package migrations
+
+import "fmt"
+
+// up does the thing
+func up() error {
+	return fmt.Errorf("whoops")
+}
+`)
+
+	a, err := normalizeSource("a.go", original)
+	assert.NoError(t, err)
+
+	b, err := normalizeSource("b.go", reformatted)
+	assert.NoError(t, err)
+	assert.Equal(t, string(a), string(b))
+
+	c, err := normalizeSource("c.go", changed)
+	assert.NoError(t, err)
+	assert.NotEqual(t, string(a), string(c))
+
+	_, err = normalizeSource("d.go", []byte("this isn't go"))
+	assert.Error(t, err)
+}
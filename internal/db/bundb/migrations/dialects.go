@@ -0,0 +1,59 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package migrations
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/uptrace/bun/dialect"
+)
+
+// dialects holds the database dialects supported by migrations that
+// don't work everywhere, keyed by migration name. Migrations that
+// aren't in here are expected to work with every dialect.
+var dialects = map[string][]dialect.Name{}
+
+// OnlyFor marks the migration in the calling file as only supporting the given
+// database dialects. Call it from the migration's init func, alongside Migrations.Register.
+func OnlyFor(ds ...dialect.Name) {
+	_, file, _, ok := runtime.Caller(1)
+	if !ok {
+		panic("migrations: couldn't work out which migration OnlyFor was called from")
+	}
+
+	base := filepath.Base(file)
+	if !migrationFileRegex.MatchString(base) {
+		panic(fmt.Sprintf("migrations: OnlyFor called from %s, which isn't a migration file", base))
+	}
+
+	// bun only uses the timestamp as the migration's name
+	dialects[base[:14]] = ds
+}
+
+// Dialects returns the database dialects supported by each migration that
+// was marked with OnlyFor, keyed by migration name.
+func Dialects() map[string][]dialect.Name {
+	ds := make(map[string][]dialect.Name, len(dialects))
+	for name, d := range dialects {
+		ds[name] = d
+	}
+	return ds
+}
@@ -28,8 +28,9 @@ import (
 )
 
 type notificationDB struct {
-	conn  *DBConn
-	cache *ttlcache.Cache
+	conn   *DBConn
+	cache  *ttlcache.Cache
+	pubsub *pubSub
 }
 
 func (n *notificationDB) newNotificationQ(i interface{}) *bun.SelectQuery {
@@ -130,3 +131,85 @@ func (n *notificationDB) getNotificationDB(ctx context.Context, id string, dst *
 	n.putNotificationCache(dst)
 	return nil
 }
+
+func (n *notificationDB) PutNotification(ctx context.Context, notif *gtsmodel.Notification) db.Error {
+	if err := n.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if _, err := n.conn.
+		NewInsert().
+		Model(notif).
+		Exec(ctx); err != nil {
+		return n.conn.ProcessError(err)
+	}
+
+	n.pubsub.publish(ctx, db.EventNotificationCreated, notif.ID)
+	return nil
+}
+
+func (n *notificationDB) DeleteNotificationsForStatus(ctx context.Context, statusID string) db.Error {
+	if err := n.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	_, err := n.deleteNotificationsWhere(ctx, func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("notification.status_id = ?", statusID)
+	})
+	return err
+}
+
+func (n *notificationDB) DeleteDanglingNotifications(ctx context.Context) (int, db.Error) {
+	if err := n.conn.CheckWritable(); err != nil {
+		return 0, err
+	}
+
+	statusQ := n.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.id = notification.status_id")
+
+	return n.deleteNotificationsWhere(ctx, func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			WhereGroup(" AND ", whereNotEmptyAndNotNull("notification.status_id")).
+			Where("NOT EXISTS (?)", statusQ)
+	})
+}
+
+// deleteNotificationsWhere deletes all notifications selected by the given where
+// func in one transaction, then drops the deleted notifications from the cache.
+func (n *notificationDB) deleteNotificationsWhere(ctx context.Context, where func(*bun.SelectQuery) *bun.SelectQuery) (int, db.Error) {
+	notifIDs := []string{}
+
+	if err := n.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		q := tx.
+			NewSelect().
+			Model((*gtsmodel.Notification)(nil)).
+			Column("notification.id")
+
+		if err := where(q).Scan(ctx, &notifIDs); err != nil {
+			return err
+		}
+
+		if len(notifIDs) == 0 {
+			return nil
+		}
+
+		_, err := tx.
+			NewDelete().
+			Model((*gtsmodel.Notification)(nil)).
+			Where("id IN (?)", bun.In(notifIDs)).
+			Exec(ctx)
+		return err
+	}); err != nil {
+		return 0, n.conn.ProcessError(err)
+	}
+
+	// only invalidate once the transaction has gone through
+	for _, id := range notifIDs {
+		n.cache.Remove(id)
+	}
+
+	return len(notifIDs), nil
+}
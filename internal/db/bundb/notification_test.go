@@ -0,0 +1,74 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type NotificationTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *NotificationTestSuite) TestDeleteNotificationsForStatus() {
+	testNotification := suite.testNotifications["local_account_1_like"]
+
+	// get the notification first so that it's cached
+	notif, err := suite.db.GetNotification(context.Background(), testNotification.ID)
+	suite.NoError(err)
+	suite.NotNil(notif)
+
+	err = suite.db.DeleteNotificationsForStatus(context.Background(), testNotification.StatusID)
+	suite.NoError(err)
+
+	// should be gone from both the cache and the db
+	notif, err = suite.db.GetNotification(context.Background(), testNotification.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(notif)
+}
+
+func (suite *NotificationTestSuite) TestDeleteDanglingNotifications() {
+	testNotification := suite.testNotifications["local_account_1_like"]
+
+	// nothing is dangling in the standard test models
+	deleted, err := suite.db.DeleteDanglingNotifications(context.Background())
+	suite.NoError(err)
+	suite.Zero(deleted)
+
+	// remove the status out from under the notification
+	err = suite.db.DeleteByID(context.Background(), testNotification.StatusID, &gtsmodel.Status{})
+	suite.NoError(err)
+
+	deleted, err = suite.db.DeleteDanglingNotifications(context.Background())
+	suite.NoError(err)
+	suite.Equal(1, deleted)
+
+	notif, err := suite.db.GetNotification(context.Background(), testNotification.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(notif)
+}
+
+func TestNotificationTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationTestSuite))
+}
@@ -0,0 +1,108 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// poolSampler periodically checks the stats of a connection pool, and logs a
+// warning when queries have spent too long waiting for a free connection.
+type poolSampler struct {
+	db        *sql.DB
+	interval  time.Duration
+	threshold time.Duration
+
+	// ctx is done once the sampler is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// last is the stats as of the previous sample
+	last sql.DBStats
+}
+
+func newPoolSampler(db *sql.DB, interval time.Duration, threshold time.Duration) *poolSampler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &poolSampler{
+		db:        db,
+		interval:  interval,
+		threshold: threshold,
+		ctx:       ctx,
+		cancel:    cancel,
+		last:      db.Stats(),
+	}
+}
+
+// start samples the pool every interval in the background, until the sampler is stopped.
+// It does nothing if the interval is 0.
+func (p *poolSampler) start() {
+	if p.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.sample()
+			}
+		}
+	}()
+}
+
+// sample reads the current stats of the pool, and logs a warning if the time spent waiting for
+// connections since the previous sample crossed the threshold. It returns whether it warned.
+func (p *poolSampler) sample() bool {
+	stats := p.db.Stats()
+	waitCount := stats.WaitCount - p.last.WaitCount
+	waitDuration := stats.WaitDuration - p.last.WaitDuration
+	p.last = stats
+
+	if waitCount == 0 || waitDuration < p.threshold {
+		return false
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"func":              "sample",
+		"waitCount":         waitCount,
+		"waitDuration":      waitDuration,
+		"maxOpen":           stats.MaxOpenConnections,
+		"open":              stats.OpenConnections,
+		"inUse":             stats.InUse,
+		"idle":              stats.Idle,
+		"maxIdleClosed":     stats.MaxIdleClosed,
+		"maxLifetimeClosed": stats.MaxLifetimeClosed,
+	}).Warnf("database connection pool is saturated: %d queries waited %s for a connection", waitCount, waitDuration)
+
+	return true
+}
+
+// stop stops sampling.
+func (p *poolSampler) stop() {
+	p.cancel()
+}
@@ -0,0 +1,116 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type PoolSamplerTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+	conn          *DBConn
+	hooks         logrus.LevelHooks
+	hook          *test.Hook
+}
+
+func (suite *PoolSamplerTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, ":memory:")
+
+	conn, err := sqliteConn(context.Background())
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.conn = conn
+
+	// capture log entries without losing whatever hooks were there before
+	suite.hooks = logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	suite.hook = test.NewGlobal()
+}
+
+func (suite *PoolSamplerTestSuite) TearDownTest() {
+	logrus.StandardLogger().ReplaceHooks(suite.hooks)
+	suite.conn.Close()
+	suite.restoreConfig()
+}
+
+// saturate holds the only connection of a tiny pool while another query waits for it.
+func (suite *PoolSamplerTestSuite) saturate(hold time.Duration) {
+	ctx := context.Background()
+	suite.conn.DB.DB.SetMaxOpenConns(1)
+
+	held, err := suite.conn.DB.DB.Conn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	go func() {
+		time.Sleep(hold)
+		held.Close()
+	}()
+
+	// this has to wait until the held connection is released
+	suite.NoError(suite.conn.PingContext(ctx))
+}
+
+func (suite *PoolSamplerTestSuite) TestSampleSaturated() {
+	sampler := newPoolSampler(suite.conn.DB.DB, time.Minute, 50*time.Millisecond)
+
+	suite.saturate(100 * time.Millisecond)
+
+	suite.True(sampler.sample())
+	entry := suite.hook.LastEntry()
+	if suite.NotNil(entry) {
+		suite.Equal(logrus.WarnLevel, entry.Level)
+		suite.Contains(entry.Message, "database connection pool is saturated")
+		suite.EqualValues(1, entry.Data["waitCount"])
+	}
+
+	// nothing more has waited since the last sample
+	suite.hook.Reset()
+	suite.False(sampler.sample())
+	suite.Nil(suite.hook.LastEntry())
+}
+
+func (suite *PoolSamplerTestSuite) TestSampleUnderThreshold() {
+	sampler := newPoolSampler(suite.conn.DB.DB, time.Minute, time.Hour)
+
+	suite.saturate(10 * time.Millisecond)
+
+	suite.False(sampler.sample())
+	suite.Nil(suite.hook.LastEntry())
+}
+
+func TestPoolSamplerTestSuite(t *testing.T) {
+	suite.Run(t, new(PoolSamplerTestSuite))
+}
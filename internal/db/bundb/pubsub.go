@@ -0,0 +1,196 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/sirupsen/logrus"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/uptrace/bun/dialect"
+)
+
+const (
+	// pubSubChannel is the postgres NOTIFY channel that events are sent on.
+	pubSubChannel = "gotosocial_events"
+	// pubSubBufferSize is how many events a subscriber can fall behind by before it starts missing them.
+	pubSubBufferSize = 64
+	// pubSubRetryInterval is how long to wait before listening again after losing the listening connection.
+	pubSubRetryInterval = 5 * time.Second
+)
+
+type pubSub struct {
+	conn *DBConn
+
+	// ctx is done once the pubsub is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	subscribers map[chan *db.Event]struct{}
+	mu          sync.Mutex
+
+	listenOnce sync.Once
+}
+
+func newPubSub(conn *DBConn) *pubSub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pubSub{
+		conn:        conn,
+		ctx:         ctx,
+		cancel:      cancel,
+		subscribers: make(map[chan *db.Event]struct{}),
+	}
+}
+
+func (p *pubSub) Subscribe() (<-chan *db.Event, func()) {
+	if p.usesNotify() {
+		// only start listening once someone's actually interested, so
+		// that short-lived processes (eg., admin commands) don't bother
+		p.listenOnce.Do(func() {
+			go p.listen(p.ctx)
+		})
+	}
+
+	ch := make(chan *db.Event, pubSubBufferSize)
+
+	p.mu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.mu.Unlock()
+
+	var unsubscribeOnce sync.Once
+	return ch, func() {
+		unsubscribeOnce.Do(func() {
+			p.mu.Lock()
+			delete(p.subscribers, ch)
+			p.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// stop stops listening for events from other processes.
+func (p *pubSub) stop() {
+	p.cancel()
+}
+
+// publish tells subscribers about the given event. It should only be called once the
+// write that the event is about has been committed. Failing to publish is logged rather
+// than returned, since the write itself went through fine.
+func (p *pubSub) publish(ctx context.Context, eventType string, id string) {
+	event := &db.Event{Type: eventType, ID: id}
+
+	if !p.usesNotify() {
+		p.dispatch(event)
+		return
+	}
+
+	// on postgres the event comes back to us through our own LISTEN,
+	// along with events from other processes, so don't dispatch it here
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("publish: error marshalling event: %s", err)
+		return
+	}
+
+	if _, err := p.conn.ExecContext(ctx, "SELECT pg_notify(?, ?)", pubSubChannel, string(payload)); err != nil {
+		logrus.Errorf("publish: error sending notification: %s", err)
+	}
+}
+
+// dispatch passes the given event to all current subscribers.
+func (p *pubSub) dispatch(event *db.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for ch := range p.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logrus.Warnf("dispatch: subscriber is falling behind, dropping %s event for %s", event.Type, event.ID)
+		}
+	}
+}
+
+// usesNotify returns true if events are passed around using postgres LISTEN/NOTIFY.
+// Cockroachdb doesn't support LISTEN/NOTIFY, so events are dispatched locally there.
+func (p *pubSub) usesNotify() bool {
+	return p.conn.Dialect().Name() == dialect.PG && !usingCockroach()
+}
+
+// listen receives events from the postgres notification channel and dispatches
+// them to subscribers, until the database is closed or ctx is done.
+func (p *pubSub) listen(ctx context.Context) {
+	l := logrus.WithField("func", "listen")
+
+	for {
+		err := p.listenConn(ctx)
+		if errors.Is(err, sql.ErrConnDone) || ctx.Err() != nil {
+			return
+		}
+
+		l.Errorf("error listening for events, will try again in %s: %s", pubSubRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pubSubRetryInterval):
+		}
+	}
+}
+
+// listenConn listens for events on one dedicated connection until something goes wrong.
+func (p *pubSub) listenConn(ctx context.Context) error {
+	conn, err := p.conn.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("listening for events is only supported for pgx connections")
+		}
+		pgxConn := stdlibConn.Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+pubSubChannel); err != nil {
+			return err
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			event := &db.Event{}
+			if err := json.Unmarshal([]byte(notification.Payload), event); err != nil {
+				logrus.Errorf("listenConn: error unmarshalling event: %s", err)
+				continue
+			}
+
+			p.dispatch(event)
+		}
+	})
+}
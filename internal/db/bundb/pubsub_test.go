@@ -0,0 +1,100 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+type PubSubTestSuite struct {
+	BunDBStandardTestSuite
+}
+
+func (suite *PubSubTestSuite) receive(events <-chan *db.Event) *db.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		suite.FailNow("timed out waiting for event")
+		return nil
+	}
+}
+
+func (suite *PubSubTestSuite) TestStatusCreated() {
+	events, unsubscribe := suite.db.Subscribe()
+	defer unsubscribe()
+
+	testAccount := suite.testAccounts["local_account_1"]
+	status := &gtsmodel.Status{
+		ID:                  "01FVSXQ3J3D4W8G1E5YTG9DJ3B",
+		URI:                 "http://localhost:8080/users/the_mighty_zork/statuses/01FVSXQ3J3D4W8G1E5YTG9DJ3B",
+		URL:                 "http://localhost:8080/@the_mighty_zork/statuses/01FVSXQ3J3D4W8G1E5YTG9DJ3B",
+		Content:             "hello world",
+		Local:               true,
+		AccountURI:          testAccount.URI,
+		AccountID:           testAccount.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}
+	err := suite.db.PutStatus(context.Background(), status)
+	suite.NoError(err)
+
+	event := suite.receive(events)
+	suite.Equal(db.EventStatusCreated, event.Type)
+	suite.Equal(status.ID, event.ID)
+}
+
+func (suite *PubSubTestSuite) TestNotificationCreated() {
+	events, unsubscribe := suite.db.Subscribe()
+	defer unsubscribe()
+
+	notif := &gtsmodel.Notification{
+		ID:               "01FVSXQCWQ6QKPPZ4J59R3KQEV",
+		NotificationType: gtsmodel.NotificationFave,
+		TargetAccountID:  suite.testAccounts["local_account_1"].ID,
+		OriginAccountID:  suite.testAccounts["admin_account"].ID,
+		StatusID:         suite.testStatuses["local_account_1_status_1"].ID,
+	}
+	err := suite.db.PutNotification(context.Background(), notif)
+	suite.NoError(err)
+
+	event := suite.receive(events)
+	suite.Equal(db.EventNotificationCreated, event.Type)
+	suite.Equal(notif.ID, event.ID)
+}
+
+func (suite *PubSubTestSuite) TestUnsubscribe() {
+	events, unsubscribe := suite.db.Subscribe()
+	unsubscribe()
+
+	// channel should be closed, and unsubscribing again should be harmless
+	_, open := <-events
+	suite.False(open)
+	unsubscribe()
+}
+
+func TestPubSubTestSuite(t *testing.T) {
+	suite.Run(t, new(PubSubTestSuite))
+}
@@ -0,0 +1,61 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/uptrace/bun/dialect"
+)
+
+// queriesFS contains queries that are too big or too dialect specific to
+// build up with bun. Each query lives in queries/<name>.<dialect>.sql, where
+// dialect is the bun dialect name (pg or sqlite), or in queries/<name>.sql
+// if the same SQL works everywhere. Arguments are given as ?0, ?1 etc.
+//
+//go:embed queries/*.sql
+var queriesFS embed.FS
+
+// dialectQuery returns the SQL for the named query for the dialect of the given connection.
+func dialectQuery(conn *DBConn, name string) (string, error) {
+	return loadDialectQuery(queriesFS, conn.Dialect().Name(), name)
+}
+
+// loadDialectQuery returns the SQL for the named query and dialect from fsys, preferring
+// a dialect-specific variant of the query and falling back to the shared one.
+func loadDialectQuery(fsys fs.FS, d dialect.Name, name string) (string, error) {
+	for _, file := range []string{
+		name + "." + d.String() + ".sql",
+		name + ".sql",
+	} {
+		b, err := fs.ReadFile(fsys, path.Join("queries", file))
+		if err == nil {
+			return string(b), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("error reading query %s: %s", file, err)
+		}
+	}
+
+	return "", fmt.Errorf("query %s not available for dialect %s", name, d)
+}
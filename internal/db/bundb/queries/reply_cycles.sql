@@ -0,0 +1,21 @@
+-- Finds statuses whose reply chain leads back to themselves,
+-- by walking up the reply chain from every reply at once.
+--
+-- Arguments: the maximum number of levels to walk up.
+WITH RECURSIVE "walk" ("start_id", "id", "depth") AS (
+	SELECT "id", "in_reply_to_id", 1
+	FROM "statuses"
+	WHERE COALESCE("in_reply_to_id", '') != ''
+
+	UNION ALL
+
+	SELECT "walk"."start_id", "statuses"."in_reply_to_id", "walk"."depth" + 1
+	FROM "statuses"
+	JOIN "walk" ON "statuses"."id" = "walk"."id"
+	WHERE "walk"."id" != "walk"."start_id"
+	AND COALESCE("statuses"."in_reply_to_id", '') != ''
+	AND "walk"."depth" < ?0
+)
+SELECT DISTINCT "start_id"
+FROM "walk"
+WHERE "id" = "start_id"
@@ -0,0 +1,21 @@
+-- Walks up a reply chain from the given status, returning
+-- the furthest ancestor reached and how far up it was.
+--
+-- Arguments: the status ID to start from, and
+-- the maximum number of levels to walk up.
+WITH RECURSIVE "ancestors" ("id", "in_reply_to_id", "depth") AS (
+	SELECT "id", "in_reply_to_id", 0
+	FROM "statuses"
+	WHERE "id" = ?0
+
+	UNION ALL
+
+	SELECT "statuses"."id", "statuses"."in_reply_to_id", "ancestors"."depth" + 1
+	FROM "statuses"
+	JOIN "ancestors" ON "statuses"."id" = "ancestors"."in_reply_to_id"
+	WHERE "ancestors"."depth" < ?1
+)
+SELECT "id", "in_reply_to_id", "depth"
+FROM "ancestors"
+ORDER BY "depth" DESC
+LIMIT 1
@@ -0,0 +1,89 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/uptrace/bun/dialect"
+)
+
+type QueriesTestSuite struct {
+	suite.Suite
+	fsys fstest.MapFS
+}
+
+func (suite *QueriesTestSuite) SetupTest() {
+	suite.fsys = fstest.MapFS{
+		"queries/split.pg.sql":     {Data: []byte("SELECT 'pg'")},
+		"queries/split.sqlite.sql": {Data: []byte("SELECT 'sqlite'")},
+		"queries/shared.sql":       {Data: []byte("SELECT 'shared'")},
+		"queries/partial.pg.sql":   {Data: []byte("SELECT 'partial pg'")},
+		"queries/partial.sql":      {Data: []byte("SELECT 'partial shared'")},
+		"queries/pgonly.pg.sql":    {Data: []byte("SELECT 'pg only'")},
+	}
+}
+
+func (suite *QueriesTestSuite) TestDialectVariantSelected() {
+	query, err := loadDialectQuery(suite.fsys, dialect.PG, "split")
+	suite.NoError(err)
+	suite.Equal("SELECT 'pg'", query)
+
+	query, err = loadDialectQuery(suite.fsys, dialect.SQLite, "split")
+	suite.NoError(err)
+	suite.Equal("SELECT 'sqlite'", query)
+}
+
+func (suite *QueriesTestSuite) TestSharedFallback() {
+	for _, d := range []dialect.Name{dialect.PG, dialect.SQLite} {
+		query, err := loadDialectQuery(suite.fsys, d, "shared")
+		suite.NoError(err)
+		suite.Equal("SELECT 'shared'", query)
+	}
+
+	// a dialect-specific variant wins over the shared one
+	query, err := loadDialectQuery(suite.fsys, dialect.PG, "partial")
+	suite.NoError(err)
+	suite.Equal("SELECT 'partial pg'", query)
+
+	query, err = loadDialectQuery(suite.fsys, dialect.SQLite, "partial")
+	suite.NoError(err)
+	suite.Equal("SELECT 'partial shared'", query)
+}
+
+func (suite *QueriesTestSuite) TestMissingVariant() {
+	_, err := loadDialectQuery(suite.fsys, dialect.SQLite, "pgonly")
+	suite.EqualError(err, "query pgonly not available for dialect sqlite")
+
+	_, err = loadDialectQuery(suite.fsys, dialect.PG, "nonexistent")
+	suite.EqualError(err, "query nonexistent not available for dialect pg")
+}
+
+func (suite *QueriesTestSuite) TestEmbeddedQueries() {
+	// make sure the real queries made it into the binary
+	query, err := loadDialectQuery(queriesFS, dialect.PG, "thread_root")
+	suite.NoError(err)
+	suite.Contains(query, "WITH RECURSIVE")
+}
+
+func TestQueriesTestSuite(t *testing.T) {
+	suite.Run(t, new(QueriesTestSuite))
+}
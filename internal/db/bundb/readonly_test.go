@@ -0,0 +1,128 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type ReadOnlyOpenTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+}
+
+func (suite *ReadOnlyOpenTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+		config.Keys.DbOpenReadOnly,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, filepath.Join(suite.T().TempDir(), "sqlite.db"))
+}
+
+func (suite *ReadOnlyOpenTestSuite) TearDownTest() {
+	suite.restoreConfig()
+}
+
+func (suite *ReadOnlyOpenTestSuite) TestSQLiteOpenedReadOnly() {
+	ctx := context.Background()
+
+	// set up a database with something in it
+	viper.Set(config.Keys.DbOpenReadOnly, false)
+	conn, err := sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	_, err = conn.ExecContext(ctx, "CREATE TABLE things (id INTEGER)")
+	suite.NoError(err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO things (id) VALUES (1)")
+	suite.NoError(err)
+	suite.NoError(conn.Close())
+
+	viper.Set(config.Keys.DbOpenReadOnly, true)
+	conn, err = sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	// reading works...
+	var count int
+	suite.NoError(conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM things").Scan(&count))
+	suite.Equal(1, count)
+
+	// ...but sqlite itself won't write, even if asked directly
+	_, err = conn.ExecContext(ctx, "INSERT INTO things (id) VALUES (2)")
+	suite.Error(err)
+
+	// and read-only mode can't be switched off
+	conn.SetReadOnly(true)
+	conn.SetReadOnly(false)
+	suite.True(conn.IsReadOnly())
+}
+
+func (suite *ReadOnlyOpenTestSuite) TestSQLiteReadOnlyModeOnly() {
+	ctx := context.Background()
+
+	// read-only mode on its own leaves the connection writable,
+	// so that it can be switched off again at runtime
+	viper.Set(config.Keys.DbOpenReadOnly, false)
+	conn, err := sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	conn.SetReadOnly(true)
+	suite.True(conn.IsReadOnly())
+	conn.SetReadOnly(false)
+	suite.False(conn.IsReadOnly())
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE things (id INTEGER)")
+	suite.NoError(err)
+}
+
+func (suite *ReadOnlyOpenTestSuite) TestSQLiteInMemoryNotLocked() {
+	viper.Set(config.Keys.DbAddress, ":memory:")
+	viper.Set(config.Keys.DbOpenReadOnly, true)
+
+	conn, err := sqliteConn(context.Background())
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	// an in-memory database is empty to begin with,
+	// so opening it read-only would make it useless
+	conn.SetReadOnly(true)
+	conn.SetReadOnly(false)
+	suite.False(conn.IsReadOnly())
+}
+
+func TestReadOnlyOpenTestSuite(t *testing.T) {
+	suite.Run(t, new(ReadOnlyOpenTestSuite))
+}
@@ -21,10 +21,16 @@ package bundb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/spf13/viper"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
+	"github.com/superseriousbusiness/gotosocial/internal/uris"
 	"github.com/uptrace/bun"
 )
 
@@ -161,6 +167,107 @@ func (r *relationshipDB) GetRelationship(ctx context.Context, requestingAccount
 	return rel, nil
 }
 
+func (r *relationshipDB) GetAccountRelationships(ctx context.Context, requestingAccount string, targetAccounts []string) ([]*gtsmodel.Relationship, db.Error) {
+	rels := make([]*gtsmodel.Relationship, 0, len(targetAccounts))
+	relsByID := make(map[string]*gtsmodel.Relationship, len(targetAccounts))
+	for _, targetAccount := range targetAccounts {
+		rel, ok := relsByID[targetAccount]
+		if !ok {
+			rel = &gtsmodel.Relationship{ID: targetAccount}
+			relsByID[targetAccount] = rel
+		}
+		rels = append(rels, rel)
+	}
+
+	if len(targetAccounts) == 0 {
+		return rels, nil
+	}
+
+	// eitherWay returns a where group func for rows in a relationship table
+	// from the requesting account to any of the targets, or the other way round
+	eitherWay := func(table string) func(*bun.SelectQuery) *bun.SelectQuery {
+		return func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.
+						Where("?.account_id = ?", bun.Ident(table), requestingAccount).
+						Where("?.target_account_id IN (?)", bun.Ident(table), bun.In(targetAccounts))
+				}).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.
+						Where("?.account_id IN (?)", bun.Ident(table), bun.In(targetAccounts)).
+						Where("?.target_account_id = ?", bun.Ident(table), requestingAccount)
+				})
+		}
+	}
+
+	follows := []*gtsmodel.Follow{}
+	if err := r.conn.
+		NewSelect().
+		Model(&follows).
+		Column("follow.account_id", "follow.target_account_id", "follow.show_reblogs", "follow.notify").
+		WhereGroup(" AND ", eitherWay("follow")).
+		Scan(ctx); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	for _, follow := range follows {
+		if follow.AccountID == requestingAccount {
+			if rel, ok := relsByID[follow.TargetAccountID]; ok {
+				rel.Following = true
+				rel.ShowingReblogs = follow.ShowReblogs
+				rel.Notifying = follow.Notify
+			}
+		}
+		if follow.TargetAccountID == requestingAccount {
+			if rel, ok := relsByID[follow.AccountID]; ok {
+				rel.FollowedBy = true
+			}
+		}
+	}
+
+	blocks := []*gtsmodel.Block{}
+	if err := r.conn.
+		NewSelect().
+		Model(&blocks).
+		Column("block.account_id", "block.target_account_id").
+		WhereGroup(" AND ", eitherWay("block")).
+		Scan(ctx); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	for _, block := range blocks {
+		if block.AccountID == requestingAccount {
+			if rel, ok := relsByID[block.TargetAccountID]; ok {
+				rel.Blocking = true
+			}
+		}
+		if block.TargetAccountID == requestingAccount {
+			if rel, ok := relsByID[block.AccountID]; ok {
+				rel.BlockedBy = true
+			}
+		}
+	}
+
+	// only outgoing follow requests count here
+	requestedIDs := []string{}
+	if err := r.conn.
+		NewSelect().
+		Model((*gtsmodel.FollowRequest)(nil)).
+		Column("follow_request.target_account_id").
+		Where("follow_request.account_id = ?", requestingAccount).
+		Where("follow_request.target_account_id IN (?)", bun.In(targetAccounts)).
+		Scan(ctx, &requestedIDs); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	for _, id := range requestedIDs {
+		relsByID[id].Requested = true
+	}
+
+	return rels, nil
+}
+
 func (r *relationshipDB) IsFollowing(ctx context.Context, sourceAccount *gtsmodel.Account, targetAccount *gtsmodel.Account) (bool, db.Error) {
 	if sourceAccount == nil || targetAccount == nil {
 		return false, nil
@@ -211,6 +318,10 @@ func (r *relationshipDB) IsMutualFollowing(ctx context.Context, account1 *gtsmod
 }
 
 func (r *relationshipDB) AcceptFollowRequest(ctx context.Context, originAccountID string, targetAccountID string) (*gtsmodel.Follow, db.Error) {
+	if err := r.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
 	// make sure the original follow request exists
 	fr := &gtsmodel.FollowRequest{}
 	if err := r.conn.
@@ -253,6 +364,10 @@ func (r *relationshipDB) AcceptFollowRequest(ctx context.Context, originAccountI
 }
 
 func (r *relationshipDB) RejectFollowRequest(ctx context.Context, originAccountID string, targetAccountID string) (*gtsmodel.FollowRequest, db.Error) {
+	if err := r.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
 	// first get the follow request out of the database
 	fr := &gtsmodel.FollowRequest{}
 	if err := r.conn.
@@ -289,6 +404,38 @@ func (r *relationshipDB) GetAccountFollowRequests(ctx context.Context, accountID
 	return followRequests, nil
 }
 
+func (r *relationshipDB) GetOutgoingFollowRequests(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.FollowRequest, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	followRequests := make([]*gtsmodel.FollowRequest, 0, limit)
+
+	q := r.newFollowQ(&followRequests).
+		Where("follow_request.account_id = ?", accountID).
+		Order("follow_request.id DESC")
+
+	if maxID != "" {
+		q = q.Where("follow_request.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	if len(followRequests) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return followRequests, nil
+}
+
 func (r *relationshipDB) GetAccountFollows(ctx context.Context, accountID string) ([]*gtsmodel.Follow, db.Error) {
 	follows := []*gtsmodel.Follow{}
 
@@ -332,6 +479,68 @@ func (r *relationshipDB) GetAccountFollowedBy(ctx context.Context, accountID str
 	return follows, nil
 }
 
+func (r *relationshipDB) GetFollowerAccountIDs(ctx context.Context, accountID string) ([]string, db.Error) {
+	accountIDs := []string{}
+
+	if err := r.conn.
+		NewSelect().
+		Model((*gtsmodel.Follow)(nil)).
+		Column("follow.account_id").
+		Where("follow.target_account_id = ?", accountID).
+		Scan(ctx, &accountIDs); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	return accountIDs, nil
+}
+
+func (r *relationshipDB) StreamFollowerAccountIDs(ctx context.Context, accountID string, batchSize int, fn func(accountIDs []string) error) db.Error {
+	if batchSize <= 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+
+	var maxID string
+	for {
+		follows := make([]*gtsmodel.Follow, 0, batchSize)
+
+		// page by follow ID rather than offset, so that
+		// each batch is as cheap to fetch as the first
+		q := r.conn.
+			NewSelect().
+			Model(&follows).
+			Column("follow.id", "follow.account_id").
+			Where("follow.target_account_id = ?", accountID).
+			Order("follow.id DESC").
+			Limit(batchSize)
+
+		if maxID != "" {
+			q = q.Where("follow.id < ?", maxID)
+		}
+
+		if err := q.Scan(ctx); err != nil {
+			return r.conn.ProcessError(err)
+		}
+
+		if len(follows) == 0 {
+			return nil
+		}
+
+		accountIDs := make([]string, 0, len(follows))
+		for _, f := range follows {
+			accountIDs = append(accountIDs, f.AccountID)
+		}
+
+		if err := fn(accountIDs); err != nil {
+			return err
+		}
+
+		if len(follows) < batchSize {
+			return nil
+		}
+		maxID = follows[len(follows)-1].ID
+	}
+}
+
 func (r *relationshipDB) CountAccountFollowedBy(ctx context.Context, accountID string, localOnly bool) (int, db.Error) {
 	return r.conn.
 		NewSelect().
@@ -339,3 +548,395 @@ func (r *relationshipDB) CountAccountFollowedBy(ctx context.Context, accountID s
 		Where("target_account_id = ?", accountID).
 		Count(ctx)
 }
+
+// newBlockedByQ returns a query for blocks by local accounts targeting accountID.
+func (r *relationshipDB) newBlockedByQ(i interface{}, accountID string) *bun.SelectQuery {
+	return r.conn.
+		NewSelect().
+		Model(i).
+		Join("JOIN ? AS ? ON ? = ?", bun.Ident("accounts"), bun.Ident("blocker"), bun.Ident("blocker.id"), bun.Ident("block.account_id")).
+		Where("block.target_account_id = ?", accountID).
+		WhereGroup(" AND ", whereEmptyOrNull("blocker.domain"))
+}
+
+func (r *relationshipDB) CountBlockedBy(ctx context.Context, accountID string) (int, db.Error) {
+	count, err := r.newBlockedByQ((*gtsmodel.Block)(nil), accountID).Count(ctx)
+	if err != nil {
+		return 0, r.conn.ProcessError(err)
+	}
+	return count, nil
+}
+
+func (r *relationshipDB) GetBlockedBy(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.Block, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	blocks := make([]*gtsmodel.Block, 0, limit)
+
+	q := r.newBlockedByQ(&blocks, accountID).
+		Relation("Account").
+		Order("block.id DESC")
+
+	if maxID != "" {
+		q = q.Where("block.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	if len(blocks) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return blocks, nil
+}
+
+func (r *relationshipDB) GetBlocksAndMutes(ctx context.Context, accountID string, maxID string, limit int) ([]*db.BlockOrMute, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	blocksQ := r.conn.
+		NewSelect().
+		Model((*gtsmodel.Block)(nil)).
+		ColumnExpr("? AS ?", db.BlockOrMuteTypeBlock, bun.Ident("type")).
+		Column("block.id", "block.target_account_id").
+		ColumnExpr("NULL AS ?", bun.Ident("status_id")).
+		Where("block.account_id = ?", accountID)
+
+	mutesQ := r.conn.
+		NewSelect().
+		Model((*gtsmodel.StatusMute)(nil)).
+		ColumnExpr("? AS ?", db.BlockOrMuteTypeMute, bun.Ident("type")).
+		Column("status_mute.id", "status_mute.target_account_id", "status_mute.status_id").
+		Where("status_mute.account_id = ?", accountID)
+
+	if maxID != "" {
+		blocksQ = blocksQ.Where("block.id < ?", maxID)
+		mutesQ = mutesQ.Where("status_mute.id < ?", maxID)
+	}
+
+	// Make educated guess for slice size
+	entries := make([]*db.BlockOrMute, 0, limit)
+
+	q := r.conn.
+		NewSelect().
+		// bun wraps each part of a union in parentheses, which sqlite won't accept, so build the union ourselves
+		TableExpr("(? UNION ALL ?) AS ?", blocksQ, mutesQ, bun.Ident("block_or_mute")).
+		ColumnExpr("?.*", bun.Ident("block_or_mute")).
+		Order("block_or_mute.id DESC")
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx, &entries); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	if len(entries) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return entries, nil
+}
+
+func (r *relationshipDB) GetFollowEdges(ctx context.Context, accountID string, outgoing bool, maxID string, limit int) ([]*db.FollowEdge, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// work out which side of the follow is which
+	ownColumn, otherColumn := "follow.target_account_id", "follow.account_id"
+	if outgoing {
+		ownColumn, otherColumn = otherColumn, ownColumn
+	}
+	other := bun.Ident(otherColumn)
+
+	// existsQ returns a query for rows in the given table from one account to another
+	existsQ := func(table string, from interface{}, to interface{}) *bun.SelectQuery {
+		return r.conn.
+			NewSelect().
+			TableExpr("? AS ?", bun.Ident(table), bun.Ident("r")).
+			Column("r.id").
+			Where("r.account_id = ?", from).
+			Where("r.target_account_id = ?", to)
+	}
+
+	// Make educated guess for slice size
+	edges := make([]*db.FollowEdge, 0, limit)
+
+	q := r.conn.
+		NewSelect().
+		TableExpr("? AS ?", bun.Ident("follows"), bun.Ident("follow")).
+		ColumnExpr("? AS ?", bun.Ident("follow.id"), bun.Ident("follow_id")).
+		ColumnExpr("? AS ?", other, bun.Ident("account_id")).
+		ColumnExpr("? AS ?", bun.Ident("follow.created_at"), bun.Ident("since")).
+		ColumnExpr("? AS ?", bun.Ident("follow.show_reblogs"), bun.Ident("show_reblogs")).
+		ColumnExpr("? AS ?", bun.Ident("follow.notify"), bun.Ident("notify")).
+		ColumnExpr("EXISTS (?) AS ?", existsQ("follows", accountID, other), bun.Ident("following")).
+		ColumnExpr("EXISTS (?) AS ?", existsQ("follows", other, accountID), bun.Ident("followed_by")).
+		ColumnExpr("EXISTS (?) AS ?", existsQ("follow_requests", accountID, other), bun.Ident("requested")).
+		ColumnExpr("EXISTS (?) AS ?", existsQ("blocks", accountID, other), bun.Ident("blocking")).
+		ColumnExpr("EXISTS (?) AS ?", existsQ("blocks", other, accountID), bun.Ident("blocked_by")).
+		Where("? = ?", bun.Ident(ownColumn), accountID).
+		Order("follow.id DESC")
+
+	if maxID != "" {
+		q = q.Where("follow.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx, &edges); err != nil {
+		return nil, r.conn.ProcessError(err)
+	}
+
+	if len(edges) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return edges, nil
+}
+
+// importFollowsBatchSize is how many targets ImportFollows deals with per transaction.
+const importFollowsBatchSize = 100
+
+// importTarget is one target passed to ImportFollows, split into its parts.
+type importTarget struct {
+	address  string
+	username string
+	domain   string
+}
+
+func (r *relationshipDB) ImportFollows(ctx context.Context, accountID string, targets []string) (db.ImportResult, db.Error) {
+	result := db.ImportResult{
+		Followed: []string{},
+		Pending:  []string{},
+		Failed:   map[string]string{},
+	}
+
+	if err := r.conn.CheckWritable(); err != nil {
+		return result, err
+	}
+
+	account := &gtsmodel.Account{}
+	if err := r.conn.
+		NewSelect().
+		Model(account).
+		Where("account.id = ?", accountID).
+		Scan(ctx); err != nil {
+		return result, r.conn.ProcessError(err)
+	}
+
+	parsed := make([]importTarget, 0, len(targets))
+	seen := make(map[string]bool, len(targets))
+	for _, address := range targets {
+		target, ok := parseImportTarget(address)
+		if !ok {
+			result.Failed[address] = "not a valid account address"
+			continue
+		}
+		if seen[target.username+"@"+target.domain] {
+			continue
+		}
+		seen[target.username+"@"+target.domain] = true
+		parsed = append(parsed, target)
+	}
+
+	for start := 0; start < len(parsed); start += importFollowsBatchSize {
+		end := start + importFollowsBatchSize
+		if end > len(parsed) {
+			end = len(parsed)
+		}
+
+		if err := r.importFollowsBatch(ctx, account, parsed[start:end], &result); err != nil {
+			return result, r.conn.ProcessError(err)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *relationshipDB) importFollowsBatch(ctx context.Context, account *gtsmodel.Account, batch []importTarget, result *db.ImportResult) error {
+	usernames := make([]string, 0, len(batch))
+	for _, target := range batch {
+		usernames = append(usernames, target.username)
+	}
+
+	// outcomes are only added to result once the transaction has committed
+	var followed, pending []string
+	failed := map[string]string{}
+
+	if err := r.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		followed, pending = nil, nil
+		failed = map[string]string{}
+
+		candidates := []*gtsmodel.Account{}
+		if err := tx.
+			NewSelect().
+			Model(&candidates).
+			Column("account.id", "account.username", "account.domain", "account.locked").
+			Where("account.username IN (?)", bun.In(usernames)).
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		byAddress := make(map[string]*gtsmodel.Account, len(candidates))
+		candidateIDs := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			byAddress[c.Username+"@"+c.Domain] = c
+			candidateIDs = append(candidateIDs, c.ID)
+		}
+
+		// blocks in either direction rule a follow out
+		blocked := []string{}
+		if len(candidateIDs) != 0 {
+			if err := tx.
+				NewSelect().
+				Model((*gtsmodel.Block)(nil)).
+				ColumnExpr("CASE WHEN ? = ? THEN ? ELSE ? END", bun.Ident("block.account_id"), account.ID, bun.Ident("block.target_account_id"), bun.Ident("block.account_id")).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.
+						Where("block.account_id = ?", account.ID).
+						Where("block.target_account_id IN (?)", bun.In(candidateIDs))
+				}).
+				WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return q.
+						Where("block.account_id IN (?)", bun.In(candidateIDs)).
+						Where("block.target_account_id = ?", account.ID)
+				}).
+				Scan(ctx, &blocked); err != nil {
+				return err
+			}
+		}
+		isBlocked := make(map[string]bool, len(blocked))
+		for _, id := range blocked {
+			isBlocked[id] = true
+		}
+
+		following := []string{}
+		if len(candidateIDs) != 0 {
+			if err := tx.
+				NewSelect().
+				Model((*gtsmodel.Follow)(nil)).
+				Column("follow.target_account_id").
+				Where("follow.account_id = ?", account.ID).
+				Where("follow.target_account_id IN (?)", bun.In(candidateIDs)).
+				Scan(ctx, &following); err != nil {
+				return err
+			}
+		}
+		isFollowing := make(map[string]bool, len(following))
+		for _, id := range following {
+			isFollowing[id] = true
+		}
+
+		for _, target := range batch {
+			targetAccount, ok := byAddress[target.username+"@"+target.domain]
+			switch {
+			case !ok:
+				failed[target.address] = "account not known to this instance"
+				continue
+			case targetAccount.ID == account.ID:
+				failed[target.address] = "can't follow yourself"
+				continue
+			case isBlocked[targetAccount.ID]:
+				failed[target.address] = "blocked"
+				continue
+			case isFollowing[targetAccount.ID]:
+				followed = append(followed, target.address)
+				continue
+			}
+
+			followID, err := id.NewULID()
+			if err != nil {
+				return err
+			}
+			uri := uris.GenerateURIForFollow(account.Username, followID)
+
+			if targetAccount.Domain == "" && !targetAccount.Locked {
+				if _, err := tx.
+					NewInsert().
+					Model(&gtsmodel.Follow{
+						ID:              followID,
+						URI:             uri,
+						AccountID:       account.ID,
+						TargetAccountID: targetAccount.ID,
+						ShowReblogs:     true,
+					}).
+					On("CONFLICT (account_id,target_account_id) DO NOTHING").
+					Exec(ctx); err != nil {
+					return err
+				}
+				followed = append(followed, target.address)
+				continue
+			}
+
+			if _, err := tx.
+				NewInsert().
+				Model(&gtsmodel.FollowRequest{
+					ID:              followID,
+					URI:             uri,
+					AccountID:       account.ID,
+					TargetAccountID: targetAccount.ID,
+					ShowReblogs:     true,
+				}).
+				On("CONFLICT (account_id,target_account_id) DO NOTHING").
+				Exec(ctx); err != nil {
+				return err
+			}
+			pending = append(pending, target.address)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	result.Followed = append(result.Followed, followed...)
+	result.Pending = append(result.Pending, pending...)
+	for address, reason := range failed {
+		result.Failed[address] = reason
+	}
+
+	return nil
+}
+
+// parseImportTarget splits an account address like 'user@example.org' or '@user@example.org'
+// into its username and domain. Addresses on this instance get an empty domain, like local accounts.
+func parseImportTarget(address string) (importTarget, bool) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(address), "@"), "@")
+	if len(parts) > 2 || parts[0] == "" {
+		return importTarget{}, false
+	}
+
+	target := importTarget{
+		address:  address,
+		username: parts[0],
+	}
+
+	if len(parts) == 2 {
+		domain := strings.ToLower(parts[1])
+		if domain == "" {
+			return importTarget{}, false
+		}
+		if domain != viper.GetString(config.Keys.Host) && domain != viper.GetString(config.Keys.AccountDomain) {
+			target.domain = domain
+		}
+	}
+
+	return target, true
+}
@@ -22,9 +22,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
 
 type RelationshipTestSuite struct {
@@ -94,6 +96,384 @@ func (suite *RelationshipTestSuite) CountAccountFollowedBy() {
 	suite.Suite.T().Skip("TODO: implement")
 }
 
+func (suite *RelationshipTestSuite) TestFollowerAccountIDs() {
+	targetAccount := suite.testAccounts["local_account_2"]
+
+	// local_account_1 already follows this account in the test models
+	for followID, follower := range map[string]*gtsmodel.Account{
+		"01FVX2B7T2CDC8BQ1V2J3JTR0S": suite.testAccounts["admin_account"],
+		"01FVX2BFHS2XZE0W9ZR7T40GTX": suite.testAccounts["remote_account_1"],
+	} {
+		err := suite.db.Put(context.Background(), &gtsmodel.Follow{
+			ID:              followID,
+			URI:             follower.URI + "/follows/" + followID,
+			AccountID:       follower.ID,
+			TargetAccountID: targetAccount.ID,
+		})
+		suite.NoError(err)
+	}
+
+	expected := []string{
+		suite.testAccounts["local_account_1"].ID,
+		suite.testAccounts["admin_account"].ID,
+		suite.testAccounts["remote_account_1"].ID,
+	}
+
+	accountIDs, err := suite.db.GetFollowerAccountIDs(context.Background(), targetAccount.ID)
+	suite.NoError(err)
+	suite.ElementsMatch(expected, accountIDs)
+
+	streamed := []string{}
+	batchSizes := []int{}
+	err = suite.db.StreamFollowerAccountIDs(context.Background(), targetAccount.ID, 2, func(accountIDs []string) error {
+		streamed = append(streamed, accountIDs...)
+		batchSizes = append(batchSizes, len(accountIDs))
+		return nil
+	})
+	suite.NoError(err)
+	suite.ElementsMatch(expected, streamed)
+	suite.Equal([]int{2, 1}, batchSizes)
+
+	// errors from the callback stop the stream
+	calls := 0
+	err = suite.db.StreamFollowerAccountIDs(context.Background(), targetAccount.ID, 1, func(accountIDs []string) error {
+		calls++
+		return errors.New("fanout failed")
+	})
+	suite.EqualError(err, "fanout failed")
+	suite.Equal(1, calls)
+
+	// no followers at all
+	accountIDs, err = suite.db.GetFollowerAccountIDs(context.Background(), suite.testAccounts["remote_account_2"].ID)
+	suite.NoError(err)
+	suite.Empty(accountIDs)
+}
+
+func (suite *RelationshipTestSuite) TestGetFollowEdges() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+	admin := suite.testAccounts["admin_account"]
+	localAccount2 := suite.testAccounts["local_account_2"]
+	remoteAccount := suite.testAccounts["remote_account_1"]
+
+	// local_account_1 already follows admin_account and local_account_2 in the test models
+	for _, model := range []interface{}{
+		&gtsmodel.Follow{ID: "01FVX6X1J2H4E7V5WB9A4ZQ3C2", URI: admin.URI + "/follows/1", AccountID: admin.ID, TargetAccountID: account.ID},
+		&gtsmodel.Follow{ID: "01FVX6X8B3J0R9CZ8J6F1HTRY5", URI: remoteAccount.URI + "/follows/1", AccountID: remoteAccount.ID, TargetAccountID: account.ID},
+		&gtsmodel.FollowRequest{ID: "01FVX6XG5G1SR2H4TY8XP7BK0N", URI: account.URI + "/follows/1", AccountID: account.ID, TargetAccountID: remoteAccount.ID},
+		&gtsmodel.Block{ID: "01FVX6XQ4AK5R7Q6N8D2HCS1EB", URI: account.URI + "/blocks/1", AccountID: account.ID, TargetAccountID: remoteAccount.ID},
+		&gtsmodel.Block{ID: "01FVX6XYW0X3NMM1Y6JXP5E4K8", URI: localAccount2.URI + "/blocks/1", AccountID: localAccount2.ID, TargetAccountID: account.ID},
+	} {
+		suite.NoError(suite.db.Put(ctx, model))
+	}
+
+	// outgoing: who local_account_1 follows
+	edges, err := suite.db.GetFollowEdges(ctx, account.ID, true, "", 1)
+	suite.NoError(err)
+	if suite.Len(edges, 1) {
+		follow := suite.testFollows["local_account_1_local_account_2"]
+		suite.Equal(follow.ID, edges[0].FollowID)
+		suite.Equal(localAccount2.ID, edges[0].AccountID)
+		suite.WithinDuration(follow.CreatedAt, edges[0].Since, time.Second)
+		suite.Equal(follow.ShowReblogs, edges[0].ShowReblogs)
+		suite.Equal(follow.Notify, edges[0].Notify)
+		suite.True(edges[0].Following)
+		suite.False(edges[0].FollowedBy)
+		suite.False(edges[0].Requested)
+		suite.False(edges[0].Blocking)
+		suite.True(edges[0].BlockedBy)
+	}
+
+	edges, err = suite.db.GetFollowEdges(ctx, account.ID, true, edges[0].FollowID, 1)
+	suite.NoError(err)
+	if suite.Len(edges, 1) {
+		suite.Equal(admin.ID, edges[0].AccountID)
+		suite.True(edges[0].Following)
+		suite.True(edges[0].FollowedBy)
+		suite.False(edges[0].BlockedBy)
+	}
+
+	_, err = suite.db.GetFollowEdges(ctx, account.ID, true, edges[0].FollowID, 1)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// incoming: who follows local_account_1
+	edges, err = suite.db.GetFollowEdges(ctx, account.ID, false, "", 10)
+	suite.NoError(err)
+	if suite.Len(edges, 2) {
+		suite.Equal("01FVX6X8B3J0R9CZ8J6F1HTRY5", edges[0].FollowID)
+		suite.Equal(remoteAccount.ID, edges[0].AccountID)
+		suite.False(edges[0].Following)
+		suite.True(edges[0].FollowedBy)
+		suite.True(edges[0].Requested)
+		suite.True(edges[0].Blocking)
+		suite.False(edges[0].BlockedBy)
+
+		suite.Equal("01FVX6X1J2H4E7V5WB9A4ZQ3C2", edges[1].FollowID)
+		suite.Equal(admin.ID, edges[1].AccountID)
+		suite.True(edges[1].Following)
+		suite.True(edges[1].FollowedBy)
+		suite.False(edges[1].Requested)
+		suite.False(edges[1].Blocking)
+	}
+}
+
+func (suite *RelationshipTestSuite) TestGetOutgoingFollowRequests() {
+	requester := suite.testAccounts["local_account_2"]
+
+	followRequests, err := suite.db.GetOutgoingFollowRequests(context.Background(), requester.ID, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(followRequests)
+
+	targets := []*gtsmodel.Account{
+		suite.testAccounts["remote_account_1"],
+		suite.testAccounts["remote_account_2"],
+	}
+	frIDs := []string{"01FVWC9BZXYRVSK8DDKXEVWS3Q", "01FVWC9X0Z5A1W2N8SXB4N1E9P"}
+	for i, target := range targets {
+		frID := frIDs[i]
+		err := suite.db.Put(context.Background(), &gtsmodel.FollowRequest{
+			ID:              frID,
+			AccountID:       requester.ID,
+			TargetAccountID: target.ID,
+			URI:             "http://localhost:8080/users/1happyturtle/follow/" + frID,
+		})
+		suite.NoError(err)
+	}
+
+	// requests made *to* the account aren't outgoing
+	err = suite.db.Put(context.Background(), &gtsmodel.FollowRequest{
+		ID:              "01FVWCA6K0HSQ3KRHB4VE9S7WM",
+		AccountID:       suite.testAccounts["admin_account"].ID,
+		TargetAccountID: requester.ID,
+		URI:             "http://localhost:8080/users/admin/follow/01FVWCA6K0HSQ3KRHB4VE9S7WM",
+	})
+	suite.NoError(err)
+
+	followRequests, err = suite.db.GetOutgoingFollowRequests(context.Background(), requester.ID, "", 1)
+	suite.NoError(err)
+	suite.Len(followRequests, 1)
+	suite.Equal(targets[1].ID, followRequests[0].TargetAccountID)
+	suite.NotNil(followRequests[0].TargetAccount)
+	suite.Equal(targets[1].Username, followRequests[0].TargetAccount.Username)
+
+	followRequests, err = suite.db.GetOutgoingFollowRequests(context.Background(), requester.ID, followRequests[0].ID, 10)
+	suite.NoError(err)
+	suite.Len(followRequests, 1)
+	suite.Equal(targets[0].ID, followRequests[0].TargetAccountID)
+
+	followRequests, err = suite.db.GetOutgoingFollowRequests(context.Background(), requester.ID, followRequests[0].ID, 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(followRequests)
+}
+
+func (suite *RelationshipTestSuite) TestGetAccountRelationships() {
+	viewer := suite.testAccounts["local_account_1"]
+
+	// add a pending follow request and a block in each direction, on top of the
+	// follows that local_account_1 already has, to get a good mix of relationships
+	for _, model := range []interface{}{
+		&gtsmodel.FollowRequest{
+			ID:              "01FVWDF6W3Q7C1KFCSVZ6HB7AV",
+			AccountID:       viewer.ID,
+			TargetAccountID: suite.testAccounts["remote_account_2"].ID,
+			URI:             "http://localhost:8080/users/the_mighty_zork/follow/01FVWDF6W3Q7C1KFCSVZ6HB7AV",
+		},
+		&gtsmodel.Follow{
+			ID:              "01FVWDFEVNKB1RPBJKQTQ5CWJ8",
+			AccountID:       suite.testAccounts["local_account_2"].ID,
+			TargetAccountID: viewer.ID,
+			URI:             "http://localhost:8080/users/1happyturtle/follow/01FVWDFEVNKB1RPBJKQTQ5CWJ8",
+		},
+		&gtsmodel.Block{
+			ID:              "01FVWDFPAD7XP3N0SSN7XT9KQY",
+			AccountID:       viewer.ID,
+			TargetAccountID: suite.testAccounts["remote_account_1"].ID,
+			URI:             "http://localhost:8080/users/the_mighty_zork/blocks/01FVWDFPAD7XP3N0SSN7XT9KQY",
+		},
+		&gtsmodel.Block{
+			ID:              "01FVWDFXSY4Q4B44CWDY7R7PH9",
+			AccountID:       suite.testAccounts["local_account_2"].ID,
+			TargetAccountID: viewer.ID,
+			URI:             "http://localhost:8080/users/1happyturtle/blocks/01FVWDFXSY4Q4B44CWDY7R7PH9",
+		},
+	} {
+		suite.NoError(suite.db.Put(context.Background(), model))
+	}
+
+	targetIDs := []string{
+		suite.testAccounts["admin_account"].ID,
+		suite.testAccounts["local_account_2"].ID,
+		suite.testAccounts["remote_account_1"].ID,
+		suite.testAccounts["remote_account_2"].ID,
+		viewer.ID,
+		"01FVWDG5GMKB3TN9RZBKNBX8JM", // no such account
+	}
+
+	rels, err := suite.db.GetAccountRelationships(context.Background(), viewer.ID, targetIDs)
+	suite.NoError(err)
+	suite.Len(rels, len(targetIDs))
+
+	for i, targetID := range targetIDs {
+		expected, err := suite.db.GetRelationship(context.Background(), viewer.ID, targetID)
+		suite.NoError(err)
+		suite.Equal(expected, rels[i])
+	}
+
+	// make sure the mix actually covered something
+	suite.True(rels[0].Following)
+	suite.True(rels[1].Following && rels[1].FollowedBy && rels[1].BlockedBy)
+	suite.True(rels[2].Blocking)
+	suite.True(rels[3].Requested)
+
+	rels, err = suite.db.GetAccountRelationships(context.Background(), viewer.ID, nil)
+	suite.NoError(err)
+	suite.Empty(rels)
+}
+
+func (suite *RelationshipTestSuite) TestBlockedBy() {
+	targetAccount := suite.testAccounts["remote_account_1"]
+
+	// local_account_2 already blocks this account in the test models
+	count, err := suite.db.CountBlockedBy(context.Background(), targetAccount.ID)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	for blockID, blocker := range map[string]*gtsmodel.Account{
+		"01FVSKD2VVQ42ZCNGG3M1G6W3B": suite.testAccounts["local_account_1"],
+		// blocks from other instances shouldn't be counted
+		"01FVSKDFR2N84K6W3ES6JNYY6Q": suite.testAccounts["remote_account_2"],
+	} {
+		err := suite.db.Put(context.Background(), &gtsmodel.Block{
+			ID:              blockID,
+			URI:             blocker.URI + "/blocks/" + blockID,
+			AccountID:       blocker.ID,
+			TargetAccountID: targetAccount.ID,
+		})
+		suite.NoError(err)
+	}
+
+	count, err = suite.db.CountBlockedBy(context.Background(), targetAccount.ID)
+	suite.NoError(err)
+	suite.Equal(2, count)
+
+	blocks, err := suite.db.GetBlockedBy(context.Background(), targetAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Len(blocks, 2)
+	suite.Equal("01FVSKD2VVQ42ZCNGG3M1G6W3B", blocks[0].ID)
+	suite.Equal(suite.testAccounts["local_account_1"].ID, blocks[0].Account.ID)
+	suite.Equal(suite.testAccounts["local_account_2"].ID, blocks[1].Account.ID)
+
+	blocks, err = suite.db.GetBlockedBy(context.Background(), targetAccount.ID, blocks[1].ID, 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(blocks)
+}
+
+func (suite *RelationshipTestSuite) TestGetBlocksAndMutes() {
+	account := suite.testAccounts["local_account_1"]
+	targetAccount := suite.testAccounts["remote_account_1"]
+	mutedStatus := suite.testStatuses["local_account_2_status_1"]
+
+	err := suite.db.Put(context.Background(), &gtsmodel.Block{
+		ID:              "01FVW5JZ0ZKG2YJ1JR04N3KQB4",
+		URI:             account.URI + "/blocks/01FVW5JZ0ZKG2YJ1JR04N3KQB4",
+		AccountID:       account.ID,
+		TargetAccountID: targetAccount.ID,
+	})
+	suite.NoError(err)
+
+	for _, muteID := range []string{"01FVW5K8BZR6AYTV2NBEAF1MJT", "01FVW5JQ9M4Q9AXTCEQD5E2H0B"} {
+		err := suite.db.Put(context.Background(), &gtsmodel.StatusMute{
+			ID:              muteID,
+			AccountID:       account.ID,
+			TargetAccountID: mutedStatus.AccountID,
+			StatusID:        mutedStatus.ID,
+		})
+		suite.NoError(err)
+	}
+
+	entries, err := suite.db.GetBlocksAndMutes(context.Background(), account.ID, "", 2)
+	suite.NoError(err)
+	suite.Len(entries, 2)
+
+	suite.Equal("01FVW5K8BZR6AYTV2NBEAF1MJT", entries[0].ID)
+	suite.Equal(db.BlockOrMuteTypeMute, entries[0].Type)
+	suite.Equal(mutedStatus.AccountID, entries[0].TargetAccountID)
+	suite.Equal(mutedStatus.ID, entries[0].StatusID)
+
+	suite.Equal("01FVW5JZ0ZKG2YJ1JR04N3KQB4", entries[1].ID)
+	suite.Equal(db.BlockOrMuteTypeBlock, entries[1].Type)
+	suite.Equal(targetAccount.ID, entries[1].TargetAccountID)
+	suite.Empty(entries[1].StatusID)
+
+	entries, err = suite.db.GetBlocksAndMutes(context.Background(), account.ID, entries[1].ID, 2)
+	suite.NoError(err)
+	suite.Len(entries, 1)
+	suite.Equal("01FVW5JQ9M4Q9AXTCEQD5E2H0B", entries[0].ID)
+	suite.Equal(db.BlockOrMuteTypeMute, entries[0].Type)
+
+	entries, err = suite.db.GetBlocksAndMutes(context.Background(), account.ID, entries[0].ID, 2)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(entries)
+}
+
+func (suite *RelationshipTestSuite) TestImportFollows() {
+	ctx := context.Background()
+	admin := suite.testAccounts["admin_account"]
+	zork := suite.testAccounts["local_account_1"]
+	turtle := suite.testAccounts["local_account_2"]
+	remote := suite.testAccounts["remote_account_1"]
+
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Block{
+		ID:              "01FWB2K7C4T9N1X6R3M8Q5V0ZE",
+		URI:             "http://example.org/users/some_user/blocks/01FWB2K7C4T9N1X6R3M8Q5V0ZE",
+		AccountID:       suite.testAccounts["remote_account_2"].ID,
+		TargetAccountID: admin.ID,
+	}))
+
+	result, err := suite.db.ImportFollows(ctx, admin.ID, []string{
+		"the_mighty_zork@localhost:8080",
+		"@the_mighty_zork@localhost:8080",
+		"1happyturtle",
+		"foss_satan@fossbros-anonymous.io",
+		"some_user@example.org",
+		"nobody@example.org",
+		"admin",
+		"not@an@address",
+	})
+	suite.NoError(err)
+
+	// unlocked local accounts are followed straight away
+	suite.Equal([]string{"the_mighty_zork@localhost:8080"}, result.Followed)
+	following, err := suite.db.IsFollowing(ctx, admin, zork)
+	suite.NoError(err)
+	suite.True(following)
+
+	// locked and remote accounts get a follow request
+	suite.Equal([]string{"1happyturtle", "foss_satan@fossbros-anonymous.io"}, result.Pending)
+	for _, target := range []*gtsmodel.Account{turtle, remote} {
+		requested, err := suite.db.IsFollowRequested(ctx, admin, target)
+		suite.NoError(err)
+		suite.True(requested, target.Username)
+	}
+
+	suite.Equal(map[string]string{
+		"some_user@example.org": "blocked",
+		"nobody@example.org":    "account not known to this instance",
+		"admin":                 "can't follow yourself",
+		"not@an@address":        "not a valid account address",
+	}, result.Failed)
+
+	// importing the same list again changes nothing
+	again, err := suite.db.ImportFollows(ctx, admin.ID, []string{"the_mighty_zork", "1happyturtle"})
+	suite.NoError(err)
+	suite.Equal([]string{"the_mighty_zork"}, again.Followed)
+	suite.Equal([]string{"1happyturtle"}, again.Pending)
+
+	follows, err := suite.db.CountAccountFollows(ctx, admin.ID, false)
+	suite.NoError(err)
+	suite.Equal(1, follows)
+}
+
 func TestRelationshipTestSuite(t *testing.T) {
 	suite.Run(t, new(RelationshipTestSuite))
 }
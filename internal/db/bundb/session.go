@@ -61,6 +61,10 @@ func (s *sessionDB) GetSession(ctx context.Context) (*gtsmodel.RouterSession, db
 }
 
 func (s *sessionDB) createSession(ctx context.Context) (*gtsmodel.RouterSession, db.Error) {
+	if err := s.conn.CheckWritable(); err != nil {
+		return nil, err
+	}
+
 	auth := make([]byte, 32)
 	crypt := make([]byte, 32)
 
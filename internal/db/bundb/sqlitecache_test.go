@@ -0,0 +1,119 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type SQLiteCacheTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+}
+
+func (suite *SQLiteCacheTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+		config.Keys.DbSqliteCache,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, filepath.Join(suite.T().TempDir(), "sqlite.db"))
+}
+
+func (suite *SQLiteCacheTestSuite) TearDownTest() {
+	suite.restoreConfig()
+}
+
+// countUncommitted inserts a row on one connection without committing it, then
+// counts the rows on another connection that asks to read uncommitted data.
+func (suite *SQLiteCacheTestSuite) countUncommitted(cache string) (int, error) {
+	ctx := context.Background()
+	viper.Set(config.Keys.DbSqliteCache, cache)
+
+	conn, err := sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE things (id INTEGER)"); err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	writer, err := conn.Conn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer writer.Close()
+
+	reader, err := conn.Conn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer reader.Close()
+
+	tx, err := writer.BeginTx(ctx, nil)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer tx.Rollback() //nolint
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO things (id) VALUES (1)"); err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	if _, err := reader.ExecContext(ctx, "PRAGMA read_uncommitted = 1"); err != nil {
+		suite.FailNow(err.Error())
+	}
+
+	var count int
+	err = reader.QueryRowContext(ctx, "SELECT COUNT(*) FROM things").Scan(&count)
+	return count, err
+}
+
+func (suite *SQLiteCacheTestSuite) TestPrivateCacheIsolated() {
+	count, err := suite.countUncommitted(dbSqliteCachePrivate)
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *SQLiteCacheTestSuite) TestSharedCacheNotIsolated() {
+	count, err := suite.countUncommitted(dbSqliteCacheShared)
+	suite.NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *SQLiteCacheTestSuite) TestUnknownCache() {
+	viper.Set(config.Keys.DbSqliteCache, "public")
+
+	_, err := sqliteConn(context.Background())
+	suite.EqualError(err, "sqlite cache mode public not recognised, expected private or shared")
+}
+
+func TestSQLiteCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(SQLiteCacheTestSuite))
+}
@@ -0,0 +1,88 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type SQLiteForeignKeysTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+}
+
+func (suite *SQLiteForeignKeysTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+		config.Keys.DbSqliteForeignKeys,
+	)
+
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, filepath.Join(suite.T().TempDir(), "sqlite.db"))
+}
+
+func (suite *SQLiteForeignKeysTestSuite) TearDownTest() {
+	suite.restoreConfig()
+}
+
+// insertOrphan inserts a row that refers to a row that doesn't exist.
+func (suite *SQLiteForeignKeysTestSuite) insertOrphan(foreignKeys bool) error {
+	ctx := context.Background()
+	viper.Set(config.Keys.DbSqliteForeignKeys, foreignKeys)
+
+	conn, err := sqliteConn(ctx)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	for _, q := range []string{
+		"CREATE TABLE parents (id TEXT PRIMARY KEY)",
+		"CREATE TABLE children (id TEXT PRIMARY KEY, parent_id TEXT REFERENCES parents (id))",
+	} {
+		if _, err := conn.ExecContext(ctx, q); err != nil {
+			suite.FailNow(err.Error())
+		}
+	}
+
+	_, err = conn.ExecContext(ctx, "INSERT INTO children (id, parent_id) VALUES ('child', 'missing')")
+	return err
+}
+
+func (suite *SQLiteForeignKeysTestSuite) TestOrphanRejected() {
+	err := suite.insertOrphan(true)
+	if suite.Error(err) {
+		suite.Contains(err.Error(), "FOREIGN KEY constraint failed")
+	}
+}
+
+func (suite *SQLiteForeignKeysTestSuite) TestOrphanAllowedWhenDisabled() {
+	suite.NoError(suite.insertOrphan(false))
+}
+
+func TestSQLiteForeignKeysTestSuite(t *testing.T) {
+	suite.Run(t, new(SQLiteForeignKeysTestSuite))
+}
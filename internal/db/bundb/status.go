@@ -21,15 +21,30 @@ package bundb
 import (
 	"container/list"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/ReneKroon/ttlcache"
 	"github.com/sirupsen/logrus"
 	"github.com/superseriousbusiness/gotosocial/internal/cache"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 )
 
+// htmlTagRegex matches html tags, for stripping them from status content.
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
 type statusDB struct {
 	conn  *DBConn
 	cache *cache.StatusCache
@@ -38,8 +53,16 @@ type statusDB struct {
 	//       all point to one single "db" type, so they can all share methods
 	//       and caches where necessary
 	accounts *accountDB
+	mentions *mentionDB
+	pubsub   *pubSub
+
+	// trendingTags caches results of GetTrendingTags
+	trendingTags *ttlcache.Cache
 }
 
+// trendingTagsCacheTTL is how long results of GetTrendingTags are cached for.
+const trendingTagsCacheTTL = 5 * time.Minute
+
 func (s *statusDB) newStatusQ(status interface{}) *bun.SelectQuery {
 	return s.conn.
 		NewSelect().
@@ -54,89 +77,1089 @@ func (s *statusDB) newStatusQ(status interface{}) *bun.SelectQuery {
 		Relation("CreatedWithApplication")
 }
 
-func (s *statusDB) newFaveQ(faves interface{}) *bun.SelectQuery {
-	return s.conn.
-		NewSelect().
-		Model(faves).
-		Relation("Account").
-		Relation("TargetAccount").
-		Relation("Status")
-}
+func (s *statusDB) newFaveQ(faves interface{}) *bun.SelectQuery {
+	return s.conn.
+		NewSelect().
+		Model(faves).
+		Relation("Account").
+		Relation("TargetAccount").
+		Relation("Status")
+}
+
+func (s *statusDB) GetStatusByID(ctx context.Context, id string) (*gtsmodel.Status, db.Error) {
+	return s.getStatus(
+		ctx,
+		func() (*gtsmodel.Status, bool) {
+			return s.cache.GetByID(id)
+		},
+		func(status *gtsmodel.Status) error {
+			return s.newStatusQ(status).Where("status.id = ?", id).Scan(ctx)
+		},
+	)
+}
+
+func (s *statusDB) GetStatusByURI(ctx context.Context, uri string) (*gtsmodel.Status, db.Error) {
+	return s.getStatus(
+		ctx,
+		func() (*gtsmodel.Status, bool) {
+			return s.cache.GetByURI(uri)
+		},
+		func(status *gtsmodel.Status) error {
+			return s.newStatusQ(status).Where("LOWER(status.uri) = LOWER(?)", uri).Scan(ctx)
+		},
+	)
+}
+
+func (s *statusDB) GetStatusByURL(ctx context.Context, url string) (*gtsmodel.Status, db.Error) {
+	return s.getStatus(
+		ctx,
+		func() (*gtsmodel.Status, bool) {
+			return s.cache.GetByURL(url)
+		},
+		func(status *gtsmodel.Status) error {
+			return s.newStatusQ(status).Where("LOWER(status.url) = LOWER(?)", url).Scan(ctx)
+		},
+	)
+}
+
+func (s *statusDB) getStatus(ctx context.Context, cacheGet func() (*gtsmodel.Status, bool), dbQuery func(*gtsmodel.Status) error) (*gtsmodel.Status, db.Error) {
+	// Attempt to fetch cached status
+	status, cached := cacheGet()
+
+	if !cached {
+		status = &gtsmodel.Status{}
+
+		// Not cached! Perform database query
+		err := dbQuery(status)
+		if err != nil {
+			return nil, s.conn.ProcessError(err)
+		}
+
+		// If there is boosted, fetch from DB also
+		if status.BoostOfID != "" {
+			boostOf, err := s.GetStatusByID(ctx, status.BoostOfID)
+			if err == nil {
+				status.BoostOf = boostOf
+			}
+		}
+
+		// Place in the cache
+		s.cache.Put(status)
+	}
+
+	// Set the status author account
+	author, err := s.accounts.GetAccountByID(ctx, status.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the prepared status
+	status.Account = author
+	return status, nil
+}
+
+func (s *statusDB) GetStatusByMediaID(ctx context.Context, attachmentID string) (*gtsmodel.Status, db.Error) {
+	var statusID string
+
+	q := s.conn.
+		NewSelect().
+		Model((*gtsmodel.MediaAttachment)(nil)).
+		Column("media_attachment.status_id").
+		Where("media_attachment.id = ?", attachmentID)
+
+	if err := q.Scan(ctx, &statusID); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if statusID == "" {
+		// attachment exists but isn't attached to anything (yet)
+		return nil, db.ErrNoEntries
+	}
+
+	return s.GetStatusByID(ctx, statusID)
+}
+
+func (s *statusDB) GetLatestPublicStatuses(ctx context.Context, accountIDs []string) ([]*gtsmodel.Status, db.Error) {
+	if len(accountIDs) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	// number each account's public statuses from newest to oldest, so we can pick out
+	// all the newest ones in one pass instead of doing a query per account; window
+	// functions are supported by postgres and by the sqlite bundled with our driver
+	ranked := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id", "status.created_at").
+		ColumnExpr("ROW_NUMBER() OVER (PARTITION BY ? ORDER BY ? DESC, ? DESC) AS ?", bun.Ident("status.account_id"), bun.Ident("status.created_at"), bun.Ident("status.id"), bun.Ident("rn")).
+		Where("status.account_id IN (?)", bun.In(accountIDs)).
+		Where("status.visibility = ?", gtsmodel.VisibilityPublic).
+		WhereGroup(" AND ", whereEmptyOrNull("status.boost_of_id"))
+
+	statusIDs := []string{}
+
+	q := s.conn.
+		NewSelect().
+		TableExpr("(?) AS ?", ranked, bun.Ident("ranked")).
+		Column("ranked.id").
+		Where("ranked.rn = 1").
+		Order("ranked.created_at DESC").
+		// statuses can be created at the same time,
+		// so fall back to the id to keep the order stable
+		Order("ranked.id DESC")
+
+	if err := q.Scan(ctx, &statusIDs); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statusIDs) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	statuses := make([]*gtsmodel.Status, 0, len(statusIDs))
+	for _, id := range statusIDs {
+		status, err := s.GetStatusByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) GetAccountMediaStatuses(ctx context.Context, accountID string, requestingAccountID string, maxID string, limit int) ([]*gtsmodel.Status, int, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// mediaStatusesQ returns a query for the IDs of all media statuses
+	// of the account that the requesting account is allowed to see
+	mediaStatusesQ := func() *bun.SelectQuery {
+		attachmentsQ := s.conn.
+			NewSelect().
+			Model((*gtsmodel.MediaAttachment)(nil)).
+			Column("media_attachment.id").
+			Where("media_attachment.status_id = status.id")
+
+		return s.conn.
+			NewSelect().
+			Model((*gtsmodel.Status)(nil)).
+			Column("status.id").
+			Where("status.account_id = ?", accountID).
+			Where("EXISTS (?)", attachmentsQ).
+			WhereGroup(" AND ", s.whereVisibleTo(requestingAccountID))
+	}
+
+	q := mediaStatusesQ().Order("status.id DESC")
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	statusIDs := make([]string, 0, limit)
+	if err := q.Scan(ctx, &statusIDs); err != nil {
+		return nil, 0, s.conn.ProcessError(err)
+	}
+
+	if len(statusIDs) == 0 {
+		return nil, 0, db.ErrNoEntries
+	}
+
+	count, err := mediaStatusesQ().Count(ctx)
+	if err != nil {
+		return nil, 0, s.conn.ProcessError(err)
+	}
+
+	statuses := make([]*gtsmodel.Status, 0, len(statusIDs))
+	for _, id := range statusIDs {
+		status, err := s.GetStatusByID(ctx, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, count, nil
+}
+
+// whereVisibleTo returns a where group func restricting statuses to the ones
+// that requestingAccountID is allowed to see. An empty requestingAccountID
+// is someone who isn't logged in: they only get public statuses, and none
+// from domains that this instance has suspended.
+func (s *statusDB) whereVisibleTo(requestingAccountID string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if requestingAccountID == "" {
+			blockedDomainQ := s.conn.
+				NewSelect().
+				Model((*gtsmodel.DomainBlock)(nil)).
+				Column("domain_block.id").
+				Join("JOIN ? AS ? ON ? = ?", bun.Ident("accounts"), bun.Ident("author"), bun.Ident("author.domain"), bun.Ident("domain_block.domain")).
+				Where("author.id = status.account_id").
+				// only suspended domains are blocked outright, domains with rejected media still federate
+				Where("domain_block.severity = ?", gtsmodel.DomainBlockSeveritySuspend)
+
+			return q.
+				Where("status.visibility = ?", gtsmodel.VisibilityPublic).
+				Where("NOT EXISTS (?)", blockedDomainQ)
+		}
+
+		q = q.WhereOr("status.visibility IN (?)", bun.In([]gtsmodel.Visibility{
+			gtsmodel.VisibilityPublic,
+			gtsmodel.VisibilityUnlocked,
+		}))
+
+		// followsQ returns a query for a follow from accountCol to targetAccountCol
+		followsQ := func(accountCol interface{}, targetAccountCol interface{}) *bun.SelectQuery {
+			return s.conn.
+				NewSelect().
+				Model((*gtsmodel.Follow)(nil)).
+				Column("follow.id").
+				Where("follow.account_id = ?", accountCol).
+				Where("follow.target_account_id = ?", targetAccountCol)
+		}
+
+		mentionedQ := s.conn.
+			NewSelect().
+			Model((*gtsmodel.Mention)(nil)).
+			Column("mention.id").
+			Where("mention.status_id = status.id").
+			Where("mention.target_account_id = ?", requestingAccountID)
+
+		author := bun.Ident("status.account_id")
+
+		return q.
+			// owners can see all their own statuses
+			WhereOr("status.account_id = ?", requestingAccountID).
+			WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.
+					Where("status.visibility = ?", gtsmodel.VisibilityFollowersOnly).
+					Where("EXISTS (?)", followsQ(requestingAccountID, author))
+			}).
+			WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.
+					Where("status.visibility = ?", gtsmodel.VisibilityMutualsOnly).
+					Where("EXISTS (?)", followsQ(requestingAccountID, author)).
+					Where("EXISTS (?)", followsQ(author, requestingAccountID))
+			}).
+			WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.
+					Where("status.visibility = ?", gtsmodel.VisibilityDirect).
+					Where("EXISTS (?)", mentionedQ)
+			})
+	}
+}
+
+// forViewer returns a query decorator that restricts statuses to the ones that the viewer set on ctx
+// with db.WithViewer may see: visible to them going by whereVisibleTo, not involved in a block either
+// way between them and the author, and not muted by them. If ctx carries no viewer, the query is
+// left as it is.
+func (s *statusDB) forViewer(ctx context.Context) func(*bun.SelectQuery) *bun.SelectQuery {
+	viewer, ok := db.ViewerFromContext(ctx)
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if !ok {
+			return q
+		}
+
+		q = q.WhereGroup(" AND ", s.whereVisibleTo(viewer.AccountID))
+		if viewer.AccountID == "" {
+			return q
+		}
+
+		mutedQ := s.conn.
+			NewSelect().
+			Model((*gtsmodel.StatusMute)(nil)).
+			Column("status_mute.id").
+			Where("status_mute.account_id = ?", viewer.AccountID).
+			Where("status_mute.status_id = status.id")
+
+		return q.
+			Where("NOT EXISTS (?)", s.blocksWithAccountQ(viewer.AccountID, bun.Ident("status.account_id"))).
+			Where("NOT EXISTS (?)", mutedQ)
+	}
+}
+
+// filterForViewer returns the statuses that the viewer set on ctx may see, going by forViewer,
+// in the order they were given in. If ctx carries no viewer, statuses are returned as they are.
+func (s *statusDB) filterForViewer(ctx context.Context, statuses []*gtsmodel.Status) ([]*gtsmodel.Status, db.Error) {
+	if _, ok := db.ViewerFromContext(ctx); !ok || len(statuses) == 0 {
+		return statuses, nil
+	}
+
+	statusIDs := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		statusIDs = append(statusIDs, status.ID)
+	}
+
+	visibleIDs := []string{}
+	if err := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.id IN (?)", bun.In(statusIDs)).
+		Apply(s.forViewer(ctx)).
+		Scan(ctx, &visibleIDs); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	isVisible := make(map[string]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		isVisible[id] = true
+	}
+
+	visible := make([]*gtsmodel.Status, 0, len(visibleIDs))
+	for _, status := range statuses {
+		if isVisible[status.ID] {
+			visible = append(visible, status)
+		}
+	}
+
+	return visible, nil
+}
+
+// blocksWithAccountQ returns a query for blocks in either direction between
+// accountID and the account in otherAccountCol, eg., the author of the status
+// being selected.
+func (s *statusDB) blocksWithAccountQ(accountID string, otherAccountCol bun.Ident) *bun.SelectQuery {
+	return s.conn.
+		NewSelect().
+		Model((*gtsmodel.Block)(nil)).
+		Column("block.id").
+		WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("block.account_id = ?", accountID).
+				Where("block.target_account_id = ?", otherAccountCol)
+		}).
+		WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("block.account_id = ?", otherAccountCol).
+				Where("block.target_account_id = ?", accountID)
+		})
+}
+
+func (s *statusDB) StatusVisibleTo(ctx context.Context, statusID string, accountID string) (bool, db.Error) {
+	visible, err := s.StatusesVisibleTo(ctx, []string{statusID}, accountID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(visible) != 0, nil
+}
+
+func (s *statusDB) StatusesVisibleTo(ctx context.Context, statusIDs []string, accountID string) ([]string, db.Error) {
+	if len(statusIDs) == 0 {
+		return []string{}, nil
+	}
+
+	q := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.id IN (?)", bun.In(statusIDs)).
+		WhereGroup(" AND ", s.whereVisibleTo(accountID))
+
+	if accountID != "" {
+		q = q.Where("NOT EXISTS (?)", s.blocksWithAccountQ(accountID, bun.Ident("status.account_id")))
+	}
+
+	visibleIDs := []string{}
+	if err := q.Scan(ctx, &visibleIDs); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	isVisible := make(map[string]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		isVisible[id] = true
+	}
+
+	// keep the order the statuses were asked for in
+	visible := make([]string, 0, len(visibleIDs))
+	for _, id := range statusIDs {
+		if isVisible[id] {
+			visible = append(visible, id)
+			isVisible[id] = false
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *statusDB) GetRepliesToAccount(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.Status, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	q := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.in_reply_to_account_id = ?", accountID).
+		// replying to yourself (ie., threading) doesn't count
+		Where("status.account_id != ?", accountID).
+		// don't show replies from anyone the account
+		// has blocked, or who has blocked the account
+		Where("NOT EXISTS (?)", s.blocksWithAccountQ(accountID, bun.Ident("status.account_id"))).
+		WhereGroup(" AND ", s.whereVisibleTo(accountID)).
+		Order("status.id DESC")
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	statusIDs := make([]string, 0, limit)
+	if err := q.Scan(ctx, &statusIDs); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statusIDs) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	statuses := make([]*gtsmodel.Status, 0, len(statusIDs))
+	for _, id := range statusIDs {
+		status, err := s.GetStatusByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) GetStatusesMatchingFilter(ctx context.Context, filter db.FilterSpec, maxID string, limit int) ([]*gtsmodel.Status, db.Error) {
+	if len(filter.Keywords) == 0 && !filter.Sensitive {
+		return nil, errors.New("filter has no criteria set")
+	}
+
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	statuses := make([]*gtsmodel.Status, 0, limit)
+
+	// select whole statuses straight away, rather than
+	// going back to the db for each one on the page
+	q := s.newStatusQ(&statuses).
+		Order("status.id DESC")
+
+	if filter.Sensitive {
+		q = q.Where("status.sensitive = ?", true)
+	}
+
+	if len(filter.Keywords) != 0 {
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, keyword := range filter.Keywords {
+				pattern := "%" + escapeLike(strings.ToLower(keyword)) + "%"
+				q = q.
+					WhereOr("LOWER(status.content) LIKE ? ESCAPE '\\'", pattern).
+					WhereOr("LOWER(status.content_warning) LIKE ? ESCAPE '\\'", pattern)
+			}
+			return q
+		})
+	}
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statuses) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return statuses, nil
+}
+
+// escapeLike escapes the characters that are special in LIKE patterns, using backslash as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (s *statusDB) GetTrendingTags(ctx context.Context, window time.Duration, limit int) ([]*db.TrendingTag, db.Error) {
+	cacheKey := fmt.Sprintf("%s/%d", window, limit)
+	if cached, ok := s.trendingTags.Get(cacheKey); ok {
+		return cached.([]*db.TrendingTag), nil
+	}
+
+	counts := []struct {
+		TagID    string `bun:"tag_id"`
+		Accounts int    `bun:"accounts"`
+		Uses     int    `bun:"uses"`
+	}{}
+
+	q := s.conn.
+		NewSelect().
+		TableExpr("? AS ?", bun.Ident("status_to_tags"), bun.Ident("status_to_tag")).
+		Column("status_to_tag.tag_id").
+		ColumnExpr("COUNT(DISTINCT ?) AS ?", bun.Ident("status.account_id"), bun.Ident("accounts")).
+		ColumnExpr("COUNT(*) AS ?", bun.Ident("uses")).
+		Join("JOIN ? AS ? ON ? = ?", bun.Ident("statuses"), bun.Ident("status"), bun.Ident("status.id"), bun.Ident("status_to_tag.status_id")).
+		Join("JOIN ? AS ? ON ? = ?", bun.Ident("tags"), bun.Ident("tag"), bun.Ident("tag.id"), bun.Ident("status_to_tag.tag_id")).
+		Where("status.created_at > ?", time.Now().Add(-window)).
+		Where("status.visibility = ?", gtsmodel.VisibilityPublic).
+		Where("tag.listable = ?", true).
+		Group("status_to_tag.tag_id").
+		OrderExpr("? DESC, ? DESC, ? DESC", bun.Ident("accounts"), bun.Ident("uses"), bun.Ident("status_to_tag.tag_id"))
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx, &counts); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(counts) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	tagIDs := make([]string, 0, len(counts))
+	for _, c := range counts {
+		tagIDs = append(tagIDs, c.TagID)
+	}
+
+	tags := []*gtsmodel.Tag{}
+	if err := s.conn.
+		NewSelect().
+		Model(&tags).
+		Where("tag.id IN (?)", bun.In(tagIDs)).
+		Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	tagsByID := make(map[string]*gtsmodel.Tag, len(tags))
+	for _, tag := range tags {
+		tagsByID[tag.ID] = tag
+	}
+
+	// keep the ranking from the first query
+	trending := make([]*db.TrendingTag, 0, len(counts))
+	for _, c := range counts {
+		tag, ok := tagsByID[c.TagID]
+		if !ok {
+			continue
+		}
+		trending = append(trending, &db.TrendingTag{
+			Tag:      tag,
+			Accounts: c.Accounts,
+			Uses:     c.Uses,
+		})
+	}
+
+	s.trendingTags.Set(cacheKey, trending)
+	return trending, nil
+}
+
+// randomStatusRun is how many consecutive statuses GetRandomPublicStatuses takes from each random point:
+// longer runs need fewer queries, but make for a sample that's less random.
+const randomStatusRun = 5
+
+func (s *statusDB) GetRandomPublicStatuses(ctx context.Context, limit int, since time.Time) ([]*gtsmodel.Status, db.Error) {
+	now := time.Now()
+	window := now.Sub(since)
+
+	// Ensure reasonable
+	if limit <= 0 || window <= 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	// status IDs are ULIDs, which sort by the time they were created,
+	// so a range scan on the primary key covers any window of time
+	lowestID, err := id.NewMinULIDFromTime(since)
+	if err != nil {
+		return nil, err
+	}
+
+	// sampleQ returns a query for the IDs of up to n eligible statuses, from startID upwards
+	sampleQ := func(startID string, n int) *bun.SelectQuery {
+		return s.conn.
+			NewSelect().
+			Model((*gtsmodel.Status)(nil)).
+			Column("status.id").
+			Where("status.id >= ?", startID).
+			Where("status.sensitive = ?", false).
+			WhereGroup(" AND ", whereEmptyOrNull("status.boost_of_id")).
+			WhereGroup(" AND ", s.whereVisibleTo("")).
+			WhereGroup(" AND ", whereNotExpired("status.expires_at", now)).
+			Order("status.id ASC").
+			Limit(n)
+	}
+
+	statusIDs := make([]string, 0, limit)
+	seen := make(map[string]struct{}, limit)
+
+	// runs can overlap, so allow for some extra jumps, but not so
+	// many that we keep going forever when there are few statuses
+	maxJumps := 2 * (limit/randomStatusRun + 1)
+	for jumps := 0; jumps < maxJumps && len(statusIDs) < limit; jumps++ {
+		pivot := since.Add(time.Duration(rand.Int63n(int64(window)))) //nolint:gosec
+		pivotID, err := id.NewMinULIDFromTime(pivot)
+		if err != nil {
+			return nil, err
+		}
+
+		n := limit - len(statusIDs)
+		if n > randomStatusRun {
+			n = randomStatusRun
+		}
+
+		runIDs := []string{}
+		if err := sampleQ(pivotID, n).Scan(ctx, &runIDs); err != nil {
+			return nil, s.conn.ProcessError(err)
+		}
+
+		if len(runIDs) < n {
+			// ran past the newest eligible status, so wrap around to the oldest
+			wrappedIDs := []string{}
+			q := sampleQ(lowestID, n-len(runIDs)).Where("status.id < ?", pivotID)
+			if err := q.Scan(ctx, &wrappedIDs); err != nil {
+				return nil, s.conn.ProcessError(err)
+			}
+			runIDs = append(runIDs, wrappedIDs...)
+		}
+
+		if len(runIDs) == 0 {
+			// nothing in the whole window
+			break
+		}
+
+		for _, statusID := range runIDs {
+			if _, ok := seen[statusID]; ok {
+				continue
+			}
+			seen[statusID] = struct{}{}
+			statusIDs = append(statusIDs, statusID)
+		}
+	}
+
+	if len(statusIDs) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	statuses := make([]*gtsmodel.Status, 0, len(statusIDs))
+	for _, statusID := range statusIDs {
+		status, err := s.GetStatusByID(ctx, statusID)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) GetStatusesByContentKey(ctx context.Context, contentKey string, since time.Time, limit int) ([]*gtsmodel.Status, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	statuses := make([]*gtsmodel.Status, 0, limit)
+
+	q := s.conn.
+		NewSelect().
+		Model(&statuses).
+		Where("status.content_key = ?", contentKey).
+		Where("status.created_at > ?", since).
+		Order("status.id DESC")
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statuses) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) GetExpiredStatuses(ctx context.Context, now time.Time, maxID string, limit int) ([]*gtsmodel.Status, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	statuses := make([]*gtsmodel.Status, 0, limit)
+
+	// this is served by statuses_expires_at_idx, which only
+	// covers statuses that expire, so say so explicitly
+	q := s.conn.
+		NewSelect().
+		Model(&statuses).
+		Where("? IS NOT NULL", bun.Ident("status.expires_at")).
+		Where("status.expires_at <= ?", now).
+		Order("status.id DESC")
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statuses) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) GetStatusesWithMissingAccount(ctx context.Context, maxID string, limit int) ([]*gtsmodel.Status, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	statuses := make([]*gtsmodel.Status, 0, limit)
+
+	accountQ := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Account)(nil)).
+		Column("account.id").
+		Where("account.id = status.account_id")
+
+	q := s.conn.
+		NewSelect().
+		Model(&statuses).
+		Where("NOT EXISTS (?)", accountQ).
+		Order("status.id DESC")
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(statuses) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return statuses, nil
+}
+
+func (s *statusDB) FixStatusesWithMissingAccount(ctx context.Context, refetch func(ctx context.Context, status *gtsmodel.Status) (*gtsmodel.Account, error)) (int, int, db.Error) {
+	if err := s.conn.CheckWritable(); err != nil {
+		return 0, 0, err
+	}
+
+	l := logrus.WithField("func", "FixStatusesWithMissingAccount")
+
+	var (
+		maxID     string
+		repointed int
+		deleted   int
+	)
+
+	for {
+		statuses, err := s.GetStatusesWithMissingAccount(ctx, maxID, 100)
+		if err != nil {
+			if err == db.ErrNoEntries {
+				return repointed, deleted, nil
+			}
+			return repointed, deleted, err
+		}
+
+		for _, status := range statuses {
+			if refetch != nil {
+				account, err := refetch(ctx, status)
+				if err == nil && account != nil {
+					if err := s.setStatusAccount(ctx, status, account); err != nil {
+						return repointed, deleted, err
+					}
+					repointed++
+					continue
+				}
+				l.Debugf("couldn't refetch account %s of status %s, deleting status: %v", status.AccountURI, status.ID, err)
+			}
+
+			if err := s.deleteStatusWithMissingAccount(ctx, status.ID); err != nil {
+				return repointed, deleted, err
+			}
+			deleted++
+		}
+
+		maxID = statuses[len(statuses)-1].ID
+	}
+}
+
+// setStatusAccount moves the given status over to the given account.
+func (s *statusDB) setStatusAccount(ctx context.Context, status *gtsmodel.Status, account *gtsmodel.Account) db.Error {
+	if _, err := s.conn.
+		NewUpdate().
+		Model(status).
+		Set("account_id = ?", account.ID).
+		Set("account_uri = ?", account.URI).
+		WherePK().
+		Exec(ctx); err != nil {
+		return s.conn.ProcessError(err)
+	}
+
+	// replace any cached copy that still points at the missing account
+	status.AccountID = account.ID
+	status.AccountURI = account.URI
+	s.cache.Put(status)
+	return nil
+}
+
+// deleteStatusWithMissingAccount deletes the given status and the rows that only exist because of it.
+func (s *statusDB) deleteStatusWithMissingAccount(ctx context.Context, statusID string) db.Error {
+	return s.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		for _, model := range []interface{}{
+			(*gtsmodel.StatusToTag)(nil),
+			(*gtsmodel.StatusToEmoji)(nil),
+			(*gtsmodel.Mention)(nil),
+		} {
+			if _, err := tx.
+				NewDelete().
+				Model(model).
+				Where("status_id = ?", statusID).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.
+			NewDelete().
+			Model((*gtsmodel.Status)(nil)).
+			Where("id = ?", statusID).
+			Exec(ctx)
+		return err
+	})
+}
+
+// statusContentKey returns a key for the text of the given status that ignores markup, case, whitespace and mentions,
+// so that statuses which differ only in those get the same key. Statuses without any text get an empty key.
+func statusContentKey(status *gtsmodel.Status) string {
+	text := status.Text
+	if text == "" {
+		// remote statuses only come with html content
+		text = html.UnescapeString(htmlTagRegex.ReplaceAllString(status.Content, " "))
+	}
+
+	words := []string{}
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if strings.HasPrefix(word, "@") {
+			// spam is often sent to different accounts
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *statusDB) GetStatusesMentions(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Mention, db.Error) {
+	mentionsByStatus := make(map[string][]*gtsmodel.Mention, len(statusIDs))
+	if len(statusIDs) == 0 {
+		return mentionsByStatus, nil
+	}
+
+	mentions := []*gtsmodel.Mention{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&mentions).
+		Relation("OriginAccount").
+		Relation("TargetAccount").
+		Where("mention.status_id IN (?)", bun.In(statusIDs)).
+		Order("mention.id ASC")
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	for _, m := range mentions {
+		mentionsByStatus[m.StatusID] = append(mentionsByStatus[m.StatusID], m)
+	}
+
+	return mentionsByStatus, nil
+}
+
+func (s *statusDB) GetStatusesTags(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Tag, db.Error) {
+	tagsByStatus := make(map[string][]*gtsmodel.Tag, len(statusIDs))
+	if len(statusIDs) == 0 {
+		return tagsByStatus, nil
+	}
+
+	statusToTags := []*gtsmodel.StatusToTag{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&statusToTags).
+		Relation("Tag").
+		Where("status_to_tag.status_id IN (?)", bun.In(statusIDs))
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	for _, st := range statusToTags {
+		if st.Tag != nil {
+			tagsByStatus[st.StatusID] = append(tagsByStatus[st.StatusID], st.Tag)
+		}
+	}
+
+	return tagsByStatus, nil
+}
+
+func (s *statusDB) GetStatusesEmojis(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Emoji, db.Error) {
+	emojisByStatus := make(map[string][]*gtsmodel.Emoji, len(statusIDs))
+	if len(statusIDs) == 0 {
+		return emojisByStatus, nil
+	}
+
+	statusToEmojis := []*gtsmodel.StatusToEmoji{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&statusToEmojis).
+		Relation("Emoji").
+		Where("status_to_emoji.status_id IN (?)", bun.In(statusIDs))
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	for _, se := range statusToEmojis {
+		if se.Emoji != nil {
+			emojisByStatus[se.StatusID] = append(emojisByStatus[se.StatusID], se.Emoji)
+		}
+	}
 
-func (s *statusDB) GetStatusByID(ctx context.Context, id string) (*gtsmodel.Status, db.Error) {
-	return s.getStatus(
-		ctx,
-		func() (*gtsmodel.Status, bool) {
-			return s.cache.GetByID(id)
-		},
-		func(status *gtsmodel.Status) error {
-			return s.newStatusQ(status).Where("status.id = ?", id).Scan(ctx)
-		},
-	)
+	return emojisByStatus, nil
 }
 
-func (s *statusDB) GetStatusByURI(ctx context.Context, uri string) (*gtsmodel.Status, db.Error) {
-	return s.getStatus(
-		ctx,
-		func() (*gtsmodel.Status, bool) {
-			return s.cache.GetByURI(uri)
-		},
-		func(status *gtsmodel.Status) error {
-			return s.newStatusQ(status).Where("LOWER(status.uri) = LOWER(?)", uri).Scan(ctx)
-		},
-	)
+// populateStatusesExtras sets the mentions, tags and emojis of all the given statuses, using
+// one query for each rather than one query per status, so it's suitable for whole timeline pages.
+func (s *statusDB) populateStatusesExtras(ctx context.Context, statuses []*gtsmodel.Status) db.Error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	statusIDs := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		statusIDs = append(statusIDs, status.ID)
+	}
+
+	mentions, err := s.GetStatusesMentions(ctx, statusIDs)
+	if err != nil {
+		return err
+	}
+
+	tags, err := s.GetStatusesTags(ctx, statusIDs)
+	if err != nil {
+		return err
+	}
+
+	emojis, err := s.GetStatusesEmojis(ctx, statusIDs)
+	if err != nil {
+		return err
+	}
+
+	// set non-nil slices even when there's nothing there,
+	// so nobody goes back to the db looking for more
+	for _, status := range statuses {
+		status.Mentions = append([]*gtsmodel.Mention{}, mentions[status.ID]...)
+		status.Tags = append([]*gtsmodel.Tag{}, tags[status.ID]...)
+		status.Emojis = append([]*gtsmodel.Emoji{}, emojis[status.ID]...)
+	}
+
+	return nil
 }
 
-func (s *statusDB) GetStatusByURL(ctx context.Context, url string) (*gtsmodel.Status, db.Error) {
-	return s.getStatus(
-		ctx,
-		func() (*gtsmodel.Status, bool) {
-			return s.cache.GetByURL(url)
-		},
-		func(status *gtsmodel.Status) error {
-			return s.newStatusQ(status).Where("LOWER(status.url) = LOWER(?)", url).Scan(ctx)
-		},
-	)
+func (s *statusDB) PutStatus(ctx context.Context, status *gtsmodel.Status) db.Error {
+	_, err := s.putStatus(ctx, status, false)
+	return err
 }
 
-func (s *statusDB) getStatus(ctx context.Context, cacheGet func() (*gtsmodel.Status, bool), dbQuery func(*gtsmodel.Status) error) (*gtsmodel.Status, db.Error) {
-	// Attempt to fetch cached status
-	status, cached := cacheGet()
+func (s *statusDB) PutStatusIfNew(ctx context.Context, status *gtsmodel.Status) (bool, db.Error) {
+	return s.putStatus(ctx, status, true)
+}
 
-	if !cached {
-		status = &gtsmodel.Status{}
+// putStatus inserts the given status along with its links to emojis, tags and attachments. If ifNew is true and a status
+// with the same URI already exists, nothing is inserted at all. It returns whether the status was inserted.
+func (s *statusDB) putStatus(ctx context.Context, status *gtsmodel.Status, ifNew bool) (bool, db.Error) {
+	if err := s.conn.CheckWritable(); err != nil {
+		return false, err
+	}
 
-		// Not cached! Perform database query
-		err := dbQuery(status)
-		if err != nil {
-			return nil, s.conn.ProcessError(err)
+	if !status.ExpiresAt.IsZero() {
+		createdAt := status.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
 		}
-
-		// If there is boosted, fetch from DB also
-		if status.BoostOfID != "" {
-			boostOf, err := s.GetStatusByID(ctx, status.BoostOfID)
-			if err == nil {
-				status.BoostOf = boostOf
-			}
+		if !status.ExpiresAt.After(createdAt) {
+			return false, db.ErrStatusExpiresTooSoon
 		}
+	}
 
-		// Place in the cache
-		s.cache.Put(status)
+	if err := checkReplyCycle(ctx, s.conn, status.ID, status.InReplyToID); err != nil {
+		return false, err
 	}
 
-	// Set the status author account
-	author, err := s.accounts.GetAccountByID(ctx, status.AccountID)
-	if err != nil {
-		return nil, err
+	// key the status by its text, so that
+	// near-identical statuses can be found
+	if status.ContentKey == "" && status.BoostOfID == "" {
+		status.ContentKey = statusContentKey(status)
 	}
 
-	// Return the prepared status
-	status.Account = author
-	return status, nil
-}
+	inserted := true
+	if err := s.conn.RunInTx(ctx, func(tx bun.Tx) error {
+		// insert the status itself first, so that if we already
+		// have it there's no need to touch anything else
+		if ifNew {
+			var err error
+			if inserted, err = insertIfNew(ctx, tx, status, "uri"); err != nil || !inserted {
+				return err
+			}
+		} else if _, err := tx.NewInsert().Model(status).Exec(ctx); err != nil {
+			return err
+		}
 
-func (s *statusDB) PutStatus(ctx context.Context, status *gtsmodel.Status) db.Error {
-	return s.conn.RunInTx(ctx, func(tx bun.Tx) error {
 		// create links between this status and any emojis it uses
 		for _, i := range status.EmojiIDs {
 			if _, err := tx.NewInsert().Model(&gtsmodel.StatusToEmoji{
@@ -168,16 +1191,35 @@ func (s *statusDB) PutStatus(ctx context.Context, status *gtsmodel.Status) db.Er
 			}
 		}
 
-		// Finally, insert the status
-		_, err := tx.NewInsert().Model(status).Exec(ctx)
-		return err
-	})
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	if inserted {
+		s.pubsub.publish(ctx, db.EventStatusCreated, status.ID)
+	}
+	return inserted, nil
 }
 
 func (s *statusDB) GetStatusParents(ctx context.Context, status *gtsmodel.Status, onlyDirect bool) ([]*gtsmodel.Status, db.Error) {
 	parents := []*gtsmodel.Status{}
 	s.statusParent(ctx, status, &parents, onlyDirect)
-	return parents, nil
+
+	visible, err := s.filterForViewer(ctx, parents)
+	if err != nil {
+		return nil, err
+	}
+
+	// parents go from nearest to furthest, so stop at the first one the viewer
+	// can't see, rather than showing them the thread above it with a gap in it
+	for i, parent := range visible {
+		if parent.ID != parents[i].ID {
+			return visible[:i], nil
+		}
+	}
+
+	return visible, nil
 }
 
 func (s *statusDB) statusParent(ctx context.Context, status *gtsmodel.Status, foundStatuses *[]*gtsmodel.Status, onlyDirect bool) {
@@ -197,6 +1239,195 @@ func (s *statusDB) statusParent(ctx context.Context, status *gtsmodel.Status, fo
 	s.statusParent(ctx, parentStatus, foundStatuses, false)
 }
 
+// threadMaxDepth is the furthest that GetThreadRoot will walk up a reply chain before giving up.
+const threadMaxDepth = 1000
+
+func (s *statusDB) GetThreadRoot(ctx context.Context, statusID string) (*gtsmodel.Status, db.Error) {
+	switch s.conn.Dialect().Name() {
+	case dialect.PG:
+		return s.getThreadRootRecursive(ctx, statusID)
+	case dialect.SQLite:
+		return s.getThreadRootIterative(ctx, statusID)
+	default:
+		return nil, fmt.Errorf("thread roots not supported for dialect %s", s.conn.Dialect().Name())
+	}
+}
+
+// getThreadRootRecursive finds the root of a thread in one query, by walking up the thread with a recursive CTE.
+func (s *statusDB) getThreadRootRecursive(ctx context.Context, statusID string) (*gtsmodel.Status, db.Error) {
+	var (
+		rootID      string
+		inReplyToID sql.NullString
+		depth       int
+	)
+
+	query, err := dialectQuery(s.conn, "thread_root")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.conn.QueryRowContext(ctx, query, statusID, threadMaxDepth).Scan(&rootID, &inReplyToID, &depth); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if depth >= threadMaxDepth && inReplyToID.String != "" {
+		return nil, fmt.Errorf("thread of status %s is more than %d statuses deep", statusID, threadMaxDepth)
+	}
+
+	return s.GetStatusByID(ctx, rootID)
+}
+
+// getThreadRootIterative finds the root of a thread by fetching one ancestor at a time,
+// which is cheap enough on sqlite since there's no network round trip per query.
+func (s *statusDB) getThreadRootIterative(ctx context.Context, statusID string) (*gtsmodel.Status, db.Error) {
+	status, err := s.GetStatusByID(ctx, statusID)
+	if err != nil {
+		return nil, err
+	}
+
+	for depth := 0; status.InReplyToID != ""; depth++ {
+		if depth >= threadMaxDepth {
+			return nil, fmt.Errorf("thread of status %s is more than %d statuses deep", statusID, threadMaxDepth)
+		}
+
+		parent, err := s.GetStatusByID(ctx, status.InReplyToID)
+		if err != nil {
+			if err == db.ErrNoEntries {
+				// we don't have the parent, so this is as high as we can go
+				break
+			}
+			return nil, err
+		}
+
+		status = parent
+	}
+
+	return status, nil
+}
+
+// checkReplyCycle walks up the reply chain from inReplyToID, returning ErrStatusReplyCycle if it gets back to the
+// status with the given ID. The walk gives up quietly after threadMaxDepth statuses, or if it runs into a cycle that
+// the status isn't part of: neither is made any worse by storing the status.
+func checkReplyCycle(ctx context.Context, conn *DBConn, statusID string, inReplyToID string) db.Error {
+	for depth := 0; inReplyToID != "" && depth < threadMaxDepth; depth++ {
+		if inReplyToID == statusID {
+			return db.ErrStatusReplyCycle
+		}
+
+		var next sql.NullString
+		if err := conn.
+			NewSelect().
+			Model((*gtsmodel.Status)(nil)).
+			Column("status.in_reply_to_id").
+			Where("status.id = ?", inReplyToID).
+			Scan(ctx, &next); err != nil {
+			if err == sql.ErrNoRows {
+				// the chain ends at a status we don't have
+				return nil
+			}
+			return conn.ProcessError(err)
+		}
+
+		inReplyToID = next.String
+	}
+
+	return nil
+}
+
+func (s *statusDB) BreakStatusReplyCycles(ctx context.Context) (int, db.Error) {
+	if err := s.conn.CheckWritable(); err != nil {
+		return 0, err
+	}
+
+	query, err := dialectQuery(s.conn, "reply_cycles")
+	if err != nil {
+		return 0, err
+	}
+
+	cycleIDs := []string{}
+	rows, err := s.conn.QueryContext(ctx, query, threadMaxDepth)
+	if err != nil {
+		return 0, s.conn.ProcessError(err)
+	}
+	defer rows.Close()
+
+	if err := s.conn.ScanRows(ctx, rows, &cycleIDs); err != nil {
+		return 0, s.conn.ProcessError(err)
+	}
+
+	if len(cycleIDs) == 0 {
+		return 0, nil
+	}
+
+	// every status of a cycle is in there, so fetch what they reply to
+	// straight from the database, since cached copies may be out of date
+	links := []struct {
+		ID          string
+		InReplyToID string
+	}{}
+	if err := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id", "status.in_reply_to_id").
+		Where("status.id IN (?)", bun.In(cycleIDs)).
+		Scan(ctx, &links); err != nil {
+		return 0, s.conn.ProcessError(err)
+	}
+
+	inReplyTo := make(map[string]string, len(links))
+	for _, link := range links {
+		inReplyTo[link.ID] = link.InReplyToID
+	}
+
+	broken := 0
+	for _, statusID := range cycleIDs {
+		if _, ok := inReplyTo[statusID]; !ok {
+			// already dealt with as part of another cycle
+			continue
+		}
+
+		// go round the cycle, picking out the newest status to break it at
+		newestID := statusID
+		for cycleID, ok := statusID, true; ok; {
+			if cycleID > newestID {
+				newestID = cycleID
+			}
+			next, found := inReplyTo[cycleID]
+			delete(inReplyTo, cycleID)
+			cycleID, ok = next, found
+		}
+
+		status := &gtsmodel.Status{}
+		if err := s.conn.RunInTx(ctx, func(tx bun.Tx) error {
+			if _, err := tx.
+				NewUpdate().
+				Model(status).
+				Set("in_reply_to_id = NULL").
+				Set("in_reply_to_account_id = NULL").
+				Where("status.id = ?", newestID).
+				Exec(ctx); err != nil {
+				return err
+			}
+
+			return tx.
+				NewSelect().
+				Model(status).
+				Where("status.id = ?", newestID).
+				Scan(ctx)
+		}); err != nil {
+			return broken, s.conn.ProcessError(err)
+		}
+
+		// replace any cached copy that still replies round in circles
+		s.cache.Put(status)
+
+		logrus.Warnf("broke reply cycle by clearing in_reply_to_id of status %s", newestID)
+		broken++
+	}
+
+	return broken, nil
+}
+
 func (s *statusDB) GetStatusChildren(ctx context.Context, status *gtsmodel.Status, onlyDirect bool, minID string) ([]*gtsmodel.Status, db.Error) {
 	foundStatuses := &list.List{}
 	foundStatuses.PushFront(status)
@@ -224,7 +1455,9 @@ func (s *statusDB) statusChildren(ctx context.Context, status *gtsmodel.Status,
 	q := s.conn.
 		NewSelect().
 		Model(&immediateChildren).
-		Where("in_reply_to_id = ?", status.ID)
+		Where("in_reply_to_id = ?", status.ID).
+		// replies the viewer can't see are left out along with their own replies
+		Apply(s.forViewer(ctx))
 	if minID != "" {
 		q = q.Where("status.id > ?", minID)
 	}
@@ -267,6 +1500,20 @@ func (s *statusDB) CountStatusFaves(ctx context.Context, status *gtsmodel.Status
 	return s.conn.NewSelect().Model(&gtsmodel.StatusFave{}).Where("status_id = ?", status.ID).Count(ctx)
 }
 
+func (s *statusDB) CountRecentStatuses(ctx context.Context, accountID string, since time.Time) (int, db.Error) {
+	count, err := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Where("status.account_id = ?", accountID).
+		Where("status.created_at >= ?", since).
+		Count(ctx)
+	if err != nil {
+		return 0, s.conn.ProcessError(err)
+	}
+
+	return count, nil
+}
+
 func (s *statusDB) IsStatusFavedBy(ctx context.Context, status *gtsmodel.Status, accountID string) (bool, db.Error) {
 	q := s.conn.
 		NewSelect().
@@ -330,3 +1577,142 @@ func (s *statusDB) GetStatusReblogs(ctx context.Context, status *gtsmodel.Status
 	}
 	return reblogs, nil
 }
+
+func (s *statusDB) GetStatusReblogAccounts(ctx context.Context, statusID string, requestingAccountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, db.Error) {
+	reblogs := []*gtsmodel.Status{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&reblogs).
+		Relation("Account").
+		Where("status.boost_of_id = ?", statusID).
+		WhereGroup(" AND ", s.whereVisibleTo(requestingAccountID)).
+		Order("status.id DESC")
+
+	if requestingAccountID != "" {
+		q = q.Where("NOT EXISTS (?)", s.blocksWithAccountQ(requestingAccountID, bun.Ident("status.account_id")))
+	}
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if sinceID != "" {
+		q = q.Where("status.id > ?", sinceID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, "", "", s.conn.ProcessError(err)
+	}
+
+	if len(reblogs) == 0 {
+		return nil, "", "", db.ErrNoEntries
+	}
+
+	accounts := make([]*gtsmodel.Account, 0, len(reblogs))
+	for _, r := range reblogs {
+		accounts = append(accounts, r.Account)
+	}
+
+	nextMaxID := reblogs[len(reblogs)-1].ID
+	prevMinID := reblogs[0].ID
+	return accounts, nextMaxID, prevMinID, nil
+}
+
+func (s *statusDB) GetStatusFavouritedAccounts(ctx context.Context, statusID string, requestingAccountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, db.Error) {
+	faves := []*gtsmodel.StatusFave{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&faves).
+		Relation("Account").
+		Where("status_fave.status_id = ?", statusID).
+		Order("status_fave.id DESC")
+
+	// don't tell anyone who faved a status they can't see
+	visibleQ := s.conn.
+		NewSelect().
+		Model((*gtsmodel.Status)(nil)).
+		Column("status.id").
+		Where("status.id = status_fave.status_id").
+		WhereGroup(" AND ", s.whereVisibleTo(requestingAccountID))
+
+	if requestingAccountID != "" {
+		visibleQ = visibleQ.Where("NOT EXISTS (?)", s.blocksWithAccountQ(requestingAccountID, bun.Ident("status.account_id")))
+		q = q.Where("NOT EXISTS (?)", s.blocksWithAccountQ(requestingAccountID, bun.Ident("status_fave.account_id")))
+	}
+
+	q = q.Where("EXISTS (?)", visibleQ)
+
+	if maxID != "" {
+		q = q.Where("status_fave.id < ?", maxID)
+	}
+
+	if sinceID != "" {
+		q = q.Where("status_fave.id > ?", sinceID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, "", "", s.conn.ProcessError(err)
+	}
+
+	if len(faves) == 0 {
+		return nil, "", "", db.ErrNoEntries
+	}
+
+	accounts := make([]*gtsmodel.Account, 0, len(faves))
+	for _, f := range faves {
+		accounts = append(accounts, f.Account)
+	}
+
+	nextMaxID := faves[len(faves)-1].ID
+	prevMinID := faves[0].ID
+	return accounts, nextMaxID, prevMinID, nil
+}
+
+func (s *statusDB) CreateStatusEdit(ctx context.Context, edit *gtsmodel.StatusEdit) db.Error {
+	if err := s.conn.CheckWritable(); err != nil {
+		return err
+	}
+
+	if _, err := s.conn.
+		NewInsert().
+		Model(edit).
+		Exec(ctx); err != nil {
+		return s.conn.ProcessError(err)
+	}
+
+	// the edit may well change who's mentioned
+	s.mentions.invalidateStatusMentions(edit.StatusID)
+	return nil
+}
+
+func (s *statusDB) GetStatusEdits(ctx context.Context, statusID string) ([]*gtsmodel.StatusEdit, db.Error) {
+	edits := []*gtsmodel.StatusEdit{}
+
+	q := s.conn.
+		NewSelect().
+		Model(&edits).
+		Where("status_id = ?", statusID).
+		// edit IDs are ULIDs so this is creation order too,
+		// even if several edits were made in the same instant
+		Order("created_at ASC", "id ASC")
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, s.conn.ProcessError(err)
+	}
+
+	if len(edits) == 0 {
+		return nil, db.ErrNoEntries
+	}
+
+	return edits, nil
+}
@@ -20,11 +20,17 @@ package bundb_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+	"github.com/superseriousbusiness/gotosocial/internal/id"
 )
 
 type StatusTestSuite struct {
@@ -132,6 +138,1317 @@ func (suite *StatusTestSuite) TestGetStatusChildren() {
 	}
 }
 
+func (suite *StatusTestSuite) TestGetStatusByMediaID() {
+	testAttachment := suite.testAttachments["admin_account_status_1_attachment_1"]
+
+	status, err := suite.db.GetStatusByMediaID(context.Background(), testAttachment.ID)
+	suite.NoError(err)
+	suite.Equal(testAttachment.StatusID, status.ID)
+	suite.Contains(status.AttachmentIDs, testAttachment.ID)
+	suite.NotNil(status.Account)
+}
+
+func (suite *StatusTestSuite) TestGetStatusByMediaIDUnattached() {
+	testAttachment := suite.testAttachments["local_account_1_unattached_1"]
+
+	status, err := suite.db.GetStatusByMediaID(context.Background(), testAttachment.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(status)
+}
+
+func (suite *StatusTestSuite) TestGetStatusByMediaIDNonexistent() {
+	status, err := suite.db.GetStatusByMediaID(context.Background(), "01FVBB0E7Z6V5KXK3H6BEJCTXR")
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(status)
+}
+
+func (suite *StatusTestSuite) TestGetLatestPublicStatuses() {
+	accountIDs := []string{}
+	for _, a := range suite.testAccounts {
+		accountIDs = append(accountIDs, a.ID)
+	}
+
+	// work out what we expect the newest public status of each account to be
+	expected := map[string]*gtsmodel.Status{}
+	for _, s := range suite.testStatuses {
+		if s.Visibility != gtsmodel.VisibilityPublic || s.BoostOfID != "" {
+			continue
+		}
+		if latest, ok := expected[s.AccountID]; !ok || s.CreatedAt.After(latest.CreatedAt) {
+			expected[s.AccountID] = s
+		}
+	}
+
+	statuses, err := suite.db.GetLatestPublicStatuses(context.Background(), accountIDs)
+	suite.NoError(err)
+	suite.Len(statuses, len(expected))
+
+	seen := map[string]bool{}
+	for i, s := range statuses {
+		// exactly one status per account
+		suite.False(seen[s.AccountID])
+		seen[s.AccountID] = true
+
+		// it should be the newest one
+		suite.Equal(expected[s.AccountID].ID, s.ID)
+		suite.NotNil(s.Account)
+
+		// and they should be sorted newest first
+		if i > 0 {
+			suite.False(s.CreatedAt.After(statuses[i-1].CreatedAt))
+		}
+	}
+}
+
+func (suite *StatusTestSuite) TestGetLatestPublicStatusesTiedCreatedAt() {
+	ctx := context.Background()
+	createdAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	// statuses with exactly the same creation time, in an order that's
+	// different from their IDs so insertion order can't be relied on
+	accountIDs := []string{}
+	for _, tied := range []struct {
+		account string
+		id      string
+	}{
+		{"local_account_2", "01FWA3M2Q1VY2PKTX3A5N5C0CB"},
+		{"admin_account", "01FWA3M2Q1VY2PKTX3A5N5C0CC"},
+		{"local_account_1", "01FWA3M2Q1VY2PKTX3A5N5C0CA"},
+	} {
+		account := suite.testAccounts[tied.account]
+		accountIDs = append(accountIDs, account.ID)
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  tied.id,
+			URI:                 account.URI + "/statuses/" + tied.id,
+			Text:                "status " + tied.id,
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			Federated:           true,
+			CreatedAt:           createdAt,
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	for i := 0; i < 3; i++ {
+		statuses, err := suite.db.GetLatestPublicStatuses(ctx, accountIDs)
+		suite.NoError(err)
+
+		ids := []string{}
+		for _, s := range statuses {
+			ids = append(ids, s.ID)
+		}
+		suite.Equal([]string{
+			"01FWA3M2Q1VY2PKTX3A5N5C0CC",
+			"01FWA3M2Q1VY2PKTX3A5N5C0CB",
+			"01FWA3M2Q1VY2PKTX3A5N5C0CA",
+		}, ids)
+	}
+}
+
+func (suite *StatusTestSuite) TestGetLatestPublicStatusesNoAccounts() {
+	statuses, err := suite.db.GetLatestPublicStatuses(context.Background(), []string{"01F8MH0BBE4FHXPH513MBVFHB0"})
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(statuses)
+}
+
+func (suite *StatusTestSuite) TestStatusEdits() {
+	testStatus := suite.testStatuses["local_account_1_status_1"]
+
+	// edit the status a few times, storing the previous version each time
+	versions := []string{"first version", "second version", "third version"}
+	for i, content := range versions {
+		editID, err := id.NewULID()
+		suite.NoError(err)
+
+		err = suite.db.CreateStatusEdit(context.Background(), &gtsmodel.StatusEdit{
+			ID:             editID,
+			CreatedAt:      time.Now().Add(time.Duration(i-len(versions)) * time.Minute),
+			StatusID:       testStatus.ID,
+			Content:        content,
+			ContentWarning: fmt.Sprintf("cw %d", i),
+			Text:           content,
+		})
+		suite.NoError(err)
+	}
+
+	edits, err := suite.db.GetStatusEdits(context.Background(), testStatus.ID)
+	suite.NoError(err)
+	suite.Len(edits, len(versions))
+
+	// edits should come back oldest first
+	for i, edit := range edits {
+		suite.Equal(testStatus.ID, edit.StatusID)
+		suite.Equal(versions[i], edit.Content)
+		suite.Equal(fmt.Sprintf("cw %d", i), edit.ContentWarning)
+		if i > 0 {
+			suite.True(edit.CreatedAt.After(edits[i-1].CreatedAt))
+		}
+	}
+}
+
+func (suite *StatusTestSuite) TestStatusEditsNeverEdited() {
+	testStatus := suite.testStatuses["local_account_1_status_2"]
+
+	edits, err := suite.db.GetStatusEdits(context.Background(), testStatus.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(edits)
+}
+
+func (suite *StatusTestSuite) TestRepairStatusJoins() {
+	testAccount := suite.testAccounts["local_account_1"]
+
+	// put the status directly, like an import would, so no joins are created
+	status := &gtsmodel.Status{
+		ID:                  "01FVS7M7GK0ZNRB1Z6GSMKWJ1H",
+		URI:                 "http://localhost:8080/users/the_mighty_zork/statuses/01FVS7M7GK0ZNRB1Z6GSMKWJ1H",
+		URL:                 "http://localhost:8080/@the_mighty_zork/statuses/01FVS7M7GK0ZNRB1Z6GSMKWJ1H",
+		Text:                "imported #welcome post with a brand new #tag and a :rainbow:",
+		Local:               true,
+		AccountURI:          testAccount.URI,
+		AccountID:           testAccount.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}
+	err := suite.db.Put(context.Background(), status)
+	suite.NoError(err)
+
+	tags, err := suite.db.GetStatusesTags(context.Background(), []string{status.ID})
+	suite.NoError(err)
+	suite.Empty(tags[status.ID])
+
+	created, err := suite.db.RepairStatusJoins(context.Background(), status)
+	suite.NoError(err)
+	suite.Equal(3, created)
+
+	tags, err = suite.db.GetStatusesTags(context.Background(), []string{status.ID})
+	suite.NoError(err)
+	tagNames := []string{}
+	for _, tag := range tags[status.ID] {
+		tagNames = append(tagNames, tag.Name)
+	}
+	suite.ElementsMatch([]string{"welcome", "tag"}, tagNames)
+
+	emojis, err := suite.db.GetStatusesEmojis(context.Background(), []string{status.ID})
+	suite.NoError(err)
+	suite.Len(emojis[status.ID], 1)
+	suite.Equal("rainbow", emojis[status.ID][0].Shortcode)
+
+	// repairing again should be a no-op
+	created, err = suite.db.RepairStatusJoins(context.Background(), status)
+	suite.NoError(err)
+	suite.Zero(created)
+}
+
+func (suite *StatusTestSuite) TestGetAccountMediaStatusesPublic() {
+	testAccount := suite.testAccounts["admin_account"]
+
+	statuses, count, err := suite.db.GetAccountMediaStatuses(context.Background(), testAccount.ID, "", "", 20)
+	suite.NoError(err)
+	suite.Equal(1, count)
+	suite.Len(statuses, 1)
+	suite.Equal(suite.testStatuses["admin_account_status_1"].ID, statuses[0].ID)
+}
+
+func (suite *StatusTestSuite) TestGetAccountMediaStatusesVisibility() {
+	testAccount := suite.testAccounts["local_account_1"]
+	requestingAccount := suite.testAccounts["admin_account"]
+
+	// the only media status of this account is mutuals only, so the owner can see it...
+	statuses, count, err := suite.db.GetAccountMediaStatuses(context.Background(), testAccount.ID, testAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Equal(1, count)
+	suite.Len(statuses, 1)
+	suite.NotEmpty(statuses[0].AttachmentIDs)
+
+	// ...but nobody else can
+	_, _, err = suite.db.GetAccountMediaStatuses(context.Background(), testAccount.ID, "", "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	_, _, err = suite.db.GetAccountMediaStatuses(context.Background(), testAccount.ID, requestingAccount.ID, "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// the test account already follows the requesting account, so following back makes them mutuals
+	err = suite.db.Put(context.Background(), &gtsmodel.Follow{
+		ID:              "01FVSCRBG9NDKTA5QC7A8RBV7N",
+		URI:             "http://localhost:8080/users/admin/follow/01FVSCRBG9NDKTA5QC7A8RBV7N",
+		AccountID:       requestingAccount.ID,
+		TargetAccountID: testAccount.ID,
+	})
+	suite.NoError(err)
+
+	statuses, count, err = suite.db.GetAccountMediaStatuses(context.Background(), testAccount.ID, requestingAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Equal(1, count)
+	suite.Len(statuses, 1)
+}
+
+func (suite *StatusTestSuite) TestGetAccountMediaStatusesAnonymous() {
+	ctx := context.Background()
+	remoteAccount := suite.testAccounts["remote_account_1"]
+	requestingAccount := suite.testAccounts["local_account_1"]
+
+	// give the remote account a media status of each visibility that a logged in account could see
+	for i, visibility := range []gtsmodel.Visibility{
+		gtsmodel.VisibilityPublic,
+		gtsmodel.VisibilityUnlocked,
+		gtsmodel.VisibilityFollowersOnly,
+	} {
+		statusID, err := id.NewULID()
+		suite.NoError(err)
+
+		attachment := &gtsmodel.MediaAttachment{}
+		*attachment = *suite.testAttachments["admin_account_status_1_attachment_1"]
+		attachment.ID, err = id.NewULID()
+		suite.NoError(err)
+		attachment.StatusID = statusID
+		attachment.AccountID = remoteAccount.ID
+		attachment.URL = fmt.Sprintf("http://fossbros-anonymous.io/media/%d.jpeg", i)
+		suite.NoError(suite.db.Put(ctx, attachment))
+
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 remoteAccount.URI + "/statuses/" + statusID,
+			Content:             fmt.Sprintf("<p>picture number %d</p>", i),
+			AttachmentIDs:       []string{attachment.ID},
+			AccountURI:          remoteAccount.URI,
+			AccountID:           remoteAccount.ID,
+			Visibility:          visibility,
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	// someone who isn't logged in only gets the public status
+	statuses, count, err := suite.db.GetAccountMediaStatuses(ctx, remoteAccount.ID, "", "", 20)
+	suite.NoError(err)
+	suite.Equal(1, count)
+	suite.Len(statuses, 1)
+	suite.Equal(gtsmodel.VisibilityPublic, statuses[0].Visibility)
+
+	// the requesting account follows the remote account
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Follow{
+		ID:              "01FW2B6ZC6Y5KX1MHZQ2N7P3RE",
+		URI:             requestingAccount.URI + "/follow/01FW2B6ZC6Y5KX1MHZQ2N7P3RE",
+		AccountID:       requestingAccount.ID,
+		TargetAccountID: remoteAccount.ID,
+	}))
+
+	_, count, err = suite.db.GetAccountMediaStatuses(ctx, remoteAccount.ID, requestingAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Equal(3, count)
+
+	// once the remote account's domain is blocked, someone who isn't logged in gets nothing
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.DomainBlock{
+		ID:                 "01FW2B7M4DQ1TQK0S1V5J8YH6C",
+		Domain:             remoteAccount.Domain,
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+	}))
+
+	_, _, err = suite.db.GetAccountMediaStatuses(ctx, remoteAccount.ID, "", "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestStatusVisibleToAnonymousDomainBlockSeverity() {
+	ctx := context.Background()
+	remoteAccount := suite.testAccounts["remote_account_1"]
+
+	statusID := "01FWMB3T6N9Q2C5H8K1R4V7Y0E"
+	suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+		ID:                  statusID,
+		URI:                 remoteAccount.URI + "/statuses/" + statusID,
+		Content:             "<p>hello from a domain with rejected media</p>",
+		AccountURI:          remoteAccount.URI,
+		AccountID:           remoteAccount.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}))
+
+	block := &gtsmodel.DomainBlock{
+		ID:                 "01FWMB48X2D5G8J1M4P7S0V3YB",
+		Domain:             remoteAccount.Domain,
+		CreatedByAccountID: suite.testAccounts["admin_account"].ID,
+		Severity:           gtsmodel.DomainBlockSeverityRejectMedia,
+	}
+	suite.NoError(suite.db.Put(ctx, block))
+
+	// only the media of the domain is rejected, so its public statuses are still visible
+	visible, err := suite.db.StatusVisibleTo(ctx, statusID, "")
+	suite.NoError(err)
+	suite.True(visible)
+
+	// but not once the domain is suspended
+	block.Severity = gtsmodel.DomainBlockSeveritySuspend
+	suite.NoError(suite.db.UpdateByPrimaryKey(ctx, block))
+
+	visible, err = suite.db.StatusVisibleTo(ctx, statusID, "")
+	suite.NoError(err)
+	suite.False(visible)
+}
+
+func (suite *StatusTestSuite) TestGetRepliesToAccount() {
+	testAccount := suite.testAccounts["local_account_1"]
+	followedAccount := suite.testAccounts["admin_account"]
+	blockedAccount := suite.testAccounts["local_account_2"]
+	repliedTo := suite.testStatuses["local_account_1_status_1"]
+
+	// a followers-only reply from an account the test account follows
+	followersOnlyReply := &gtsmodel.Status{
+		ID:                  "01FVSFH1Y4ZQ1ZV6GQGJ2C5Y0E",
+		URI:                 "http://localhost:8080/users/admin/statuses/01FVSFH1Y4ZQ1ZV6GQGJ2C5Y0E",
+		URL:                 "http://localhost:8080/@admin/statuses/01FVSFH1Y4ZQ1ZV6GQGJ2C5Y0E",
+		Content:             "only my followers can see this",
+		Local:               true,
+		AccountURI:          followedAccount.URI,
+		AccountID:           followedAccount.ID,
+		InReplyToID:         repliedTo.ID,
+		InReplyToAccountID:  testAccount.ID,
+		InReplyToURI:        repliedTo.URI,
+		Visibility:          gtsmodel.VisibilityFollowersOnly,
+		ActivityStreamsType: "Note",
+	}
+	err := suite.db.Put(context.Background(), followersOnlyReply)
+	suite.NoError(err)
+
+	statuses, err := suite.db.GetRepliesToAccount(context.Background(), testAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Equal([]string{
+		followersOnlyReply.ID,
+		suite.testStatuses["admin_account_status_3"].ID,
+		suite.testStatuses["local_account_2_status_5"].ID,
+	}, statusIDs(statuses))
+
+	// replies from blocked accounts shouldn't show up
+	err = suite.db.Put(context.Background(), &gtsmodel.Block{
+		ID:              "01FVSFPJ9D4W1ZK0S3XKHDCZ0F",
+		URI:             "http://localhost:8080/users/the_mighty_zork/blocks/01FVSFPJ9D4W1ZK0S3XKHDCZ0F",
+		AccountID:       testAccount.ID,
+		TargetAccountID: blockedAccount.ID,
+	})
+	suite.NoError(err)
+
+	statuses, err = suite.db.GetRepliesToAccount(context.Background(), testAccount.ID, "", 20)
+	suite.NoError(err)
+	suite.Equal([]string{
+		followersOnlyReply.ID,
+		suite.testStatuses["admin_account_status_3"].ID,
+	}, statusIDs(statuses))
+
+	// page through
+	statuses, err = suite.db.GetRepliesToAccount(context.Background(), testAccount.ID, followersOnlyReply.ID, 20)
+	suite.NoError(err)
+	suite.Equal([]string{suite.testStatuses["admin_account_status_3"].ID}, statusIDs(statuses))
+}
+
+func statusIDs(statuses []*gtsmodel.Status) []string {
+	ids := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		ids = append(ids, status.ID)
+	}
+	return ids
+}
+
+func (suite *StatusTestSuite) TestGetTrendingTags() {
+	// nothing recent has been tagged in the test models
+	_, err := suite.db.GetTrendingTags(context.Background(), time.Hour, 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	spam := &gtsmodel.Tag{ID: "01FVT3B1ZJ1CWWYF0GE4E6QNTX", Name: "spam", URL: "http://localhost:8080/tags/spam", Useable: true, Listable: true}
+	popular := &gtsmodel.Tag{ID: "01FVT3B8M0H6TQX34M4RGYSN6Y", Name: "popular", URL: "http://localhost:8080/tags/popular", Useable: true, Listable: true}
+	hidden := &gtsmodel.Tag{ID: "01FVT3BFF4X50DBVEGXBPJGAYH", Name: "hidden", URL: "http://localhost:8080/tags/hidden", Useable: true, Listable: false}
+	for _, tag := range []*gtsmodel.Tag{spam, popular, hidden} {
+		suite.NoError(suite.db.Put(context.Background(), tag))
+	}
+
+	putTagged := func(account *gtsmodel.Account, visibility gtsmodel.Visibility, tags ...*gtsmodel.Tag) {
+		statusID, err := id.NewRandomULID()
+		suite.NoError(err)
+
+		tagIDs := []string{}
+		for _, tag := range tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+
+		suite.NoError(suite.db.PutStatus(context.Background(), &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			TagIDs:              tagIDs,
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          visibility,
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	// lots of spam from one account...
+	for i := 0; i < 3; i++ {
+		putTagged(suite.testAccounts["local_account_1"], gtsmodel.VisibilityPublic, spam, hidden)
+	}
+
+	// ...doesn't beat a couple of different accounts
+	putTagged(suite.testAccounts["local_account_2"], gtsmodel.VisibilityPublic, popular)
+	putTagged(suite.testAccounts["admin_account"], gtsmodel.VisibilityPublic, popular)
+
+	// and non-public statuses don't count
+	putTagged(suite.testAccounts["remote_account_1"], gtsmodel.VisibilityFollowersOnly, spam)
+
+	trending, err := suite.db.GetTrendingTags(context.Background(), time.Hour, 10)
+	suite.NoError(err)
+	suite.Len(trending, 2)
+	suite.Equal(popular.ID, trending[0].Tag.ID)
+	suite.Equal(2, trending[0].Accounts)
+	suite.Equal(2, trending[0].Uses)
+	suite.Equal(spam.ID, trending[1].Tag.ID)
+	suite.Equal(1, trending[1].Accounts)
+	suite.Equal(3, trending[1].Uses)
+
+	// results are cached for a while
+	putTagged(suite.testAccounts["remote_account_1"], gtsmodel.VisibilityPublic, spam)
+	putTagged(suite.testAccounts["remote_account_2"], gtsmodel.VisibilityPublic, spam)
+
+	cached, err := suite.db.GetTrendingTags(context.Background(), time.Hour, 10)
+	suite.NoError(err)
+	suite.Equal(trending, cached)
+}
+
+func (suite *StatusTestSuite) TestGetStatusesByContentKey() {
+	putStatus := func(account *gtsmodel.Account, text string, content string, createdAt time.Time) *gtsmodel.Status {
+		statusID, err := id.NewULIDFromTime(createdAt)
+		suite.NoError(err)
+
+		status := &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			Text:                text,
+			Content:             content,
+			CreatedAt:           createdAt,
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}
+		suite.NoError(suite.db.PutStatus(context.Background(), status))
+		return status
+	}
+
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+
+	// the same spam from three different accounts, with different mentions, case and formatting
+	first := putStatus(suite.testAccounts["local_account_1"], "@the_mighty_zork Buy cheap   watches now!", "", now.Add(-3*time.Hour))
+	second := putStatus(suite.testAccounts["remote_account_1"], "", `<p><span class="h-card"><a href="http://example.org/@someone">@someone</a></span> buy cheap watches <b>now!</b></p>`, now.Add(-2*time.Hour))
+	third := putStatus(suite.testAccounts["remote_account_2"], "buy cheap watches now!", "", now.Add(-1*time.Hour))
+
+	// something else entirely
+	other := putStatus(suite.testAccounts["local_account_2"], "buy cheap watches later?", "", now.Add(-1*time.Hour))
+
+	// the same spam, but too long ago
+	putStatus(suite.testAccounts["admin_account"], "buy cheap watches now!", "", now.Add(-48*time.Hour))
+
+	// nothing to key on at all
+	empty := putStatus(suite.testAccounts["admin_account"], "@someone", "", now)
+
+	suite.NotEmpty(first.ContentKey)
+	suite.Equal(first.ContentKey, second.ContentKey)
+	suite.Equal(first.ContentKey, third.ContentKey)
+	suite.NotEqual(first.ContentKey, other.ContentKey)
+	suite.Empty(empty.ContentKey)
+
+	statuses, err := suite.db.GetStatusesByContentKey(context.Background(), first.ContentKey, since, 10)
+	suite.NoError(err)
+	if suite.Len(statuses, 3) {
+		suite.Equal(third.ID, statuses[0].ID)
+		suite.Equal(second.ID, statuses[1].ID)
+		suite.Equal(first.ID, statuses[2].ID)
+	}
+
+	statuses, err = suite.db.GetStatusesByContentKey(context.Background(), other.ContentKey, since, 10)
+	suite.NoError(err)
+	suite.Len(statuses, 1)
+
+	statuses, err = suite.db.GetStatusesByContentKey(context.Background(), strings.Repeat("0", 64), since, 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(statuses)
+}
+
+func (suite *StatusTestSuite) TestGetRandomPublicStatuses() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+	now := time.Now()
+	since := now.Add(-time.Hour)
+
+	// the test models were all created long ago
+	statuses, err := suite.db.GetRandomPublicStatuses(ctx, 5, since)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Nil(statuses)
+
+	putStatus := func(createdAt time.Time, visibility gtsmodel.Visibility, sensitive bool) *gtsmodel.Status {
+		statusID, err := id.NewULIDFromTime(createdAt)
+		suite.NoError(err)
+
+		status := &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			Text:                "hello " + statusID,
+			CreatedAt:           createdAt,
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          visibility,
+			Sensitive:           sensitive,
+			ActivityStreamsType: "Note",
+		}
+		suite.NoError(suite.db.PutStatus(ctx, status))
+		return status
+	}
+
+	eligible := map[string]bool{}
+	for i := 1; i <= 8; i++ {
+		status := putStatus(now.Add(-time.Duration(i)*5*time.Minute), gtsmodel.VisibilityPublic, false)
+		eligible[status.ID] = true
+	}
+
+	// none of these should ever be picked
+	putStatus(now.Add(-7*time.Minute), gtsmodel.VisibilityPublic, true)
+	putStatus(now.Add(-12*time.Minute), gtsmodel.VisibilityFollowersOnly, false)
+	putStatus(now.Add(-2*time.Hour), gtsmodel.VisibilityPublic, false)
+
+	statuses, err = suite.db.GetRandomPublicStatuses(ctx, 5, since)
+	suite.NoError(err)
+	suite.Len(statuses, 5)
+
+	seen := map[string]bool{}
+	for _, status := range statuses {
+		suite.True(eligible[status.ID], "status %s shouldn't have been picked", status.ID)
+		suite.False(seen[status.ID], "status %s was picked twice", status.ID)
+		seen[status.ID] = true
+	}
+
+	// asking for more than there are gives what there is
+	statuses, err = suite.db.GetRandomPublicStatuses(ctx, 20, since)
+	suite.NoError(err)
+	suite.GreaterOrEqual(len(statuses), 5)
+	suite.LessOrEqual(len(statuses), len(eligible))
+	for _, status := range statuses {
+		suite.True(eligible[status.ID], "status %s shouldn't have been picked", status.ID)
+	}
+}
+
+func (suite *StatusTestSuite) TestFixStatusesWithMissingAccount() {
+	ctx := context.Background()
+
+	// no statuses in the test models are missing their account
+	_, err := suite.db.GetStatusesWithMissingAccount(ctx, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	remoteAccount := suite.testAccounts["remote_account_1"]
+	for statusID, accountURI := range map[string]string{
+		"01FVXB3NWTJ3G1K8BZ4Q1VBMK2": remoteAccount.URI,
+		"01FVXB3WQ8HC4Y8FQZ3XKJ4M5R": "http://gone.example.org/users/ghost",
+	} {
+		suite.NoError(suite.db.Put(ctx, &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 accountURI + "/statuses/" + statusID,
+			AccountURI:          accountURI,
+			AccountID:           "01FVXB45MZGYZK9TT0JQ5Q7HNE",
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	statuses, err := suite.db.GetStatusesWithMissingAccount(ctx, "", 10)
+	suite.NoError(err)
+	if suite.Len(statuses, 2) {
+		suite.Equal("01FVXB3WQ8HC4Y8FQZ3XKJ4M5R", statuses[0].ID)
+		suite.Equal("01FVXB3NWTJ3G1K8BZ4Q1VBMK2", statuses[1].ID)
+	}
+
+	repointed, deleted, err := suite.db.FixStatusesWithMissingAccount(ctx, func(ctx context.Context, status *gtsmodel.Status) (*gtsmodel.Account, error) {
+		if status.AccountURI == remoteAccount.URI {
+			return remoteAccount, nil
+		}
+		return nil, errors.New("gone")
+	})
+	suite.NoError(err)
+	suite.Equal(1, repointed)
+	suite.Equal(1, deleted)
+
+	status, err := suite.db.GetStatusByID(ctx, "01FVXB3NWTJ3G1K8BZ4Q1VBMK2")
+	suite.NoError(err)
+	suite.Equal(remoteAccount.ID, status.AccountID)
+	suite.Equal(remoteAccount.ID, status.Account.ID)
+
+	_, err = suite.db.GetStatusByID(ctx, "01FVXB3WQ8HC4Y8FQZ3XKJ4M5R")
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	_, err = suite.db.GetStatusesWithMissingAccount(ctx, "", 10)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestPutStatusIfNew() {
+	ctx := context.Background()
+	account := suite.testAccounts["remote_account_1"]
+
+	events, unsubscribe := suite.db.Subscribe()
+	defer unsubscribe()
+
+	newStatus := func(statusID string) *gtsmodel.Status {
+		return &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/01FVXH2K2B8QW3MPPXJ4Y8ZE7C",
+			Text:                "hello, hello again",
+			TagIDs:              []string{suite.testTags["welcome"].ID},
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}
+	}
+
+	inserted, err := suite.db.PutStatusIfNew(ctx, newStatus("01FVXH2K2B8QW3MPPXJ4Y8ZE7C"))
+	suite.NoError(err)
+	suite.True(inserted)
+
+	// the same activity delivered again gets a fresh ID, but has the same URI
+	inserted, err = suite.db.PutStatusIfNew(ctx, newStatus("01FVXH3A8PDNJ5Q0Q3V4S1T9RG"))
+	suite.NoError(err)
+	suite.False(inserted)
+
+	status, err := suite.db.GetStatusByURI(ctx, account.URI+"/statuses/01FVXH2K2B8QW3MPPXJ4Y8ZE7C")
+	suite.NoError(err)
+	suite.Equal("01FVXH2K2B8QW3MPPXJ4Y8ZE7C", status.ID)
+
+	_, err = suite.db.GetStatusByID(ctx, "01FVXH3A8PDNJ5Q0Q3V4S1T9RG")
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// only the first insert counts as a new status
+	suite.Equal("01FVXH2K2B8QW3MPPXJ4Y8ZE7C", (<-events).ID)
+	select {
+	case event := <-events:
+		suite.Fail("unexpected event for duplicate status", event.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (suite *StatusTestSuite) TestGetThreadRootOfRoot() {
+	ctx := context.Background()
+	status := suite.testStatuses["local_account_1_status_1"]
+
+	root, err := suite.db.GetThreadRoot(ctx, status.ID)
+	suite.NoError(err)
+	suite.Equal(status.ID, root.ID)
+}
+
+func (suite *StatusTestSuite) TestGetThreadRootDeepChain() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+	root := suite.testStatuses["local_account_1_status_1"]
+
+	// build a long chain of replies, each replying to the one before
+	parentID := root.ID
+	for i := 0; i < 50; i++ {
+		statusID, err := id.NewULID()
+		suite.NoError(err)
+
+		reply := &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			Text:                fmt.Sprintf("reply number %d", i),
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			InReplyToID:         parentID,
+			InReplyToAccountID:  account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}
+		suite.NoError(suite.db.PutStatus(ctx, reply))
+		parentID = reply.ID
+	}
+
+	threadRoot, err := suite.db.GetThreadRoot(ctx, parentID)
+	suite.NoError(err)
+	suite.Equal(root.ID, threadRoot.ID)
+}
+
+func (suite *StatusTestSuite) TestGetThreadRootMissingParent() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+
+	// a reply to something we've never seen is the root as far as we know
+	orphan := &gtsmodel.Status{
+		ID:                  "01FW1X9T1CVY6M3J4WQ9Z2S8NA",
+		URI:                 account.URI + "/statuses/01FW1X9T1CVY6M3J4WQ9Z2S8NA",
+		Text:                "replying into the void",
+		AccountURI:          account.URI,
+		AccountID:           account.ID,
+		InReplyToID:         "01FW1XA3W7D9K0E5B6N8H4G2TR",
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}
+	suite.NoError(suite.db.PutStatus(ctx, orphan))
+
+	threadRoot, err := suite.db.GetThreadRoot(ctx, orphan.ID)
+	suite.NoError(err)
+	suite.Equal(orphan.ID, threadRoot.ID)
+}
+
+// putReplyChain stores a chain of n statuses, each replying to the one before, and returns them oldest first.
+func (suite *StatusTestSuite) putReplyChain(n int) []*gtsmodel.Status {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+
+	chain := []*gtsmodel.Status{}
+	for i := 0; i < n; i++ {
+		statusID, err := id.NewULIDFromTime(time.Now().Add(time.Duration(i-n) * time.Minute))
+		suite.NoError(err)
+
+		status := &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			Text:                fmt.Sprintf("chain link %d", i),
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Note",
+		}
+		if i > 0 {
+			status.InReplyToID = chain[i-1].ID
+			status.InReplyToAccountID = account.ID
+		}
+		suite.NoError(suite.db.PutStatus(ctx, status))
+		chain = append(chain, status)
+	}
+
+	return chain
+}
+
+func (suite *StatusTestSuite) TestPutStatusSelfReply() {
+	account := suite.testAccounts["local_account_1"]
+
+	status := &gtsmodel.Status{
+		ID:                  "01FWJ5T0X3B8K2N6Q9R1V4Y7ZD",
+		URI:                 account.URI + "/statuses/01FWJ5T0X3B8K2N6Q9R1V4Y7ZD",
+		Text:                "talking to myself",
+		AccountURI:          account.URI,
+		AccountID:           account.ID,
+		InReplyToID:         "01FWJ5T0X3B8K2N6Q9R1V4Y7ZD",
+		InReplyToAccountID:  account.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}
+
+	err := suite.db.PutStatus(context.Background(), status)
+	suite.ErrorIs(err, db.ErrStatusReplyCycle)
+
+	_, err = suite.db.GetStatusByID(context.Background(), status.ID)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestUpdateStatusReplyCycle() {
+	ctx := context.Background()
+	chain := suite.putReplyChain(4)
+
+	// the first status of the chain can't be made to reply to the last one
+	first := chain[0]
+	first.InReplyToID = chain[3].ID
+	suite.ErrorIs(suite.db.UpdateByPrimaryKey(ctx, first), db.ErrStatusReplyCycle)
+
+	stored, err := suite.db.GetStatusByID(ctx, first.ID)
+	suite.NoError(err)
+	suite.Empty(stored.InReplyToID)
+
+	// but replying to something outside of the chain is fine
+	first.InReplyToID = suite.testStatuses["local_account_1_status_1"].ID
+	suite.NoError(suite.db.UpdateByPrimaryKey(ctx, first))
+}
+
+func (suite *StatusTestSuite) TestBreakStatusReplyCycles() {
+	ctx := context.Background()
+
+	broken, err := suite.db.BreakStatusReplyCycles(ctx)
+	suite.NoError(err)
+	suite.Zero(broken)
+
+	// close a longer loop and a self reply behind the back of the checks
+	chain := suite.putReplyChain(3)
+	self := suite.putReplyChain(1)[0]
+	for statusID, inReplyToID := range map[string]string{
+		chain[0].ID: chain[2].ID,
+		self.ID:     self.ID,
+	} {
+		suite.NoError(suite.db.UpdateWhere(ctx, []db.Where{{Key: "id", Value: statusID}}, "in_reply_to_id", inReplyToID, &gtsmodel.Status{}))
+	}
+
+	broken, err = suite.db.BreakStatusReplyCycles(ctx)
+	suite.NoError(err)
+	suite.Equal(2, broken)
+
+	// the newest status of each cycle no longer replies to anything
+	for _, status := range []*gtsmodel.Status{chain[2], self} {
+		stored, err := suite.db.GetStatusByID(ctx, status.ID)
+		suite.NoError(err)
+		suite.Empty(stored.InReplyToID)
+	}
+
+	root, err := suite.db.GetThreadRoot(ctx, chain[0].ID)
+	suite.NoError(err)
+	suite.Equal(chain[2].ID, root.ID)
+
+	broken, err = suite.db.BreakStatusReplyCycles(ctx)
+	suite.NoError(err)
+	suite.Zero(broken)
+}
+
+func (suite *StatusTestSuite) TestGetStatusReblogAndFavouritedAccounts() {
+	ctx := context.Background()
+	status := suite.testStatuses["admin_account_status_1"]
+	faver := suite.testAccounts["local_account_1"]
+	requestingAccount := suite.testAccounts["local_account_2"]
+
+	// one public boost and one followers-only boost
+	for _, booster := range []struct {
+		account    *gtsmodel.Account
+		visibility gtsmodel.Visibility
+	}{
+		{suite.testAccounts["remote_account_1"], gtsmodel.VisibilityPublic},
+		{faver, gtsmodel.VisibilityFollowersOnly},
+	} {
+		boostID, err := id.NewULID()
+		suite.NoError(err)
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  boostID,
+			URI:                 booster.account.URI + "/statuses/" + boostID,
+			AccountURI:          booster.account.URI,
+			AccountID:           booster.account.ID,
+			BoostOfID:           status.ID,
+			BoostOfAccountID:    status.AccountID,
+			Visibility:          booster.visibility,
+			ActivityStreamsType: "Announce",
+		}))
+	}
+
+	boosters, nextMaxID, prevMinID, err := suite.db.GetStatusReblogAccounts(ctx, status.ID, "", "", "", 20)
+	suite.NoError(err)
+	suite.Len(boosters, 1)
+	suite.Equal(suite.testAccounts["remote_account_1"].ID, boosters[0].ID)
+	suite.Equal(nextMaxID, prevMinID)
+
+	faves, _, _, err := suite.db.GetStatusFavouritedAccounts(ctx, status.ID, requestingAccount.ID, "", "", 20)
+	suite.NoError(err)
+	suite.Len(faves, 1)
+	suite.Equal(faver.ID, faves[0].ID)
+
+	// the status is public, so logged out viewers can see who faved it too
+	faves, _, _, err = suite.db.GetStatusFavouritedAccounts(ctx, status.ID, "", "", "", 20)
+	suite.NoError(err)
+	suite.Len(faves, 1)
+	suite.Equal(faver.ID, faves[0].ID)
+
+	// after a block, the faver drops out of the requesting account's lists
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Block{
+		ID:              "01FW2RQ8H5D0X3W9CKM7E2SYBT",
+		URI:             faver.URI + "/blocks/01FW2RQ8H5D0X3W9CKM7E2SYBT",
+		AccountID:       faver.ID,
+		TargetAccountID: requestingAccount.ID,
+	}))
+
+	_, _, _, err = suite.db.GetStatusFavouritedAccounts(ctx, status.ID, requestingAccount.ID, "", "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestGetStatusFavouritedAccountsStatusNotVisible() {
+	ctx := context.Background()
+	author := suite.testAccounts["admin_account"]
+	faver := suite.testAccounts["local_account_1"]
+
+	statusID := "01FWQ2K7D4G9M3P6S1V8Y5B0EH"
+	suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+		ID:                  statusID,
+		URI:                 author.URI + "/statuses/" + statusID,
+		Content:             "<p>just for zork</p>",
+		AccountURI:          author.URI,
+		AccountID:           author.ID,
+		Visibility:          gtsmodel.VisibilityDirect,
+		MentionIDs:          []string{},
+		ActivityStreamsType: "Note",
+	}))
+
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.StatusFave{
+		ID:              "01FWQ2M3C8F1J6N9R2T5W8Z1D4",
+		AccountID:       faver.ID,
+		TargetAccountID: author.ID,
+		StatusID:        statusID,
+		URI:             faver.URI + "/faves/01FWQ2M3C8F1J6N9R2T5W8Z1D4",
+	}))
+
+	// the author can see who faved their status
+	faves, _, _, err := suite.db.GetStatusFavouritedAccounts(ctx, statusID, author.ID, "", "", 20)
+	suite.NoError(err)
+	suite.Len(faves, 1)
+	suite.Equal(faver.ID, faves[0].ID)
+
+	// but someone who can't see the status can't
+	_, _, _, err = suite.db.GetStatusFavouritedAccounts(ctx, statusID, suite.testAccounts["local_account_2"].ID, "", "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	// and neither can someone who's logged out
+	_, _, _, err = suite.db.GetStatusFavouritedAccounts(ctx, statusID, "", "", "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestGetStatusByURLNotURI() {
+	ctx := context.Background()
+	account := suite.testAccounts["remote_account_1"]
+
+	// for remote statuses, the web url and the activitypub uri are usually different
+	status := &gtsmodel.Status{
+		ID:                  "01FW5B9DX2E6S1PQ4Z8KAWT3NY",
+		URI:                 "http://fossbros-anonymous.io/users/foss_satan/statuses/01FW5B9DX2E6S1PQ4Z8KAWT3NY",
+		URL:                 "http://fossbros-anonymous.io/@foss_satan/01FW5B9DX2E6S1PQ4Z8KAWT3NY",
+		Content:             "<p>link me</p>",
+		AccountURI:          account.URI,
+		AccountID:           account.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: "Note",
+	}
+	suite.NoError(suite.db.PutStatus(ctx, status))
+
+	byURL, err := suite.db.GetStatusByURL(ctx, status.URL)
+	suite.NoError(err)
+	suite.Equal(status.ID, byURL.ID)
+
+	// urls are matched regardless of case
+	byURL, err = suite.db.GetStatusByURL(ctx, strings.ToUpper(status.URL))
+	suite.NoError(err)
+	suite.Equal(status.ID, byURL.ID)
+
+	byURI, err := suite.db.GetStatusByURI(ctx, status.URI)
+	suite.NoError(err)
+	suite.Equal(status.ID, byURI.ID)
+
+	// neither lookup should find the status by the other identifier
+	_, err = suite.db.GetStatusByURL(ctx, status.URI)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	_, err = suite.db.GetStatusByURI(ctx, status.URL)
+	suite.ErrorIs(err, db.ErrNoEntries)
+}
+
+func (suite *StatusTestSuite) TestGetStatusesMatchingFilter() {
+	// expectedIDs returns the IDs of the test statuses that match, highest ID first
+	expectedIDs := func(match func(*gtsmodel.Status) bool) []string {
+		ids := []string{}
+		for _, status := range suite.testStatuses {
+			if match(status) {
+				ids = append(ids, status.ID)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+		return ids
+	}
+
+	ids := func(statuses []*gtsmodel.Status) []string {
+		ids := []string{}
+		for _, status := range statuses {
+			ids = append(ids, status.ID)
+		}
+		return ids
+	}
+
+	// sensitive only
+	statuses, err := suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Sensitive: true}, "", 0)
+	suite.NoError(err)
+	suite.Equal(expectedIDs(func(s *gtsmodel.Status) bool { return s.Sensitive }), ids(statuses))
+
+	// keywords match case-insensitively in either the content or the content warning
+	matchesKeywords := func(s *gtsmodel.Status) bool {
+		return strings.Contains(strings.ToLower(s.Content+" "+s.ContentWarning), "puppies") ||
+			strings.Contains(strings.ToLower(s.Content+" "+s.ContentWarning), "introduction")
+	}
+	statuses, err = suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Keywords: []string{"PUPPIES", "Introduction"}}, "", 0)
+	suite.NoError(err)
+	suite.NotEmpty(statuses)
+	suite.Equal(expectedIDs(matchesKeywords), ids(statuses))
+
+	// both criteria have to match
+	statuses, err = suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Keywords: []string{"hello"}, Sensitive: true}, "", 0)
+	suite.NoError(err)
+	suite.Equal(expectedIDs(func(s *gtsmodel.Status) bool {
+		return s.Sensitive && strings.Contains(strings.ToLower(s.Content+" "+s.ContentWarning), "hello")
+	}), ids(statuses))
+
+	// paging
+	all, err := suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Sensitive: true}, "", 0)
+	suite.NoError(err)
+	statuses, err = suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Sensitive: true}, all[0].ID, 2)
+	suite.NoError(err)
+	suite.Equal(ids(all[1:3]), ids(statuses))
+
+	// wildcards in keywords are taken literally
+	statuses, err = suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{Keywords: []string{"%"}}, "", 0)
+	suite.ErrorIs(err, db.ErrNoEntries)
+	suite.Empty(statuses)
+
+	// an empty filter would match everything, which is never what's wanted
+	_, err = suite.db.GetStatusesMatchingFilter(context.Background(), db.FilterSpec{}, "", 0)
+	suite.Error(err)
+}
+
+func (suite *StatusTestSuite) TestStatusVisibleTo() {
+	ctx := context.Background()
+	admin := suite.testAccounts["admin_account"]
+	zork := suite.testAccounts["local_account_1"]
+	public := suite.testStatuses["admin_account_status_1"]
+	followersOnly := suite.testStatuses["local_account_1_status_5"]
+
+	for _, check := range []struct {
+		statusID  string
+		accountID string
+		visible   bool
+	}{
+		{public.ID, "", true},
+		{public.ID, zork.ID, true},
+		{followersOnly.ID, zork.ID, true},
+		{followersOnly.ID, admin.ID, false},
+		{followersOnly.ID, "", false},
+		{"01FW9C7XTMQ6W3K8R3N1Y5J0PA", zork.ID, false},
+	} {
+		visible, err := suite.db.StatusVisibleTo(ctx, check.statusID, check.accountID)
+		suite.NoError(err)
+		suite.Equal(check.visible, visible, "status %s for account %q", check.statusID, check.accountID)
+	}
+
+	// all at once, in the order asked for, with doubles collapsed
+	visible, err := suite.db.StatusesVisibleTo(ctx, []string{followersOnly.ID, "01FW9C7XTMQ6W3K8R3N1Y5J0PA", public.ID, followersOnly.ID}, zork.ID)
+	suite.NoError(err)
+	suite.Equal([]string{followersOnly.ID, public.ID}, visible)
+
+	// once the author blocks zork, even public statuses are off limits
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Block{
+		ID:              "01FW9C9J0K6B1BQY2M4E8N5T7H",
+		URI:             admin.URI + "/blocks/01FW9C9J0K6B1BQY2M4E8N5T7H",
+		AccountID:       admin.ID,
+		TargetAccountID: zork.ID,
+	}))
+
+	ok, err := suite.db.StatusVisibleTo(ctx, public.ID, zork.ID)
+	suite.NoError(err)
+	suite.False(ok)
+
+	ok, err = suite.db.StatusVisibleTo(ctx, public.ID, "")
+	suite.NoError(err)
+	suite.True(ok)
+}
+
+func (suite *StatusTestSuite) TestThreadForViewer() {
+	ctx := context.Background()
+	zork := suite.testAccounts["local_account_1"]
+	turtle := suite.testAccounts["local_account_2"]
+	admin := suite.testAccounts["admin_account"]
+	remote := suite.testAccounts["remote_account_1"]
+
+	reply := func(author *gtsmodel.Account, parent *gtsmodel.Status, visibility gtsmodel.Visibility) *gtsmodel.Status {
+		statusID, err := id.NewULID()
+		suite.NoError(err)
+
+		status := &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 author.URI + "/statuses/" + statusID,
+			Text:                "hello",
+			AccountURI:          author.URI,
+			AccountID:           author.ID,
+			Visibility:          visibility,
+			ActivityStreamsType: "Note",
+		}
+		if parent != nil {
+			status.InReplyToID = parent.ID
+			status.InReplyToURI = parent.URI
+			status.InReplyToAccountID = parent.AccountID
+		}
+		suite.NoError(suite.db.PutStatus(ctx, status))
+		return status
+	}
+
+	root := reply(zork, nil, gtsmodel.VisibilityPublic)
+	public := reply(turtle, root, gtsmodel.VisibilityPublic)
+	followersOnly := reply(turtle, root, gtsmodel.VisibilityFollowersOnly)
+	blocked := reply(remote, root, gtsmodel.VisibilityPublic)
+	muted := reply(zork, root, gtsmodel.VisibilityPublic)
+	underFollowersOnly := reply(turtle, followersOnly, gtsmodel.VisibilityPublic)
+
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Block{
+		ID:              "01FWA1R8N4Q2J6V0T3E5C7B9XK",
+		URI:             admin.URI + "/blocks/01FWA1R8N4Q2J6V0T3E5C7B9XK",
+		AccountID:       admin.ID,
+		TargetAccountID: remote.ID,
+	}))
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.StatusMute{
+		ID:              "01FWA1SFX0M3C8D2W6H4K9P7NB",
+		AccountID:       admin.ID,
+		TargetAccountID: zork.ID,
+		StatusID:        muted.ID,
+	}))
+
+	sorted := func(ids ...string) []string {
+		sort.Strings(ids)
+		return ids
+	}
+
+	childIDs := func(ctx context.Context) []string {
+		children, err := suite.db.GetStatusChildren(ctx, root, false, "")
+		suite.NoError(err)
+
+		ids := []string{}
+		for _, c := range children {
+			ids = append(ids, c.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// without a viewer, nothing is left out
+	suite.Equal(sorted(public.ID, followersOnly.ID, blocked.ID, muted.ID, underFollowersOnly.ID), childIDs(ctx))
+
+	// zork follows turtle, and hasn't blocked or muted anything
+	suite.Equal(sorted(public.ID, followersOnly.ID, blocked.ID, muted.ID, underFollowersOnly.ID), childIDs(db.WithViewer(ctx, db.ViewerContext{AccountID: zork.ID})))
+
+	// admin doesn't follow turtle, blocks remote, and muted one of the replies;
+	// replies to a reply admin can't see go too
+	adminCtx := db.WithViewer(ctx, db.ViewerContext{AccountID: admin.ID})
+	suite.Equal(sorted(public.ID), childIDs(adminCtx))
+
+	// logged out viewers only get public statuses
+	suite.Equal(sorted(public.ID, blocked.ID, muted.ID), childIDs(db.WithViewer(ctx, db.ViewerContext{})))
+
+	parents, err := suite.db.GetStatusParents(ctx, underFollowersOnly, false)
+	suite.NoError(err)
+	suite.Len(parents, 2)
+
+	// admin can't see the followers-only reply, so nothing above it is shown either
+	parents, err = suite.db.GetStatusParents(adminCtx, underFollowersOnly, false)
+	suite.NoError(err)
+	suite.Empty(parents)
+
+	parents, err = suite.db.GetStatusParents(adminCtx, public, false)
+	suite.NoError(err)
+	suite.Len(parents, 1)
+	suite.Equal(root.ID, parents[0].ID)
+}
+
+func (suite *StatusTestSuite) TestCountRecentStatuses() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_2"]
+	now := time.Now()
+	boundary := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	// take a baseline, since some test statuses may be recent
+	before, err := suite.db.CountRecentStatuses(ctx, account.ID, boundary)
+	suite.NoError(err)
+	beforeFuture, err := suite.db.CountRecentStatuses(ctx, account.ID, future)
+	suite.NoError(err)
+
+	// only the statuses on the near side of the boundary should be counted
+	for i, createdAt := range []time.Time{
+		boundary.Add(-time.Minute),
+		boundary.Add(time.Second),
+		now.Add(-30 * time.Minute),
+		now,
+	} {
+		statusID, err := id.NewULIDFromTime(createdAt)
+		suite.NoError(err)
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  statusID,
+			URI:                 account.URI + "/statuses/" + statusID,
+			Text:                fmt.Sprintf("status %d", i),
+			AccountURI:          account.URI,
+			AccountID:           account.ID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			CreatedAt:           createdAt,
+			ActivityStreamsType: "Note",
+		}))
+	}
+
+	count, err := suite.db.CountRecentStatuses(ctx, account.ID, boundary)
+	suite.NoError(err)
+	suite.Equal(before+3, count)
+
+	count, err = suite.db.CountRecentStatuses(ctx, account.ID, future)
+	suite.NoError(err)
+	suite.Equal(beforeFuture, count)
+}
+
+func (suite *StatusTestSuite) TestGetExpiredStatuses() {
+	ctx := context.Background()
+	now := time.Now()
+
+	// none of the test statuses expire
+	_, err := suite.db.GetExpiredStatuses(ctx, now, "", 20)
+	suite.ErrorIs(err, db.ErrNoEntries)
+
+	for _, expiry := range []struct {
+		status    string
+		expiresAt time.Time
+	}{
+		{"local_account_1_status_1", now.Add(-time.Hour)},
+		{"local_account_1_status_2", now},
+		{"local_account_2_status_1", now.Add(time.Hour)},
+	} {
+		err := suite.db.UpdateWhere(ctx, []db.Where{{Key: "id", Value: suite.testStatuses[expiry.status].ID}}, "expires_at", expiry.expiresAt, &gtsmodel.Status{})
+		suite.NoError(err)
+	}
+
+	expired, err := suite.db.GetExpiredStatuses(ctx, now, "", 20)
+	suite.NoError(err)
+	suite.Len(expired, 2)
+	for i, status := range expired {
+		suite.False(status.ExpiresAt.After(now))
+		suite.NotEqual(suite.testStatuses["local_account_2_status_1"].ID, status.ID)
+		if i > 0 {
+			suite.Less(status.ID, expired[i-1].ID)
+		}
+	}
+
+	// page past the first
+	paged, err := suite.db.GetExpiredStatuses(ctx, now, expired[0].ID, 20)
+	suite.NoError(err)
+	suite.Len(paged, 1)
+	suite.Equal(expired[1].ID, paged[0].ID)
+
+	// an hour later, the last one has expired too
+	expired, err = suite.db.GetExpiredStatuses(ctx, now.Add(time.Hour), "", 20)
+	suite.NoError(err)
+	suite.Len(expired, 3)
+}
+
+func (suite *StatusTestSuite) TestPutStatusExpiresTooSoon() {
+	ctx := context.Background()
+	account := suite.testAccounts["local_account_1"]
+	createdAt := time.Now().Add(-time.Hour)
+
+	statusID, err := id.NewULIDFromTime(createdAt)
+	suite.NoError(err)
+	status := &gtsmodel.Status{
+		ID:                  statusID,
+		URI:                 account.URI + "/statuses/" + statusID,
+		AccountURI:          account.URI,
+		AccountID:           account.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		CreatedAt:           createdAt,
+		ExpiresAt:           createdAt,
+		ActivityStreamsType: "Note",
+	}
+
+	err = suite.db.PutStatus(ctx, status)
+	suite.ErrorIs(err, db.ErrStatusExpiresTooSoon)
+
+	// expiring a moment after creation is fine, even if that's already past
+	status.ExpiresAt = createdAt.Add(time.Minute)
+	suite.NoError(suite.db.PutStatus(ctx, status))
+
+	stored, err := suite.db.GetStatusByID(ctx, statusID)
+	suite.NoError(err)
+	suite.WithinDuration(status.ExpiresAt, stored.ExpiresAt, time.Millisecond)
+}
+
 func TestStatusTestSuite(t *testing.T) {
 	suite.Run(t, new(StatusTestSuite))
 }
@@ -22,6 +22,7 @@ import (
 	"context"
 	"database/sql"
 	"sort"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
@@ -29,7 +30,8 @@ import (
 )
 
 type timelineDB struct {
-	conn *DBConn
+	conn     *DBConn
+	statuses *statusDB
 }
 
 func (t *timelineDB) GetHomeTimeline(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*gtsmodel.Status, db.Error) {
@@ -41,6 +43,9 @@ func (t *timelineDB) GetHomeTimeline(ctx context.Context, accountID string, maxI
 	// Make educated guess for slice size
 	statuses := make([]*gtsmodel.Status, 0, limit)
 
+	// the home timeline is always read on behalf of its owner
+	ctx = db.WithViewer(ctx, db.ViewerContext{AccountID: accountID})
+
 	q := t.conn.
 		NewSelect().
 		Model(&statuses)
@@ -48,6 +53,10 @@ func (t *timelineDB) GetHomeTimeline(ctx context.Context, accountID string, maxI
 	q = q.ColumnExpr("status.*").
 		// Find out who accountID follows.
 		Join("LEFT JOIN follows AS f ON f.target_account_id = status.account_id").
+		// Drop statuses that have expired but haven't been deleted yet.
+		WhereGroup(" AND ", whereNotExpired("status.expires_at", time.Now())).
+		// Drop statuses accountID can't see, or has blocked or muted.
+		Apply(t.statuses.forViewer(ctx)).
 		// Sort by highest ID (newest) to lowest ID (oldest)
 		Order("status.id DESC")
 
@@ -92,9 +101,52 @@ func (t *timelineDB) GetHomeTimeline(ctx context.Context, accountID string, maxI
 	if err := q.Scan(ctx); err != nil {
 		return nil, t.conn.ProcessError(err)
 	}
+
+	if err := t.statuses.populateStatusesExtras(ctx, statuses); err != nil {
+		return nil, err
+	}
+
 	return statuses, nil
 }
 
+func (t *timelineDB) GetHomeTimelineDeduped(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*db.TimelineEntry, string, string, db.Error) {
+	statuses, err := t.GetHomeTimeline(ctx, accountID, maxID, sinceID, minID, limit, local)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if len(statuses) == 0 {
+		return nil, "", "", db.ErrNoEntries
+	}
+
+	// Make educated guess for slice size
+	entries := make([]*db.TimelineEntry, 0, len(statuses))
+
+	// key entries on the boosted status, if any, so that
+	// all boosts of a status and the status itself collapse
+	byStatusID := make(map[string]*db.TimelineEntry, len(statuses))
+
+	for _, status := range statuses {
+		statusID := status.ID
+		if status.BoostOfID != "" {
+			statusID = status.BoostOfID
+		}
+
+		entry, ok := byStatusID[statusID]
+		if !ok {
+			entry = &db.TimelineEntry{Status: status}
+			byStatusID[statusID] = entry
+			entries = append(entries, entry)
+		}
+
+		if status.BoostOfID != "" {
+			entry.BoostedBy = append(entry.BoostedBy, status.AccountID)
+		}
+	}
+
+	return entries, statuses[len(statuses)-1].ID, statuses[0].ID, nil
+}
+
 func (t *timelineDB) GetPublicTimeline(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*gtsmodel.Status, db.Error) {
 	// Ensure reasonable
 	if limit < 0 {
@@ -111,6 +163,8 @@ func (t *timelineDB) GetPublicTimeline(ctx context.Context, accountID string, ma
 		WhereGroup(" AND ", whereEmptyOrNull("in_reply_to_id")).
 		WhereGroup(" AND ", whereEmptyOrNull("in_reply_to_uri")).
 		WhereGroup(" AND ", whereEmptyOrNull("boost_of_id")).
+		WhereGroup(" AND ", t.whereNotSilencedFor(accountID)).
+		WhereGroup(" AND ", whereNotExpired("status.expires_at", time.Now())).
 		Order("status.id DESC")
 
 	if maxID != "" {
@@ -136,6 +190,51 @@ func (t *timelineDB) GetPublicTimeline(ctx context.Context, accountID string, ma
 	if err := q.Scan(ctx); err != nil {
 		return nil, t.conn.ProcessError(err)
 	}
+
+	if err := t.statuses.populateStatusesExtras(ctx, statuses); err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+func (t *timelineDB) GetLocalTimeline(ctx context.Context, maxID string, limit int) ([]*gtsmodel.Status, db.Error) {
+	// Ensure reasonable
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Make educated guess for slice size
+	statuses := make([]*gtsmodel.Status, 0, limit)
+
+	// local, visibility and id are all covered by statuses_local_timeline_idx
+	q := t.conn.
+		NewSelect().
+		Model(&statuses).
+		Where("status.local = ?", true).
+		Where("status.visibility = ?", gtsmodel.VisibilityPublic).
+		WhereGroup(" AND ", whereEmptyOrNull("in_reply_to_id")).
+		WhereGroup(" AND ", whereEmptyOrNull("boost_of_id")).
+		WhereGroup(" AND ", t.whereNotSilencedFor("")).
+		WhereGroup(" AND ", whereNotExpired("status.expires_at", time.Now())).
+		Order("status.id DESC")
+
+	if maxID != "" {
+		q = q.Where("status.id < ?", maxID)
+	}
+
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, t.conn.ProcessError(err)
+	}
+
+	if err := t.statuses.populateStatusesExtras(ctx, statuses); err != nil {
+		return nil, err
+	}
+
 	return statuses, nil
 }
 
@@ -193,7 +292,8 @@ func (t *timelineDB) GetFavedTimeline(ctx context.Context, accountID string, max
 	err = t.conn.
 		NewSelect().
 		Model(&statuses).
-		Where("id IN (?)", bun.In(statusIDs)).
+		Where("status.id IN (?)", bun.In(statusIDs)).
+		WhereGroup(" AND ", whereNotExpired("status.expires_at", time.Now())).
 		Scan(ctx)
 	if err != nil {
 		return nil, "", "", t.conn.ProcessError(err)
@@ -210,7 +310,37 @@ func (t *timelineDB) GetFavedTimeline(ctx context.Context, accountID string, max
 		return statusesFavesMap[statusI.ID] < statusesFavesMap[statusJ.ID]
 	})
 
+	if err := t.statuses.populateStatusesExtras(ctx, statuses); err != nil {
+		return nil, "", "", err
+	}
+
 	nextMaxID := faves[len(faves)-1].ID
 	prevMinID := faves[0].ID
 	return statuses, nextMaxID, prevMinID, nil
 }
+
+// whereNotSilencedFor returns a where group func that drops statuses by silenced accounts,
+// unless they were posted by accountID itself or by an account that accountID follows.
+func (t *timelineDB) whereNotSilencedFor(accountID string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.WhereOr("NOT EXISTS (?)", t.conn.
+			NewSelect().
+			TableExpr("? AS ?", bun.Ident("accounts"), bun.Ident("silenced")).
+			Column("silenced.id").
+			Where("silenced.id = status.account_id").
+			Where("silenced.silenced_at IS NOT NULL"))
+
+		if accountID != "" {
+			q = q.
+				WhereOr("status.account_id = ?", accountID).
+				WhereOr("EXISTS (?)", t.conn.
+					NewSelect().
+					Model((*gtsmodel.Follow)(nil)).
+					Column("follow.id").
+					Where("follow.account_id = ?", accountID).
+					Where("follow.target_account_id = status.account_id"))
+		}
+
+		return q
+	}
+}
@@ -19,10 +19,17 @@
 package bundb_test
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/ap"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
 
 type TimelineTestSuite struct {
@@ -38,6 +45,308 @@ func (suite *TimelineTestSuite) TestGetPublicTimeline() {
 	suite.Len(s, 6)
 }
 
+func (suite *TimelineTestSuite) TestGetPublicTimelineSilenced() {
+	silenced := suite.testAccounts["local_account_2"]
+	follower := suite.testAccounts["local_account_1"]
+	stranger := suite.testAccounts["admin_account"]
+
+	countBy := func(statuses []*gtsmodel.Status, accountID string) int {
+		count := 0
+		for _, s := range statuses {
+			if s.AccountID == accountID {
+				count++
+			}
+		}
+		return count
+	}
+
+	before, err := suite.db.GetPublicTimeline(context.Background(), stranger.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.NotZero(countBy(before, silenced.ID))
+
+	account, err := suite.db.SilenceAccount(context.Background(), silenced.ID, suite.testAccounts["admin_account"].ID)
+	suite.NoError(err)
+	suite.False(account.SilencedAt.IsZero())
+
+	// strangers and logged-out visitors don't see the silenced account any more
+	s, err := suite.db.GetPublicTimeline(context.Background(), stranger.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.Zero(countBy(s, silenced.ID))
+	suite.Len(s, len(before)-countBy(before, silenced.ID))
+
+	s, err = suite.db.GetPublicTimeline(context.Background(), "", "", "", "", 20, false)
+	suite.NoError(err)
+	suite.Zero(countBy(s, silenced.ID))
+
+	// but followers and the account itself still do
+	s, err = suite.db.GetPublicTimeline(context.Background(), follower.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.NotZero(countBy(s, silenced.ID))
+
+	s, err = suite.db.GetPublicTimeline(context.Background(), silenced.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.NotZero(countBy(s, silenced.ID))
+
+	s, err = suite.db.GetHomeTimeline(context.Background(), follower.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.NotZero(countBy(s, silenced.ID))
+
+	account, err = suite.db.UnsilenceAccount(context.Background(), silenced.ID, suite.testAccounts["admin_account"].ID)
+	suite.NoError(err)
+	suite.True(account.SilencedAt.IsZero())
+
+	s, err = suite.db.GetPublicTimeline(context.Background(), stranger.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.Len(s, len(before))
+}
+
+func (suite *TimelineTestSuite) TestTimelinesLeaveOutExpiredStatuses() {
+	ctx := context.Background()
+	viewingAccount := suite.testAccounts["local_account_1"]
+	expired := suite.testStatuses["admin_account_status_1"]
+
+	containsExpired := func(statuses []*gtsmodel.Status) bool {
+		for _, s := range statuses {
+			if s.ID == expired.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	public, err := suite.db.GetPublicTimeline(ctx, viewingAccount.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.True(containsExpired(public))
+
+	// expire the status without deleting it, as if the reaper hasn't got to it yet
+	err = suite.db.UpdateWhere(ctx, []db.Where{{Key: "id", Value: expired.ID}}, "expires_at", time.Now().Add(-time.Minute), &gtsmodel.Status{})
+	suite.NoError(err)
+
+	s, err := suite.db.GetPublicTimeline(ctx, viewingAccount.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.False(containsExpired(s))
+	suite.Len(s, len(public)-1)
+
+	s, err = suite.db.GetLocalTimeline(ctx, "", 20)
+	suite.NoError(err)
+	suite.False(containsExpired(s))
+
+	s, err = suite.db.GetHomeTimeline(ctx, viewingAccount.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.False(containsExpired(s))
+
+	// a status that expires later is still shown
+	err = suite.db.UpdateWhere(ctx, []db.Where{{Key: "id", Value: expired.ID}}, "expires_at", time.Now().Add(time.Hour), &gtsmodel.Status{})
+	suite.NoError(err)
+
+	s, err = suite.db.GetPublicTimeline(ctx, viewingAccount.ID, "", "", "", 20, false)
+	suite.NoError(err)
+	suite.True(containsExpired(s))
+}
+
+func (suite *TimelineTestSuite) TestGetHomeTimelineBlockedAndMuted() {
+	ctx := context.Background()
+	zork := suite.testAccounts["local_account_1"]
+	admin := suite.testAccounts["admin_account"]
+	muted := suite.testStatuses["local_account_2_status_1"]
+
+	countBy := func(statuses []*gtsmodel.Status, accountID string) int {
+		count := 0
+		for _, s := range statuses {
+			if s.AccountID == accountID {
+				count++
+			}
+		}
+		return count
+	}
+
+	contains := func(statuses []*gtsmodel.Status, statusID string) bool {
+		for _, s := range statuses {
+			if s.ID == statusID {
+				return true
+			}
+		}
+		return false
+	}
+
+	before, err := suite.db.GetHomeTimeline(ctx, zork.ID, "", "", "", 50, false)
+	suite.NoError(err)
+	suite.NotZero(countBy(before, admin.ID))
+	suite.True(contains(before, muted.ID))
+
+	// zork mutes one of turtle's statuses, and admin blocks zork
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.StatusMute{
+		ID:              "01FWA3B2Q5M8Z1C4V7N0X3K6JD",
+		AccountID:       zork.ID,
+		TargetAccountID: muted.AccountID,
+		StatusID:        muted.ID,
+	}))
+	suite.NoError(suite.db.Put(ctx, &gtsmodel.Block{
+		ID:              "01FWA3BWT9H2E5R8Y1U4I7O0PS",
+		URI:             admin.URI + "/blocks/01FWA3BWT9H2E5R8Y1U4I7O0PS",
+		AccountID:       admin.ID,
+		TargetAccountID: zork.ID,
+	}))
+
+	after, err := suite.db.GetHomeTimeline(ctx, zork.ID, "", "", "", 50, false)
+	suite.NoError(err)
+	suite.Zero(countBy(after, admin.ID))
+	suite.False(contains(after, muted.ID))
+	suite.Len(after, len(before)-countBy(before, admin.ID)-1)
+}
+
+func (suite *TimelineTestSuite) TestGetHomeTimelineDeduped() {
+	ctx := context.Background()
+	zork := suite.testAccounts["local_account_1"]
+	original := suite.testStatuses["local_account_1_status_1"]
+
+	// zork follows both admin and turtle, who both boost one of zork's own statuses
+	boosters := []*gtsmodel.Account{
+		suite.testAccounts["admin_account"],
+		suite.testAccounts["local_account_2"],
+	}
+	boostIDs := []string{"01FWH2V4Z7RA1SMJ8YB3DE5KXC", "01FWH2VD6P9N3GQT0CJ4WZ8FHM"}
+	for i, booster := range boosters {
+		suite.NoError(suite.db.PutStatus(ctx, &gtsmodel.Status{
+			ID:                  boostIDs[i],
+			URI:                 booster.URI + "/statuses/" + boostIDs[i],
+			AccountURI:          booster.URI,
+			AccountID:           booster.ID,
+			BoostOfID:           original.ID,
+			BoostOfAccountID:    original.AccountID,
+			Visibility:          gtsmodel.VisibilityPublic,
+			ActivityStreamsType: "Announce",
+		}))
+	}
+
+	statuses, err := suite.db.GetHomeTimeline(ctx, zork.ID, "", "", "", 50, false)
+	suite.NoError(err)
+
+	entries, lowestID, highestID, err := suite.db.GetHomeTimelineDeduped(ctx, zork.ID, "", "", "", 50, false)
+	suite.NoError(err)
+	// both boosts and the status itself collapse into one entry
+	suite.Len(entries, len(statuses)-2)
+	suite.Equal(statuses[len(statuses)-1].ID, lowestID)
+	suite.Equal(statuses[0].ID, highestID)
+
+	boosts := []*db.TimelineEntry{}
+	for _, entry := range entries {
+		if entry.Status.BoostOfID == original.ID || entry.Status.ID == original.ID {
+			boosts = append(boosts, entry)
+		}
+	}
+
+	// turtle's boost is newer, so it comes first on the page
+	if suite.Len(boosts, 1) {
+		suite.Equal(boostIDs[1], boosts[0].Status.ID)
+		suite.Equal([]string{boosters[1].ID, boosters[0].ID}, boosts[0].BoostedBy)
+	}
+}
+
+func (suite *TimelineTestSuite) TestGetLocalTimeline() {
+	ctx := context.Background()
+	remoteAccount := suite.testAccounts["remote_account_1"]
+
+	// a public, top-level status by a remote account shouldn't show up
+	remoteStatus := &gtsmodel.Status{
+		ID:                  "01FW8J1ZJ3N4HTC9QAWGBXKZ2R",
+		URI:                 "http://fossbros-anonymous.io/users/foss_satan/statuses/01FW8J1ZJ3N4HTC9QAWGBXKZ2R",
+		URL:                 "http://fossbros-anonymous.io/@foss_satan/statuses/01FW8J1ZJ3N4HTC9QAWGBXKZ2R",
+		Content:             "newer than everything local",
+		Local:               false,
+		AccountURI:          remoteAccount.URI,
+		AccountID:           remoteAccount.ID,
+		Visibility:          gtsmodel.VisibilityPublic,
+		ActivityStreamsType: ap.ObjectNote,
+		Federated:           true,
+		Boostable:           true,
+		Replyable:           true,
+		Likeable:            true,
+	}
+	suite.NoError(suite.db.Put(ctx, remoteStatus))
+
+	s, err := suite.db.GetLocalTimeline(ctx, "", 20)
+	suite.NoError(err)
+	suite.Len(s, 6)
+
+	for i, status := range s {
+		suite.True(status.Local)
+		suite.Equal(gtsmodel.VisibilityPublic, status.Visibility)
+		suite.Empty(status.InReplyToID)
+		suite.NotEqual(remoteStatus.ID, status.ID)
+		if i > 0 {
+			suite.Less(status.ID, s[i-1].ID)
+		}
+	}
+
+	// replies by local accounts are left out too
+	for _, reply := range []string{"admin_account_status_3", "local_account_2_status_5"} {
+		for _, status := range s {
+			suite.NotEqual(suite.testStatuses[reply].ID, status.ID)
+		}
+	}
+
+	// page past the newest
+	paged, err := suite.db.GetLocalTimeline(ctx, s[0].ID, 20)
+	suite.NoError(err)
+	suite.Len(paged, 5)
+}
+
+// countQueries returns how many queries were run against the db by fn,
+// going by what the trace-level query hook logs for the given context.
+func (suite *TimelineTestSuite) countQueries(fn func(ctx context.Context)) int {
+	out := logrus.StandardLogger().Out
+	defer logrus.SetOutput(out)
+
+	buf := &bytes.Buffer{}
+	logrus.SetOutput(buf)
+
+	fn(db.WithSubsystem(context.Background(), "querycounter"))
+
+	return strings.Count(buf.String(), "subsystem=querycounter")
+}
+
+func (suite *TimelineTestSuite) TestGetPublicTimelineQueryCount() {
+	viewingAccount := suite.testAccounts["local_account_1"]
+
+	suite.checkQueryCount(6, func(ctx context.Context, limit int) ([]*gtsmodel.Status, error) {
+		return suite.db.GetPublicTimeline(ctx, viewingAccount.ID, "", "", "", limit, false)
+	})
+}
+
+func (suite *TimelineTestSuite) TestGetHomeTimelineQueryCount() {
+	viewingAccount := suite.testAccounts["local_account_2"]
+
+	suite.checkQueryCount(5, func(ctx context.Context, limit int) ([]*gtsmodel.Status, error) {
+		return suite.db.GetHomeTimeline(ctx, viewingAccount.ID, "", "", "", limit, false)
+	})
+}
+
+// checkQueryCount checks that getting a page of the given size from a timeline
+// doesn't take any more queries than getting a page of just one status.
+func (suite *TimelineTestSuite) checkQueryCount(size int, getTimeline func(ctx context.Context, limit int) ([]*gtsmodel.Status, error)) {
+	getPage := func(limit int) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			s, err := getTimeline(ctx, limit)
+			suite.NoError(err)
+			suite.Len(s, limit)
+
+			// extras should be populated without having to go back to the db
+			for _, status := range s {
+				suite.NotNil(status.Mentions)
+				suite.Len(status.Mentions, len(status.MentionIDs))
+				suite.NotNil(status.Tags)
+				suite.NotNil(status.Emojis)
+			}
+		}
+	}
+
+	small := suite.countQueries(getPage(1))
+	large := suite.countQueries(getPage(size))
+	suite.NotZero(small)
+	suite.Equal(small, large)
+}
+
 func TestTimelineTestSuite(t *testing.T) {
 	suite.Run(t, new(TimelineTestSuite))
 }
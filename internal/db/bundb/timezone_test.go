@@ -0,0 +1,116 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/config"
+)
+
+type TimezoneTestSuite struct {
+	suite.Suite
+	restoreConfig func()
+	local         *time.Location
+}
+
+func (suite *TimezoneTestSuite) SetupTest() {
+	suite.restoreConfig = saveConfig(
+		config.Keys.DbType,
+		config.Keys.DbAddress,
+		config.Keys.DbPort,
+		config.Keys.DbUser,
+		config.Keys.DbPassword,
+		config.Keys.DbDatabase,
+		config.Keys.DbTimezone,
+	)
+	suite.local = time.Local
+}
+
+func (suite *TimezoneTestSuite) TearDownTest() {
+	suite.restoreConfig()
+	time.Local = suite.local
+}
+
+func (suite *TimezoneTestSuite) setPostgres() {
+	viper.Set(config.Keys.DbType, "postgres")
+	viper.Set(config.Keys.DbAddress, "localhost")
+	viper.Set(config.Keys.DbPort, 5432)
+	viper.Set(config.Keys.DbUser, "postgres")
+	viper.Set(config.Keys.DbPassword, "postgres")
+	viper.Set(config.Keys.DbDatabase, "postgres")
+}
+
+func (suite *TimezoneTestSuite) TestPGOptionsTimezone() {
+	suite.setPostgres()
+	viper.Set(config.Keys.DbTimezone, "UTC")
+
+	opts, err := deriveBunDBPGOptions()
+	suite.NoError(err)
+	suite.Equal("UTC", opts.RuntimeParams["timezone"])
+}
+
+func (suite *TimezoneTestSuite) TestPGOptionsNoTimezone() {
+	suite.setPostgres()
+	viper.Set(config.Keys.DbTimezone, "")
+
+	opts, err := deriveBunDBPGOptions()
+	suite.NoError(err)
+	_, ok := opts.RuntimeParams["timezone"]
+	suite.False(ok)
+}
+
+func (suite *TimezoneTestSuite) TestDateTruncationIgnoresLocalTimezone() {
+	viper.Set(config.Keys.DbType, "sqlite")
+	viper.Set(config.Keys.DbAddress, ":memory:")
+
+	conn, err := sqliteConn(context.Background())
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	defer conn.Close()
+
+	// late in the evening UTC, which is already tomorrow
+	// or still earlier today in plenty of other places
+	instant := time.Date(2022, 2, 8, 23, 30, 0, 0, time.UTC)
+
+	for _, loc := range []*time.Location{
+		time.UTC,
+		time.FixedZone("UTC+14", 14*60*60),
+		time.FixedZone("UTC-12", -12*60*60),
+	} {
+		time.Local = loc
+
+		var day string
+		err := conn.
+			NewSelect().
+			ColumnExpr("date(?)", instant.In(loc)).
+			Scan(context.Background(), &day)
+		suite.NoError(err)
+		suite.Equal("2022-02-08", day, "unexpected date with local timezone %s", loc)
+	}
+}
+
+func TestTimezoneTestSuite(t *testing.T) {
+	suite.Run(t, new(TimezoneTestSuite))
+}
@@ -21,18 +21,33 @@ package bundb
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/uptrace/bun"
 )
 
-func newDebugQueryHook() bun.QueryHook {
-	return &debugQueryHook{}
+// queryLogSummaryInterval is how often to log how many queries were left out by sampling.
+const queryLogSummaryInterval = time.Minute
+
+// newDebugQueryHook returns a query hook that logs the given fraction of queries, from 0 to 1.
+func newDebugQueryHook(sampleRate float64) bun.QueryHook {
+	return &debugQueryHook{
+		sampleRate:  sampleRate,
+		lastSummary: time.Now(),
+	}
 }
 
 // debugQueryHook implements bun.QueryHook
 type debugQueryHook struct {
+	sampleRate float64
+
+	mu          sync.Mutex
+	credit      float64   // credit is the sample rate accumulated since the last logged query
+	suppressed  int       // suppressed is how many queries were left out since the last summary
+	lastSummary time.Time // lastSummary is when suppressed queries were last summarized
 }
 
 func (q *debugQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
@@ -41,13 +56,18 @@ func (q *debugQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) con
 }
 
 // AfterQuery logs the time taken to query, the operation (select, update, etc), and the query itself as translated by bun.
-func (q *debugQueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+// If the query context was labelled with a subsystem, that's logged too so queries can be traced back to where they came from.
+func (q *debugQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
 	dur := time.Since(event.StartTime).Round(time.Microsecond)
 	l := logrus.WithFields(logrus.Fields{
 		"duration":  dur,
 		"operation": event.Operation(),
 	})
 
+	if subsystem := db.SubsystemFromContext(ctx); subsystem != "" {
+		l = l.WithField("subsystem", subsystem)
+	}
+
 	if event.Err != nil && event.Err != sql.ErrNoRows {
 		// if there's an error the it'll be handled in the application logic,
 		// but we can still debug log it here alongside the query
@@ -56,5 +76,38 @@ func (q *debugQueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
 		return
 	}
 
+	if !q.sample() {
+		return
+	}
+
 	l.Tracef("[%s] %s", dur, event.Operation())
 }
+
+// sample returns whether the current query should be logged, and logs a summary of
+// how many were left out if it's been long enough since the last one. Queries are
+// sampled evenly rather than randomly, so that bursts are thinned out predictably.
+func (q *debugQueryHook) sample() bool {
+	if q.sampleRate >= 1 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	log := false
+	q.credit += q.sampleRate
+	if q.credit >= 1 {
+		q.credit--
+		log = true
+	} else {
+		q.suppressed++
+	}
+
+	if q.suppressed > 0 && time.Since(q.lastSummary) >= queryLogSummaryInterval {
+		logrus.Tracef("left %d queries out of the log in the last %s", q.suppressed, time.Since(q.lastSummary).Round(time.Second))
+		q.suppressed = 0
+		q.lastSummary = time.Now()
+	}
+
+	return log
+}
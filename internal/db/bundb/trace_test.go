@@ -0,0 +1,119 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+	"github.com/uptrace/bun"
+)
+
+type TraceTestSuite struct {
+	suite.Suite
+	buf   *bytes.Buffer
+	out   io.Writer
+	level logrus.Level
+}
+
+func (suite *TraceTestSuite) SetupTest() {
+	suite.buf = &bytes.Buffer{}
+	suite.out = logrus.StandardLogger().Out
+	suite.level = logrus.GetLevel()
+	logrus.SetOutput(suite.buf)
+	logrus.SetLevel(logrus.TraceLevel)
+}
+
+func (suite *TraceTestSuite) TearDownTest() {
+	logrus.SetOutput(suite.out)
+	logrus.SetLevel(suite.level)
+}
+
+func (suite *TraceTestSuite) TestSubsystemLogged() {
+	ctx := db.WithSubsystem(context.Background(), "federator")
+
+	newDebugQueryHook(1).AfterQuery(ctx, &bun.QueryEvent{
+		StartTime: time.Now(),
+		Query:     "SELECT 1",
+	})
+
+	suite.Contains(suite.buf.String(), "subsystem=federator")
+	suite.Contains(suite.buf.String(), "operation=SELECT")
+}
+
+func (suite *TraceTestSuite) TestNoSubsystem() {
+	newDebugQueryHook(1).AfterQuery(context.Background(), &bun.QueryEvent{
+		StartTime: time.Now(),
+		Query:     "SELECT 1",
+	})
+
+	suite.NotContains(suite.buf.String(), "subsystem=")
+	suite.Contains(suite.buf.String(), "operation=SELECT")
+}
+
+func (suite *TraceTestSuite) TestSampled() {
+	hook := newDebugQueryHook(0.25)
+	for i := 0; i < 8; i++ {
+		hook.AfterQuery(context.Background(), &bun.QueryEvent{
+			StartTime: time.Now(),
+			Query:     "SELECT 1",
+		})
+	}
+
+	suite.Equal(2, strings.Count(suite.buf.String(), "operation=SELECT"))
+	suite.NotContains(suite.buf.String(), "left")
+}
+
+func (suite *TraceTestSuite) TestSampledSummary() {
+	hook := newDebugQueryHook(0.5)
+	hook.(*debugQueryHook).lastSummary = time.Now().Add(-queryLogSummaryInterval)
+
+	for i := 0; i < 2; i++ {
+		hook.AfterQuery(context.Background(), &bun.QueryEvent{
+			StartTime: time.Now(),
+			Query:     "SELECT 1",
+		})
+	}
+
+	suite.Equal(1, strings.Count(suite.buf.String(), "operation=SELECT"))
+	suite.Contains(suite.buf.String(), "left 1 queries out of the log")
+}
+
+func (suite *TraceTestSuite) TestSampledErrorsAlwaysLogged() {
+	hook := newDebugQueryHook(0)
+	hook.AfterQuery(context.Background(), &bun.QueryEvent{
+		StartTime: time.Now(),
+		Query:     "SELECT oops",
+		Err:       errors.New("syntax error"),
+	})
+
+	suite.Contains(suite.buf.String(), "syntax error")
+}
+
+func TestTraceTestSuite(t *testing.T) {
+	suite.Run(t, new(TraceTestSuite))
+}
@@ -19,6 +19,10 @@
 package bundb
 
 import (
+	"context"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
 	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/uptrace/bun"
 )
@@ -37,6 +41,34 @@ func whereEmptyOrNull(column string) func(*bun.SelectQuery) *bun.SelectQuery {
 	}
 }
 
+// whereNotEmptyAndNotNull is a convenience function to return a bun WhereGroup that specifies
+// that the given column should be NEITHER an empty string NOR null.
+//
+// Use it as follows:
+//
+//   q = q.WhereGroup(" AND ", whereNotEmptyAndNotNull("whatever_column"))
+func whereNotEmptyAndNotNull(column string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			Where("? IS NOT NULL", bun.Ident(column)).
+			Where("? != ''", bun.Ident(column))
+	}
+}
+
+// whereNotExpired is a convenience function to return a bun WhereGroup that specifies
+// that the given expiry column should be EITHER null OR later than now.
+//
+// Use it as follows:
+//
+//   q = q.WhereGroup(" AND ", whereNotExpired("status.expires_at", time.Now()))
+func whereNotExpired(column string, now time.Time) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			WhereOr("? IS NULL", bun.Ident(column)).
+			WhereOr("? > ?", bun.Ident(column), now)
+	}
+}
+
 // updateWhere parses []db.Where and adds it to the given update query.
 func updateWhere(q *bun.UpdateQuery, where []db.Where) {
 	for _, w := range where {
@@ -98,3 +130,42 @@ func parseWhere(w db.Where) (query string, args []interface{}) {
 	args = []interface{}{bun.Safe(w.Key), w.Value}
 	return
 }
+
+// newExpiringCache returns a cache whose entries expire ttl after they were put in,
+// no matter how often they're read in the meantime, so that cached results are
+// always recomputed once they're ttl old.
+func newExpiringCache(ttl time.Duration) *ttlcache.Cache {
+	c := ttlcache.NewCache()
+	c.SetTTL(ttl)
+	c.SkipTtlExtensionOnHit(true)
+	return c
+}
+
+// insertIfNew inserts the given model, doing nothing instead if that would conflict with an existing row
+// on the given columns (or on any unique constraint at all, if no columns are given). It returns whether
+// a row was actually inserted, so that callers can skip side effects when the row was already there.
+func insertIfNew(ctx context.Context, idb bun.IDB, model interface{}, conflictColumns ...string) (bool, error) {
+	q := idb.NewInsert().Model(model)
+
+	if len(conflictColumns) == 0 {
+		q = q.On("CONFLICT DO NOTHING")
+	} else {
+		cols := make([]bun.Ident, 0, len(conflictColumns))
+		for _, col := range conflictColumns {
+			cols = append(cols, bun.Ident(col))
+		}
+		q = q.On("CONFLICT (?) DO NOTHING", bun.In(cols))
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,37 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import "github.com/spf13/viper"
+
+// saveConfig saves the current values of the given config keys, and returns a
+// function that puts them back. All the suites in this package share one viper
+// instance, so suites should only save (and change) the keys they need.
+func saveConfig(keys ...string) (restore func()) {
+	saved := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		saved[key] = viper.Get(key)
+	}
+
+	return func() {
+		for key, value := range saved {
+			viper.Set(key, value)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package bundb
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WarmUpTestSuite struct {
+	suite.Suite
+	sqldb *sql.DB
+}
+
+func (suite *WarmUpTestSuite) SetupTest() {
+	// postgres isn't available to tests, but any pool will do
+	sqldb, err := sql.Open("sqlite", "file:"+filepath.Join(suite.T().TempDir(), "sqlite.db"))
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	tweakConnectionValues(sqldb)
+	suite.sqldb = sqldb
+}
+
+func (suite *WarmUpTestSuite) TearDownTest() {
+	suite.sqldb.Close()
+}
+
+func (suite *WarmUpTestSuite) TestPoolEmptyWithoutWarmUp() {
+	suite.Equal(0, suite.sqldb.Stats().OpenConnections)
+}
+
+func (suite *WarmUpTestSuite) TestWarmUpFillsPool() {
+	suite.NoError(warmUpPool(context.Background(), suite.sqldb, maxConns()))
+
+	stats := suite.sqldb.Stats()
+	suite.Equal(maxConns(), stats.OpenConnections)
+	suite.Equal(maxConns(), stats.Idle)
+	suite.Zero(stats.InUse)
+}
+
+func (suite *WarmUpTestSuite) TestWarmUpCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	suite.ErrorIs(warmUpPool(ctx, suite.sqldb, maxConns()), context.Canceled)
+	suite.Zero(suite.sqldb.Stats().InUse)
+}
+
+func TestWarmUpTestSuite(t *testing.T) {
+	suite.Run(t, new(WarmUpTestSuite))
+}
@@ -0,0 +1,62 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import "context"
+
+type ctxKey int
+
+const (
+	subsystemKey ctxKey = iota
+	viewerKey
+)
+
+// WithSubsystem returns a copy of ctx labelled with the given subsystem name (eg., "federator", "processor").
+// Queries run with the returned context can then be attributed to that subsystem, for example in query logs.
+func WithSubsystem(ctx context.Context, subsystem string) context.Context {
+	return context.WithValue(ctx, subsystemKey, subsystem)
+}
+
+// SubsystemFromContext returns the subsystem label set on ctx by WithSubsystem,
+// or an empty string if ctx hasn't been labelled.
+func SubsystemFromContext(ctx context.Context) string {
+	subsystem, _ := ctx.Value(subsystemKey).(string)
+	return subsystem
+}
+
+// ViewerContext describes who a read is being done on behalf of, so that the
+// statuses it returns can be restricted to the ones they're allowed to see.
+type ViewerContext struct {
+	// AccountID of the viewer, or empty if they're not logged in.
+	AccountID string
+}
+
+// WithViewer returns a copy of ctx carrying the given viewer. Reads that honour it (eg., GetStatusParents
+// and GetStatusChildren) then leave out statuses that the viewer can't see, whose author has blocked them
+// or been blocked by them, or that they've muted.
+func WithViewer(ctx context.Context, viewer ViewerContext) context.Context {
+	return context.WithValue(ctx, viewerKey, viewer)
+}
+
+// ViewerFromContext returns the viewer set on ctx by WithViewer,
+// and false if ctx doesn't carry one.
+func ViewerFromContext(ctx context.Context) (ViewerContext, bool) {
+	viewer, ok := ctx.Value(viewerKey).(ViewerContext)
+	return viewer, ok
+}
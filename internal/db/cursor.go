@@ -0,0 +1,106 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// Cursor directions.
+const (
+	CursorDirectionNext = "next" // CursorDirectionNext pages onwards to older items, like maxID.
+	CursorDirectionPrev = "prev" // CursorDirectionPrev pages back to newer items, like minID.
+)
+
+// Cursor is the position a page of results was left at, for handing out to clients
+// in opaque form with EncodeCursor instead of exposing the raw ID.
+type Cursor struct {
+	// ID of the last item on the page.
+	ID string `json:"i"`
+	// Which way to page from ID, CursorDirectionNext or CursorDirectionPrev.
+	Direction string `json:"d"`
+}
+
+// Page returns the maxID and minID to pass to a paginated query to carry on from the cursor.
+// Only one of them will be set.
+func (c Cursor) Page() (maxID string, minID string) {
+	if c.Direction == CursorDirectionPrev {
+		return "", c.ID
+	}
+	return c.ID, ""
+}
+
+// EncodeCursor returns the given cursor as an opaque string that's safe to put in a url, signed with
+// the given key so that it can't be tampered with. The router session's Auth key does nicely for this.
+func EncodeCursor(cursor Cursor, key []byte) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(cursorMAC(payload, key)), nil
+}
+
+// DecodeCursor checks the signature of a cursor encoded by EncodeCursor with the same key, and returns the
+// cursor. If the cursor is malformed or its signature doesn't match, ErrInvalidCursor will be returned.
+func DecodeCursor(encoded string, key []byte) (Cursor, error) {
+	cursor := Cursor{}
+	enc := base64.RawURLEncoding
+
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 2 {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	mac, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(mac, cursorMAC(payload, key)) {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if cursor.ID == "" || (cursor.Direction != CursorDirectionNext && cursor.Direction != CursorDirectionPrev) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// cursorMAC returns the HMAC-SHA256 of the given cursor payload.
+func cursorMAC(payload []byte, key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
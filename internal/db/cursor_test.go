@@ -0,0 +1,100 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+)
+
+type CursorTestSuite struct {
+	suite.Suite
+	key []byte
+}
+
+func (suite *CursorTestSuite) SetupTest() {
+	suite.key = []byte("0123456789abcdef0123456789abcdef")
+}
+
+func (suite *CursorTestSuite) TestRoundTrip() {
+	for _, cursor := range []db.Cursor{
+		{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: db.CursorDirectionNext},
+		{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: db.CursorDirectionPrev},
+	} {
+		encoded, err := db.EncodeCursor(cursor, suite.key)
+		suite.NoError(err)
+		suite.NotContains(encoded, cursor.ID)
+
+		decoded, err := db.DecodeCursor(encoded, suite.key)
+		suite.NoError(err)
+		suite.Equal(cursor, decoded)
+	}
+}
+
+func (suite *CursorTestSuite) TestPage() {
+	maxID, minID := db.Cursor{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: db.CursorDirectionNext}.Page()
+	suite.Equal("01F8MHAMCHF6Y650WCRSCP4WMY", maxID)
+	suite.Empty(minID)
+
+	maxID, minID = db.Cursor{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: db.CursorDirectionPrev}.Page()
+	suite.Empty(maxID)
+	suite.Equal("01F8MHAMCHF6Y650WCRSCP4WMY", minID)
+}
+
+func (suite *CursorTestSuite) TestTamperedCursor() {
+	encoded, err := db.EncodeCursor(db.Cursor{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: db.CursorDirectionNext}, suite.key)
+	suite.NoError(err)
+	parts := strings.Split(encoded, ".")
+
+	// swap in a different payload but keep the original signature
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"i":"01F8MH1H7YV1Z7D2C8K2730QBF","d":"next"}`))
+
+	for _, tampered := range []string{
+		payload + "." + parts[1],
+		parts[0] + "." + parts[1][1:],
+		parts[0],
+		encoded + ".",
+		"",
+		"not a cursor at all",
+	} {
+		_, err := db.DecodeCursor(tampered, suite.key)
+		suite.ErrorIs(err, db.ErrInvalidCursor, tampered)
+	}
+
+	// a different key doesn't work either
+	_, err = db.DecodeCursor(encoded, []byte("fedcba9876543210fedcba9876543210"))
+	suite.ErrorIs(err, db.ErrInvalidCursor)
+}
+
+func (suite *CursorTestSuite) TestSignedButInvalid() {
+	// correctly signed, but not something EncodeCursor would ever have been given
+	encoded, err := db.EncodeCursor(db.Cursor{ID: "01F8MHAMCHF6Y650WCRSCP4WMY", Direction: "sideways"}, suite.key)
+	suite.NoError(err)
+
+	_, err = db.DecodeCursor(encoded, suite.key)
+	suite.ErrorIs(err, db.ErrInvalidCursor)
+}
+
+func TestCursorTestSuite(t *testing.T) {
+	suite.Run(t, new(CursorTestSuite))
+}
@@ -33,12 +33,15 @@ const (
 type DB interface {
 	Account
 	Admin
+	Announcement
 	Basic
 	Domain
+	FeatureFlag
 	Instance
 	Media
 	Mention
 	Notification
+	PubSub
 	Relationship
 	Session
 	Status
@@ -48,10 +51,11 @@ type DB interface {
 		USEFUL CONVERSION FUNCTIONS
 	*/
 
-	// MentionStringsToMentions takes a slice of deduplicated, lowercase account names in the form "@test@whatever.example.org" for a remote account,
+	// MentionStringsToMentions takes a slice of account names in the form "@test@whatever.example.org" for a remote account,
 	// or @test for a local account, which have been mentioned in a status.
 	// It takes the id of the account that wrote the status, and the id of the status itself, and then
 	// checks in the database for the mentioned accounts, and returns a slice of mentions generated based on the given parameters.
+	// Each mentioned account gets only one mention, however many times and in whatever case it was named; the first name is kept.
 	//
 	// Note: this func doesn't/shouldn't do any manipulation of the accounts in the DB, it's just for checking
 	// if they exist in the db and conveniently returning them if they do.
@@ -73,4 +77,11 @@ type DB interface {
 	// Note: this func doesn't/shouldn't do any manipulation of the emoji in the DB, it's just for checking
 	// if they exist in the db and conveniently returning them if they do.
 	EmojiStringsToEmojis(ctx context.Context, emojis []string) ([]*gtsmodel.Emoji, error)
+
+	// RepairStatusJoins re-parses the text of the given status for hashtags and emojis, using TagStringsToTags and
+	// EmojiStringsToEmojis, and creates any status_to_tags or status_to_emojis join rows that are missing for it.
+	// Tags that don't exist yet will be created. It returns the number of join rows that were created.
+	//
+	// This is useful for repairing statuses that were inserted directly (eg., by a bulk import) rather than via PutStatus.
+	RepairStatusJoins(ctx context.Context, status *gtsmodel.Status) (int, error)
 }
@@ -20,7 +20,16 @@ package db
 
 import (
 	"context"
+	"io"
 	"net/url"
+
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+const (
+	// DomainBlocksFormatMastodonCSV is the CSV blocklist format used by Mastodon, with the columns
+	// #domain, #severity, #reject_media and #public_comment.
+	DomainBlocksFormatMastodonCSV string = "mastodon_csv"
 )
 
 // Domain contains DB functions related to domains and domain blocks.
@@ -31,9 +40,31 @@ type Domain interface {
 	// AreDomainsBlocked checks if an instance-level domain block exists for any of the given domains strings, and returns true if even one is found.
 	AreDomainsBlocked(ctx context.Context, domains []string) (bool, Error)
 
+	// GetBlockedDomains returns the subset of the given domains (eg., `example.org`) which are suspended by an instance-level domain block.
+	// Domains that only have their media rejected are still federated with, so they're not included.
+	// The blocklist is cached for a short while, so this can be called for every incoming request without hitting the database each time.
+	GetBlockedDomains(ctx context.Context, domains []string) ([]string, Error)
+
+	// DomainMediaRejected checks if media from the given domain (eg., `example.org`) should not be downloaded, which is the
+	// case for domains that are suspended or have their media rejected. Text from reject_media domains is still accepted.
+	DomainMediaRejected(ctx context.Context, domain string) (bool, Error)
+
 	// IsURIBlocked checks if an instance-level domain block exists for the `host` in the given URI (eg., `https://example.org/users/whatever`).
 	IsURIBlocked(ctx context.Context, uri *url.URL) (bool, Error)
 
 	// AreURIsBlocked checks if an instance-level domain block exists for any `host` in the given URI slice, and returns true if even one is found.
 	AreURIsBlocked(ctx context.Context, uris []*url.URL) (bool, Error)
+
+	// ExportDomainBlocks writes all instance-level domain blocks to w in the given format (eg., DomainBlocksFormatMastodonCSV),
+	// ordered by domain. Blocks are streamed out of the database as they're written, so large blocklists aren't held in memory.
+	// Private comments are never included in the export.
+	ExportDomainBlocks(ctx context.Context, w io.Writer, format string) Error
+
+	// PutDomainBlock puts the given domain block in the database, and records in the audit log
+	// that block.CreatedByAccountID created it. Both are written in one transaction. The cached blocklist is invalidated.
+	PutDomainBlock(ctx context.Context, block *gtsmodel.DomainBlock) Error
+
+	// DeleteDomainBlock removes the given domain block from the database, and records in the audit log
+	// that moderatorID removed it. Both are written in one transaction. The cached blocklist is invalidated.
+	DeleteDomainBlock(ctx context.Context, block *gtsmodel.DomainBlock, moderatorID string) Error
 }
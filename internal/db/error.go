@@ -32,4 +32,16 @@ var (
 	ErrAlreadyExists Error = fmt.Errorf("already exists")
 	// ErrUnknown denotes an unknown database error.
 	ErrUnknown Error = fmt.Errorf("unknown error")
+	// ErrReadOnly is returned when a caller tries to write to the database while it is in read-only mode.
+	ErrReadOnly Error = fmt.Errorf("database is read-only")
+	// ErrInvalidCursor is returned when decoding a pagination cursor that's malformed or has been tampered with.
+	ErrInvalidCursor Error = fmt.Errorf("invalid cursor")
+	// ErrTooManyAccountFields is returned when trying to give an account more than MaxAccountFields profile fields.
+	ErrTooManyAccountFields Error = fmt.Errorf("too many account fields, the most allowed is %d", MaxAccountFields)
+	// ErrStatusExpiresTooSoon is returned when trying to create a status that expires before it was created.
+	ErrStatusExpiresTooSoon Error = fmt.Errorf("status expires before it was created")
+	// ErrAccountAliasNotMutual is returned when trying to move an account to another account, when the two don't list each other as aliases.
+	ErrAccountAliasNotMutual Error = fmt.Errorf("accounts aren't aliases of each other")
+	// ErrStatusReplyCycle is returned when trying to store a status whose reply chain would lead back to the status itself.
+	ErrStatusReplyCycle Error = fmt.Errorf("status would end up replying to itself")
 )
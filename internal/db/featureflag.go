@@ -0,0 +1,52 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"context"
+
+	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
+)
+
+// FeatureFlag contains functions for getting and setting instance-wide feature flags.
+//
+// Flags are cached for a short while, so reading them is cheap enough to do on every request.
+// Changes made through this interface are seen straight away; changes made by another process
+// sharing the same database are picked up when the cache next refreshes.
+type FeatureFlag interface {
+	// GetFeatureFlags returns all feature flags that have been set, ordered by name.
+	// This is intended for admins managing flags. If there are none, ErrNoEntries will be returned.
+	GetFeatureFlags(ctx context.Context) ([]*gtsmodel.FeatureFlag, Error)
+
+	// IsFeatureEnabled returns true if the flag with the given name is set to the JSON value `true`.
+	// Flags that have never been set are not enabled, and that's not an error.
+	IsFeatureEnabled(ctx context.Context, name string) (bool, Error)
+
+	// GetFeatureFlagValue decodes the JSON value of the flag with the given name into v.
+	// If the flag has never been set, ErrNoEntries will be returned.
+	GetFeatureFlagValue(ctx context.Context, name string, v interface{}) Error
+
+	// SetFeatureFlag encodes value as JSON and stores it as the value of the flag with the given name,
+	// creating the flag if it doesn't exist yet. This is intended for admins: accountID is the admin making the change.
+	SetFeatureFlag(ctx context.Context, name string, value interface{}, accountID string) Error
+
+	// DeleteFeatureFlag removes the flag with the given name, so that it's no longer set.
+	// Deleting a flag that doesn't exist is not an error.
+	DeleteFeatureFlag(ctx context.Context, name string) Error
+}
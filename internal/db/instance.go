@@ -32,9 +32,35 @@ type Instance interface {
 	// CountInstanceStatuses returns the number of known statuses posted from the given domain.
 	CountInstanceStatuses(ctx context.Context, domain string) (int, Error)
 
+	// GetLocalInstanceStats returns the user, status and domain counts of this instance, as
+	// reported by the instance API. Counts are cached for a short while, so they may lag a little.
+	GetLocalInstanceStats(ctx context.Context) (*InstanceStats, Error)
+
 	// CountInstanceDomains returns the number of known instances known that the given domain federates with.
 	CountInstanceDomains(ctx context.Context, domain string) (int, Error)
 
 	// GetInstanceAccounts returns a slice of accounts from the given instance, arranged by ID.
 	GetInstanceAccounts(ctx context.Context, domain string, maxID string, limit int) ([]*gtsmodel.Account, Error)
+
+	// PutThemeAsset stores the metadata of a theme asset, whose file has already been written to storage at asset.Path.
+	// If there's no asset of the same name yet, it's created at version 1; otherwise the existing one is replaced, and its
+	// version goes up by one. Either way, asset is updated to match what was stored. The path of the replaced file is
+	// returned, so that it can be removed from storage, or an empty string if nothing was replaced.
+	PutThemeAsset(ctx context.Context, asset *gtsmodel.ThemeAsset) (string, Error)
+
+	// GetThemeAsset returns the theme asset with the given name, or ErrNoEntries if there isn't one.
+	GetThemeAsset(ctx context.Context, name string) (*gtsmodel.ThemeAsset, Error)
+
+	// GetThemeAssets returns all theme assets, ordered by name.
+	GetThemeAssets(ctx context.Context) ([]*gtsmodel.ThemeAsset, Error)
+}
+
+// InstanceStats are the numbers that this instance reports about itself.
+type InstanceStats struct {
+	// Number of local accounts, not counting the instance account or suspended accounts.
+	UserCount int
+	// Number of statuses posted by local accounts.
+	StatusCount int
+	// Number of other instances this instance knows about, not counting suspended ones.
+	DomainCount int
 }
@@ -20,6 +20,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
@@ -28,4 +29,85 @@ import (
 type Media interface {
 	// GetAttachmentByID gets a single attachment by its ID
 	GetAttachmentByID(ctx context.Context, id string) (*gtsmodel.MediaAttachment, Error)
+
+	// GetMediaByIDs gets the attachments with the given IDs in one query, in the same order as the IDs.
+	// IDs that don't correspond to an attachment are skipped, so the result may be shorter than ids.
+	GetMediaByIDs(ctx context.Context, ids []string) ([]*gtsmodel.MediaAttachment, Error)
+
+	// UpdateAttachmentDescription sets the description (alt text) of the attachment with the given ID.
+	UpdateAttachmentDescription(ctx context.Context, attachmentID string, description string) Error
+
+	// UpdateAttachmentFocus sets the focus point of the attachment with the given ID, leaving the rest
+	// of its file metadata untouched. Both coordinates must be between -1 and 1.
+	UpdateAttachmentFocus(ctx context.Context, attachmentID string, focusX float32, focusY float32) Error
+
+	// GetAccountMedia pages through the image, gif and video attachments of the given account, newest first.
+	// Only attachments that are attached to a status and that have finished processing are returned.
+	// If onlyImages is true, only plain image attachments will be returned.
+	// If maxID is set, only attachments older than maxID will be returned.
+	// If no attachments are found, ErrNoEntries will be returned.
+	GetAccountMedia(ctx context.Context, accountID string, onlyImages bool, maxID string, limit int) ([]*gtsmodel.MediaAttachment, Error)
+
+	// GetMediaStats returns the number of attachments created since the given time, along with how many bytes
+	// they take up in storage, keyed by the type of attachment. Types with no attachments won't be in the map.
+	// Pass a zero time to get stats for all attachments ever stored.
+	GetMediaStats(ctx context.Context, since time.Time) (map[gtsmodel.FileType]*MediaStats, Error)
+
+	// GetAttachmentsWithoutFileHash pages through attachments that don't have a file hash yet, newest first,
+	// so that the hash can be backfilled from storage. If no attachments are found, ErrNoEntries will be returned.
+	GetAttachmentsWithoutFileHash(ctx context.Context, maxID string, limit int) ([]*gtsmodel.MediaAttachment, Error)
+
+	// SetAttachmentFileHash sets the file hash of the attachment with the given ID.
+	SetAttachmentFileHash(ctx context.Context, attachmentID string, fileHash string) Error
+
+	// GetMediaMissingBlurhash pages through processed image and gif attachments that don't have a blurhash, newest first, so that
+	// one can be backfilled from their files. If force is true, attachments that already have a blurhash are returned too, so that
+	// every blurhash can be worked out again, eg., after a change to how they're generated. Attachments whose files have been pruned
+	// are left out, since there's nothing to work a blurhash out from. If no attachments are found, ErrNoEntries will be returned.
+	GetMediaMissingBlurhash(ctx context.Context, force bool, maxID string, limit int) ([]*gtsmodel.MediaAttachment, Error)
+
+	// SetAttachmentBlurhash sets the blurhash of the attachment with the given ID.
+	SetAttachmentBlurhash(ctx context.Context, attachmentID string, blurhash string) Error
+
+	// GetDuplicateMediaByHash pages through file hashes that are shared by more than one attachment, in descending
+	// order of hash, returning the attachments for each. Attachments without a file hash aren't considered, so the
+	// hashes should be backfilled first. If maxHash is set, only hashes lower than maxHash will be returned.
+	// If there are no duplicates, ErrNoEntries will be returned.
+	GetDuplicateMediaByHash(ctx context.Context, maxHash string, limit int) ([]*MediaDuplicates, Error)
+
+	// GetRemoteMediaOlderThan pages through remote attachments that haven't been served since cutoff (or, if they've never
+	// been served, that were created before cutoff), newest first, for pruning their files from storage. Attachments that have
+	// been pruned already are left out. If maxID is set, only attachments with an ID lower than maxID will be returned.
+	// If no attachments are found, ErrNoEntries will be returned.
+	GetRemoteMediaOlderThan(ctx context.Context, cutoff time.Time, maxID string, limit int) ([]*gtsmodel.MediaAttachment, Error)
+
+	// SetAttachmentAccessedAt records that the attachment with the given ID was served at the given time.
+	SetAttachmentAccessedAt(ctx context.Context, attachmentID string, accessedAt time.Time) Error
+
+	// SetAttachmentPruned records that the files of the remote attachment with the given ID have been removed from storage.
+	// The attachment itself is kept, so that its files can be fetched again from the remote URL when they're next needed.
+	SetAttachmentPruned(ctx context.Context, attachmentID string) Error
+
+	// GetRemoteEmojiNeedingFetch returns up to limit emoji from the given remote domain whose images haven't been cached
+	// on this instance yet (ie., that don't have a local image url), newest first, so that they can be fetched again once
+	// the domain is reachable. Disabled emoji are left out. If no emoji are found, ErrNoEntries will be returned.
+	GetRemoteEmojiNeedingFetch(ctx context.Context, domain string, limit int) ([]*gtsmodel.Emoji, Error)
+}
+
+// MediaStats contains aggregated storage statistics for one type of media attachment.
+type MediaStats struct {
+	// How many attachments of this type there are.
+	Count int
+	// How many bytes the original files of these attachments take up.
+	FileBytes int64
+	// How many bytes the thumbnails of these attachments take up.
+	ThumbnailBytes int64
+}
+
+// MediaDuplicates contains attachments that all have the same file in storage.
+type MediaDuplicates struct {
+	// The hash of the file that the attachments share.
+	FileHash string
+	// The attachments with this file hash, oldest first.
+	Attachments []*gtsmodel.MediaAttachment
 }
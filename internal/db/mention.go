@@ -31,4 +31,8 @@ type Mention interface {
 
 	// GetMentions gets multiple mentions.
 	GetMentions(ctx context.Context, ids []string) ([]*gtsmodel.Mention, Error)
+
+	// GetStatusMentions returns all the mentions in the status with the given ID, in the order they were created,
+	// using one query. Results are cached per status until the status is edited or deleted.
+	GetStatusMentions(ctx context.Context, statusID string) ([]*gtsmodel.Mention, Error)
 }
@@ -32,4 +32,12 @@ type Notification interface {
 	GetNotifications(ctx context.Context, accountID string, limit int, maxID string, sinceID string) ([]*gtsmodel.Notification, Error)
 	// GetNotification returns one notification according to its id.
 	GetNotification(ctx context.Context, id string) (*gtsmodel.Notification, Error)
+	// PutNotification stores a new notification, and publishes an EventNotificationCreated event for it.
+	PutNotification(ctx context.Context, notif *gtsmodel.Notification) Error
+	// DeleteNotificationsForStatus deletes all notifications that pertain to the given statusID,
+	// and removes them from the notification cache. This should be called when a status is deleted.
+	DeleteNotificationsForStatus(ctx context.Context, statusID string) Error
+	// DeleteDanglingNotifications deletes all notifications that point to a status which no longer
+	// exists in the database, returning the number of notifications deleted.
+	DeleteDanglingNotifications(ctx context.Context) (int, Error)
 }
@@ -0,0 +1,44 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package db
+
+const (
+	// EventStatusCreated is published when a new status has been put in the database.
+	EventStatusCreated string = "status_created"
+	// EventNotificationCreated is published when a new notification has been put in the database.
+	EventNotificationCreated string = "notification_created"
+)
+
+// Event describes something that was just written to the database.
+type Event struct {
+	// Type of the event, eg., EventStatusCreated.
+	Type string `json:"type"`
+	// ID of the database entry that the event is about.
+	ID string `json:"id"`
+}
+
+// PubSub lets callers find out about new entries in the database as they're written, rather than polling for them.
+//
+// On Postgres, events are passed around using LISTEN/NOTIFY, so subscribers are told about entries written by any
+// GoToSocial process using the same database. On SQLite, only entries written by this process are seen.
+type PubSub interface {
+	// Subscribe returns a channel on which events will be delivered, and a func to call to unsubscribe again.
+	// Subscribers that don't keep up with reading from the channel will miss events rather than holding up writes.
+	Subscribe() (<-chan *Event, func())
+}
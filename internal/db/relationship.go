@@ -20,6 +20,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
@@ -39,6 +40,10 @@ type Relationship interface {
 	// GetRelationship retrieves the relationship of the targetAccount to the requestingAccount.
 	GetRelationship(ctx context.Context, requestingAccount string, targetAccount string) (*gtsmodel.Relationship, Error)
 
+	// GetAccountRelationships is like GetRelationship, but for several target accounts at once, using the same small
+	// number of queries however many targets there are. One relationship is returned per target, in the same order.
+	GetAccountRelationships(ctx context.Context, requestingAccount string, targetAccounts []string) ([]*gtsmodel.Relationship, Error)
+
 	// IsFollowing returns true if sourceAccount follows target account, or an error if something goes wrong while finding out.
 	IsFollowing(ctx context.Context, sourceAccount *gtsmodel.Account, targetAccount *gtsmodel.Account) (bool, Error)
 
@@ -62,6 +67,10 @@ type Relationship interface {
 	// GetAccountFollowRequests returns all follow requests targeting the given account.
 	GetAccountFollowRequests(ctx context.Context, accountID string) ([]*gtsmodel.FollowRequest, Error)
 
+	// GetOutgoingFollowRequests returns the pending follow requests made by the given account, ordered by ID descending,
+	// with the target account populated. If there are no follow requests on the requested page, ErrNoEntries will be returned.
+	GetOutgoingFollowRequests(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.FollowRequest, Error)
+
 	// GetAccountFollows returns a slice of follows owned by the given accountID.
 	GetAccountFollows(ctx context.Context, accountID string) ([]*gtsmodel.Follow, Error)
 
@@ -77,4 +86,97 @@ type Relationship interface {
 
 	// CountAccountFollowedBy returns the amounts that the given ID is followed by.
 	CountAccountFollowedBy(ctx context.Context, accountID string, localOnly bool) (int, Error)
+
+	// GetFollowerAccountIDs returns the IDs of all accounts following the given accountID, without fetching the accounts
+	// themselves, eg., for fanning a new status out to followers. Accounts with lots of followers should be streamed
+	// through with StreamFollowerAccountIDs instead.
+	GetFollowerAccountIDs(ctx context.Context, accountID string) ([]string, Error)
+
+	// StreamFollowerAccountIDs calls fn with the IDs of the accounts following the given accountID, batchSize at a time,
+	// so that they don't all have to be held in memory at once. If fn returns an error, streaming stops and the error is
+	// returned.
+	StreamFollowerAccountIDs(ctx context.Context, accountID string, batchSize int, fn func(accountIDs []string) error) Error
+
+	// CountBlockedBy returns the number of local accounts that have blocked the given accountID.
+	//
+	// Who blocks who is private, so this is for moderation by admins only: don't show it to anyone else.
+	CountBlockedBy(ctx context.Context, accountID string) (int, Error)
+
+	// GetBlockedBy returns blocks by local accounts that target the given accountID, ordered by ID descending,
+	// with the blocking account populated. If there are no blocks on the requested page, ErrNoEntries will be returned.
+	//
+	// Who blocks who is private, so this is for moderation by admins only: don't show it to anyone else.
+	GetBlockedBy(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.Block, Error)
+
+	// GetBlocksAndMutes returns both the blocks and the status mutes created by the given accountID, together in one
+	// list ordered by ID descending, with each entry tagged with its type. If there are no blocks or mutes on the
+	// requested page, ErrNoEntries will be returned.
+	GetBlocksAndMutes(ctx context.Context, accountID string, maxID string, limit int) ([]*BlockOrMute, Error)
+
+	// GetFollowEdges pages through the follows of the given accountID, ordered by follow ID descending, with each edge
+	// annotated with the rest of the relationship between accountID and the account at the other end. If outgoing is
+	// true, the follows *by* accountID are returned, otherwise the follows *of* accountID. If there are no follows on
+	// the requested page, ErrNoEntries will be returned.
+	GetFollowEdges(ctx context.Context, accountID string, outgoing bool, maxID string, limit int) ([]*FollowEdge, Error)
+
+	// ImportFollows makes accountID follow each of targets, given as 'user@domain' addresses like the ones in a follows
+	// list exported from Mastodon, for users moving here from another instance. Targets are dealt with in batches, one
+	// transaction per batch. Unlocked local accounts are followed straight away; for locked or remote accounts a follow
+	// request is created instead, which the caller still needs to deliver to remote accounts. Targets this instance
+	// doesn't know about yet can't be resolved here, so they should be dereferenced before importing.
+	ImportFollows(ctx context.Context, accountID string, targets []string) (ImportResult, Error)
+}
+
+// ImportResult reports what came of each target passed to ImportFollows.
+type ImportResult struct {
+	// Followed holds the targets that accountID now follows, including ones it already did.
+	Followed []string
+	// Pending holds the targets that accountID has requested to follow.
+	Pending []string
+	// Failed holds the targets that couldn't be followed, mapped to the reason why.
+	Failed map[string]string
+}
+
+const (
+	// BlockOrMuteTypeBlock means a BlockOrMute is a block of an account.
+	BlockOrMuteTypeBlock string = "block"
+	// BlockOrMuteTypeMute means a BlockOrMute is a mute of a status.
+	BlockOrMuteTypeMute string = "mute"
+)
+
+// BlockOrMute is one entry in a combined list of blocks and mutes.
+type BlockOrMute struct {
+	// Type of the entry, BlockOrMuteTypeBlock or BlockOrMuteTypeMute.
+	Type string `bun:"type"`
+	// Database ID of the block or mute.
+	ID string `bun:"id"`
+	// ID of the account that's blocked, or whose status is muted.
+	TargetAccountID string `bun:"target_account_id"`
+	// ID of the muted status. Empty for blocks.
+	StatusID string `bun:"status_id"`
+}
+
+// FollowEdge is one follow to or from an account, along with the
+// relationship between that account and the account at the other end.
+type FollowEdge struct {
+	// Database ID of the follow.
+	FollowID string `bun:"follow_id"`
+	// ID of the account at the other end of the follow.
+	AccountID string `bun:"account_id"`
+	// When the follow was created.
+	Since time.Time `bun:"since"`
+	// Whether the follow includes reblogs.
+	ShowReblogs bool `bun:"show_reblogs"`
+	// Whether the follow notifies of new statuses.
+	Notify bool `bun:"notify"`
+	// Whether the account follows the other account.
+	Following bool `bun:"following"`
+	// Whether the other account follows the account.
+	FollowedBy bool `bun:"followed_by"`
+	// Whether the account has requested to follow the other account, and is waiting for approval.
+	Requested bool `bun:"requested"`
+	// Whether the account blocks the other account.
+	Blocking bool `bun:"blocking"`
+	// Whether the other account blocks the account.
+	BlockedBy bool `bun:"blocked_by"`
 }
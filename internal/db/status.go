@@ -20,6 +20,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
@@ -35,9 +36,94 @@ type Status interface {
 	// GetStatusByURL returns one status from the database, with no rel fields populated, only their linking ID / URIs
 	GetStatusByURL(ctx context.Context, uri string) (*gtsmodel.Status, Error)
 
-	// PutStatus stores one status in the database.
+	// GetStatusByMediaID returns the status that the media attachment with the given ID is attached to.
+	// If the attachment isn't attached to any status, or doesn't exist, ErrNoEntries will be returned.
+	GetStatusByMediaID(ctx context.Context, attachmentID string) (*gtsmodel.Status, Error)
+
+	// GetLatestPublicStatuses returns the most recent public, non-boost status of each of the given accounts,
+	// newest first. Accounts that haven't posted any public statuses are left out. If none of the accounts have
+	// posted anything public, ErrNoEntries will be returned.
+	GetLatestPublicStatuses(ctx context.Context, accountIDs []string) ([]*gtsmodel.Status, Error)
+
+	// GetAccountMediaStatuses returns statuses created by accountID that have at least one media attachment,
+	// and which requestingAccountID is allowed to see, ordered by ID descending. Pass an empty requestingAccountID
+	// for someone who isn't logged in; they'll only see public statuses from domains that aren't blocked.
+	// The total number of such statuses (ignoring maxID and limit) is returned too.
+	// If there are no statuses on the requested page, ErrNoEntries will be returned.
+	GetAccountMediaStatuses(ctx context.Context, accountID string, requestingAccountID string, maxID string, limit int) ([]*gtsmodel.Status, int, Error)
+
+	// StatusVisibleTo returns whether accountID may see, and so interact with (fave, reply to, boost), the status with the given ID.
+	// This applies the same visibility rules as status queries do, and also checks for blocks in either direction between accountID
+	// and the author. An empty accountID is someone who isn't logged in. If the status doesn't exist, false will be returned.
+	StatusVisibleTo(ctx context.Context, statusID string, accountID string) (bool, Error)
+
+	// StatusesVisibleTo is like StatusVisibleTo, but for several statuses at once. It returns the IDs of the statuses
+	// that accountID may see, in the order they were given, using one query no matter how many statuses are checked.
+	StatusesVisibleTo(ctx context.Context, statusIDs []string, accountID string) ([]string, Error)
+
+	// GetRepliesToAccount returns statuses replying to accountID which that account is allowed to see, ordered by ID
+	// descending. Replies from accounts that accountID has blocked, or that have blocked accountID, are left out, and
+	// so are the account's replies to itself. If there are no replies on the requested page, ErrNoEntries will be returned.
+	GetRepliesToAccount(ctx context.Context, accountID string, maxID string, limit int) ([]*gtsmodel.Status, Error)
+
+	// GetTrendingTags returns up to limit listable tags used by public statuses created within the given window,
+	// ranked by how many different accounts used them, so that one account spamming a tag can't make it trend.
+	// Results are cached for a short while, since working them out is expensive. If no tags have been used
+	// within the window, ErrNoEntries will be returned.
+	GetTrendingTags(ctx context.Context, window time.Duration, limit int) ([]*TrendingTag, Error)
+
+	// GetRandomPublicStatuses returns up to limit randomly picked statuses created since the given time, for discovery
+	// surfaces. Only public, non-sensitive statuses that aren't boosts, and aren't from blocked domains, are picked.
+	//
+	// Rather than shuffling every eligible status, which is slow, this jumps to a few random points in time within the
+	// window, and takes a short run of consecutive statuses from each. So statuses posted right after a quiet spell are
+	// more likely to be picked than ones posted in a busy spell, and statuses in a run tend to be close together in time.
+	// If there are no eligible statuses, ErrNoEntries will be returned.
+	GetRandomPublicStatuses(ctx context.Context, limit int, since time.Time) ([]*gtsmodel.Status, Error)
+
+	// GetStatusesByContentKey returns statuses created since the given time that have the given content key, newest first,
+	// so that moderators can find the same text being posted by many accounts. Boosts don't have a content key of their own.
+	// If there are no such statuses, ErrNoEntries will be returned.
+	GetStatusesByContentKey(ctx context.Context, contentKey string, since time.Time, limit int) ([]*gtsmodel.Status, Error)
+
+	// GetExpiredStatuses pages through statuses whose expiry time is at or before now, ordered by ID descending, so that
+	// they can be deleted. Expired statuses are already left out of timelines, so it doesn't matter if they linger a while.
+	// If there are no such statuses, ErrNoEntries will be returned.
+	GetExpiredStatuses(ctx context.Context, now time.Time, maxID string, limit int) ([]*gtsmodel.Status, Error)
+
+	// GetStatusesWithMissingAccount pages through statuses whose account doesn't exist in the database, ordered by
+	// ID descending. These can't be shown, since there's no author to show them with. The returned statuses don't
+	// have their account populated, obviously. If there are no such statuses, ErrNoEntries will be returned.
+	GetStatusesWithMissingAccount(ctx context.Context, maxID string, limit int) ([]*gtsmodel.Status, Error)
+
+	// FixStatusesWithMissingAccount goes through all statuses whose account is missing, and tries to get the account
+	// back for each one by calling refetch, eg., by dereferencing the status' AccountURI. If that gives an account, the
+	// status is moved over to it. Otherwise, or if refetch is nil, the status is deleted, along with its tag, emoji
+	// and mention rows; notifications of it are left for DeleteDanglingNotifications to tidy up.
+	// It returns how many statuses were moved to a refetched account, and how many were deleted.
+	FixStatusesWithMissingAccount(ctx context.Context, refetch func(ctx context.Context, status *gtsmodel.Status) (*gtsmodel.Account, error)) (int, int, Error)
+
+	// GetStatusesMentions fetches the mentions of all the given statuses in one go, keyed by status ID.
+	// The origin and target accounts of each mention are populated. Statuses without mentions won't be in the map.
+	GetStatusesMentions(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Mention, Error)
+
+	// GetStatusesTags fetches the tags used by all the given statuses in one go, keyed by status ID.
+	// Statuses without tags won't be in the map.
+	GetStatusesTags(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Tag, Error)
+
+	// GetStatusesEmojis fetches the emojis used by all the given statuses in one go, keyed by status ID.
+	// Statuses without emojis won't be in the map.
+	GetStatusesEmojis(ctx context.Context, statusIDs []string) (map[string][]*gtsmodel.Emoji, Error)
+
+	// PutStatus stores one status in the database, and publishes an EventStatusCreated event for it.
+	// If the status has an expiry time that isn't after its creation time, ErrStatusExpiresTooSoon will be returned.
+	// If the status replies to itself, or to a status whose reply chain leads back to it, ErrStatusReplyCycle will be returned.
 	PutStatus(ctx context.Context, status *gtsmodel.Status) Error
 
+	// PutStatusIfNew is like PutStatus, but does nothing if a status with the same URI is already stored. It returns
+	// whether the status was stored, so that callers can skip side effects (eg., notifications) for duplicates.
+	PutStatusIfNew(ctx context.Context, status *gtsmodel.Status) (bool, Error)
+
 	// CountStatusReplies returns the amount of replies recorded for a status, or an error if something goes wrong
 	CountStatusReplies(ctx context.Context, status *gtsmodel.Status) (int, Error)
 
@@ -47,14 +133,32 @@ type Status interface {
 	// CountStatusFaves returns the amount of faves/likes recorded for a status, or an error if something goes wrong
 	CountStatusFaves(ctx context.Context, status *gtsmodel.Status) (int, Error)
 
+	// CountRecentStatuses returns how many statuses the given account has created since the given time, for rate limiting posting.
+	// This is served by the statuses_account_id_created_at_idx index, so it's cheap enough to call every time a status is created.
+	CountRecentStatuses(ctx context.Context, accountID string, since time.Time) (int, Error)
+
 	// GetStatusParents gets the parent statuses of a given status.
 	//
 	// If onlyDirect is true, only the immediate parent will be returned.
+	//
+	// If ctx carries a viewer, walking up the thread stops at the first parent the viewer may not see.
 	GetStatusParents(ctx context.Context, status *gtsmodel.Status, onlyDirect bool) ([]*gtsmodel.Status, Error)
 
+	// GetThreadRoot returns the oldest ancestor of the given status, found by following in_reply_to_id upwards.
+	// If the status isn't a reply, it's returned itself. If an ancestor is missing from the database,
+	// the oldest ancestor that we do have is returned.
+	GetThreadRoot(ctx context.Context, statusID string) (*gtsmodel.Status, Error)
+
+	// BreakStatusReplyCycles finds statuses whose reply chains lead back to themselves, which federation can produce
+	// despite the checks in PutStatus, and which would make walking up the thread go round in circles. Each cycle is
+	// broken by clearing the in_reply_to_id of its newest status. It returns how many cycles were broken.
+	BreakStatusReplyCycles(ctx context.Context) (int, Error)
+
 	// GetStatusChildren gets the child statuses of a given status.
 	//
 	// If onlyDirect is true, only the immediate children will be returned.
+	//
+	// If ctx carries a viewer, children the viewer may not see are left out, along with the replies under them.
 	GetStatusChildren(ctx context.Context, status *gtsmodel.Status, onlyDirect bool, minID string) ([]*gtsmodel.Status, Error)
 
 	// IsStatusFavedBy checks if a given status has been faved by a given account ID
@@ -69,6 +173,14 @@ type Status interface {
 	// IsStatusBookmarkedBy checks if a given status has been bookmarked by a given account ID
 	IsStatusBookmarkedBy(ctx context.Context, status *gtsmodel.Status, accountID string) (bool, Error)
 
+	// CreateStatusEdit stores a previous version of a status. It should be called with what the status looked
+	// like before an edit; the status itself should then be updated to hold the new, latest version.
+	CreateStatusEdit(ctx context.Context, edit *gtsmodel.StatusEdit) Error
+
+	// GetStatusEdits returns the previous versions of the status with the given ID, oldest first.
+	// If the status has never been edited, ErrNoEntries will be returned.
+	GetStatusEdits(ctx context.Context, statusID string) ([]*gtsmodel.StatusEdit, Error)
+
 	// GetStatusFaves returns a slice of faves/likes of the given status.
 	// This slice will be unfiltered, not taking account of blocks and whatnot, so filter it before serving it back to a user.
 	GetStatusFaves(ctx context.Context, status *gtsmodel.Status) ([]*gtsmodel.StatusFave, Error)
@@ -76,4 +188,39 @@ type Status interface {
 	// GetStatusReblogs returns a slice of statuses that are a boost/reblog of the given status.
 	// This slice will be unfiltered, not taking account of blocks and whatnot, so filter it before serving it back to a user.
 	GetStatusReblogs(ctx context.Context, status *gtsmodel.Status) ([]*gtsmodel.Status, Error)
+
+	// GetStatusReblogAccounts returns the accounts that boosted/reblogged the given status, newest boost first, for the
+	// "boosted by" list. Boosts that requestingAccountID isn't allowed to see, and boosts by accounts that have blocked
+	// or been blocked by requestingAccountID, are left out. Pages are keyed on the IDs of the boosts themselves, and the
+	// lowest and highest boost ID on the page are returned, for use as the next maxID and previous sinceID.
+	// If there are no boosts on the requested page, ErrNoEntries will be returned.
+	GetStatusReblogAccounts(ctx context.Context, statusID string, requestingAccountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, Error)
+
+	// GetStatusFavouritedAccounts is like GetStatusReblogAccounts, but for the accounts that faved/liked the status.
+	// Pages are keyed on the IDs of the faves. If requestingAccountID can't see the status itself, ErrNoEntries is returned.
+	GetStatusFavouritedAccounts(ctx context.Context, statusID string, requestingAccountID string, maxID string, sinceID string, limit int) ([]*gtsmodel.Account, string, string, Error)
+
+	// GetStatusesMatchingFilter pages through statuses that match everything in the given filter, ordered by ID descending,
+	// for moderation review queues. The filter must have at least one criterion set. If there are no matching statuses
+	// on the requested page, ErrNoEntries will be returned.
+	GetStatusesMatchingFilter(ctx context.Context, filter FilterSpec, maxID string, limit int) ([]*gtsmodel.Status, Error)
+}
+
+// FilterSpec describes which statuses GetStatusesMatchingFilter should pick out.
+// Criteria that are set all have to match; criteria that aren't set are ignored.
+type FilterSpec struct {
+	// Only statuses whose content or content warning contains at least one of these keywords, ignoring case.
+	Keywords []string
+	// Only statuses that are marked as sensitive.
+	Sensitive bool
+}
+
+// TrendingTag is a tag along with how much it's been used recently.
+type TrendingTag struct {
+	// The tag in question.
+	Tag *gtsmodel.Tag
+	// How many different accounts used the tag.
+	Accounts int
+	// How many statuses used the tag.
+	Uses int
 }
@@ -31,12 +31,27 @@ type Timeline interface {
 	// Statuses should be returned in descending order of when they were created (newest first).
 	GetHomeTimeline(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*gtsmodel.Status, Error)
 
+	// GetHomeTimelineDeduped is like GetHomeTimeline, but when a status shows up on the page more than once, because several
+	// followed accounts boosted it, or it was boosted as well as posted by someone followed, it's only returned once. Only the
+	// first appearance on the page is kept, along with the IDs of all the accounts that boosted the status on the page.
+	//
+	// Also note the extra return values, which are the lowest and highest status IDs on the page before deduplicating, for use
+	// as the nextMaxID and prevMinID: otherwise the next page would repeat boosts that were collapsed on this one.
+	// If there are no statuses on the requested page, ErrNoEntries will be returned.
+	GetHomeTimelineDeduped(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*TimelineEntry, string, string, Error)
+
 	// GetPublicTimeline fetches the account's PUBLIC timeline -- ie., posts and replies that are public.
 	// It will use the given filters and try to return as many statuses as possible up to the limit.
 	//
 	// Statuses should be returned in descending order of when they were created (newest first).
 	GetPublicTimeline(ctx context.Context, accountID string, maxID string, sinceID string, minID string, limit int, local bool) ([]*gtsmodel.Status, Error)
 
+	// GetLocalTimeline fetches the timeline that anonymous visitors see of this instance -- ie., public posts by local accounts that aren't replies or boosts.
+	// There's no viewer, so none of the relationship checks of GetPublicTimeline are needed, which keeps this cheap enough to serve to anyone.
+	//
+	// Statuses should be returned in descending order of when they were created (newest first).
+	GetLocalTimeline(ctx context.Context, maxID string, limit int) ([]*gtsmodel.Status, Error)
+
 	// GetFavedTimeline fetches the account's FAVED timeline -- ie., posts and replies that the requesting account has faved.
 	// It will use the given filters and try to return as many statuses as possible up to the limit.
 	//
@@ -46,3 +61,11 @@ type Timeline interface {
 	// Also note the extra return values, which correspond to the nextMaxID and prevMinID for building Link headers.
 	GetFavedTimeline(ctx context.Context, accountID string, maxID string, minID string, limit int) ([]*gtsmodel.Status, string, string, Error)
 }
+
+// TimelineEntry is a status on a deduplicated timeline.
+type TimelineEntry struct {
+	// The first appearance of the status on the page: either a boost of it, or the status itself.
+	Status *gtsmodel.Status
+	// The IDs of the accounts that boosted the status on the page, in the order the boosts appeared.
+	BoostedBy []string
+}
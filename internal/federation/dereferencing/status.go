@@ -264,8 +264,17 @@ func (d *deref) populateStatusFields(ctx context.Context, status *gtsmodel.Statu
 		status.ID = newID
 	}
 
-	// 1. Media attachments.
-	if err := d.populateStatusAttachments(ctx, status, requestingUsername); err != nil {
+	// 1. Media attachments, unless we don't take media from this domain.
+	host := statusIRI.Hostname()
+	mediaRejected, err := d.db.DomainMediaRejected(ctx, host)
+	if err != nil {
+		return fmt.Errorf("populateStatusFields: error checking media rejection of %s: %s", host, err)
+	}
+	if mediaRejected {
+		l.Debugf("media from domain %s is rejected, skipping attachments", host)
+		status.AttachmentIDs = []string{}
+		status.Attachments = []*gtsmodel.MediaAttachment{}
+	} else if err := d.populateStatusAttachments(ctx, status, requestingUsername); err != nil {
 		return fmt.Errorf("populateStatusFields: error populating status attachments: %s", err)
 	}
 
@@ -25,9 +25,12 @@ import (
 
 	"github.com/superseriousbusiness/activity/pub"
 	"github.com/superseriousbusiness/activity/streams/vocab"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
 )
 
-// federatingActor implements the go-fed federating protocol interface
+// federatingActor implements the go-fed federating protocol interface.
+// Contexts of incoming federation requests are labelled with the "federator"
+// subsystem, so that the queries they cause can be told apart from others.
 type federatingActor struct {
 	actor pub.FederatingActor
 }
@@ -74,14 +77,14 @@ func (f *federatingActor) Send(c context.Context, outbox *url.URL, t vocab.Type)
 // http.StatusMethodNotAllowed status code in the response. No side
 // effects occur.
 func (f *federatingActor) PostInbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
-	return f.actor.PostInbox(c, w, r)
+	return f.actor.PostInbox(db.WithSubsystem(c, "federator"), w, r)
 }
 
 // PostInboxScheme is similar to PostInbox, except clients are able to
 // specify which protocol scheme to handle the incoming request and the
 // data stored within the application (HTTP, HTTPS, etc).
 func (f *federatingActor) PostInboxScheme(c context.Context, w http.ResponseWriter, r *http.Request, scheme string) (bool, error) {
-	return f.actor.PostInboxScheme(c, w, r, scheme)
+	return f.actor.PostInboxScheme(db.WithSubsystem(c, "federator"), w, r, scheme)
 }
 
 // GetInbox returns true if the request was handled as an ActivityPub
@@ -99,7 +102,7 @@ func (f *federatingActor) PostInboxScheme(c context.Context, w http.ResponseWrit
 // serializing this OrderedCollection and responding with the correct
 // headers and http.StatusOK.
 func (f *federatingActor) GetInbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
-	return f.actor.GetInbox(c, w, r)
+	return f.actor.GetInbox(db.WithSubsystem(c, "federator"), w, r)
 }
 
 // PostOutbox returns true if the request was handled as an ActivityPub
@@ -146,5 +149,5 @@ func (f *federatingActor) PostOutboxScheme(c context.Context, w http.ResponseWri
 // serializing this OrderedCollection and responding with the correct
 // headers and http.StatusOK.
 func (f *federatingActor) GetOutbox(c context.Context, w http.ResponseWriter, r *http.Request) (bool, error) {
-	return f.actor.GetOutbox(c, w, r)
+	return f.actor.GetOutbox(db.WithSubsystem(c, "federator"), w, r)
 }
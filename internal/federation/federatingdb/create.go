@@ -27,7 +27,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/superseriousbusiness/activity/streams/vocab"
 	"github.com/superseriousbusiness/gotosocial/internal/ap"
-	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/internal/id"
 	"github.com/superseriousbusiness/gotosocial/internal/messages"
@@ -230,16 +229,17 @@ func (f *federatingDB) createNote(ctx context.Context, note vocab.ActivityStream
 	}
 	status.ID = statusID
 
-	if err := f.db.PutStatus(ctx, status); err != nil {
-		if err == db.ErrAlreadyExists {
-			// the status already exists in the database, which means we've already handled everything else,
-			// so we can just return nil here and be done with it.
-			return nil
-		}
-		// an actual error has happened
+	inserted, err := f.db.PutStatusIfNew(ctx, status)
+	if err != nil {
 		return fmt.Errorf("createNote: database error inserting status: %s", err)
 	}
 
+	if !inserted {
+		// the status already exists in the database, which means we've already handled everything else,
+		// so we can just return nil here and be done with it.
+		return nil
+	}
+
 	fromFederatorChan <- messages.FromFederator{
 		APObjectType:     ap.ObjectNote,
 		APActivityType:   ap.ActivityCreate,
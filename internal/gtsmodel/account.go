@@ -46,6 +46,8 @@ type Account struct {
 	Memorial                bool             `validate:"-" bun:",default:false"`                                                                                     // Is this a memorial account, ie., has the user passed away?
 	AlsoKnownAs             string           `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                                                                // This account is associated with x account id (TODO: migrate to be AlsoKnownAsID)
 	MovedToAccountID        string           `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                                                                // This account has moved this account id in the database
+	MovedToAccount          *Account         `validate:"-" bun:"rel:belongs-to"`                                                                                     // Account corresponding to movedToAccountID
+	MovedAt                 time.Time        `validate:"-" bun:"type:timestamptz,nullzero"`                                                                          // When did this account move to movedToAccountID?
 	Bot                     bool             `validate:"-" bun:",default:false"`                                                                                     // Does this account identify itself as a bot?
 	Reason                  string           `validate:"-" bun:""`                                                                                                   // What reason was given for signing up when this account was created?
 	Locked                  bool             `validate:"-" bun:",default:true"`                                                                                      // Does this account need an approval for new followers?
@@ -70,6 +72,8 @@ type Account struct {
 	SuspendedAt             time.Time        `validate:"-" bun:"type:timestamptz,nullzero"`                                                                          // When was this account suspended (eg., don't allow it to log in/post, don't accept media/posts from this account)
 	HideCollections         bool             `validate:"-" bun:",default:false"`                                                                                     // Hide this account's collections
 	SuspensionOrigin        string           `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                                                                // id of the database entry that caused this account to become suspended -- can be an account ID or a domain block ID
+	UnsuspendedAt           time.Time        `validate:"-" bun:"type:timestamptz,nullzero"`                                                                          // When was the most recent suspension of this account lifted?
+	UnsuspensionOrigin      string           `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                                                                // id of the account that lifted the most recent suspension of this account
 }
 
 // Field represents a key value field on an account, for things like pronouns, website, etc.
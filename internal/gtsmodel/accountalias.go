@@ -0,0 +1,31 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gtsmodel
+
+import "time"
+
+// AccountAlias records that one account considers another to be an alias of itself (ActivityPub alsoKnownAs),
+// eg., because they belong to the same person on different instances. An account can only be moved to
+// another account that it's an alias of, and that is an alias of it in turn.
+type AccountAlias struct {
+	ID             string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`           // id of this item in the database
+	CreatedAt      time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`    // when was item created
+	AccountID      string    `validate:"required,ulid" bun:"type:CHAR(26),unique:accountalias,notnull,nullzero"` // id of the account that lists the alias
+	AliasAccountID string    `validate:"required,ulid" bun:"type:CHAR(26),unique:accountalias,notnull,nullzero"` // id of the account that is listed as an alias
+}
@@ -22,14 +22,25 @@ import "time"
 
 // DomainBlock represents a federation block against a particular domain
 type DomainBlock struct {
-	ID                 string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`        // id of this item in the database
-	CreatedAt          time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item created
-	UpdatedAt          time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item last updated
-	Domain             string    `validate:"required,fqdn" bun:",nullzero,notnull"`                               // domain to block. Eg. 'whatever.com'
-	CreatedByAccountID string    `validate:"required,ulid" bun:"type:CHAR(26),nullzero,notnull"`                  // Account ID of the creator of this block
-	CreatedByAccount   *Account  `validate:"-" bun:"rel:belongs-to"`                                              // Account corresponding to createdByAccountID
-	PrivateComment     string    `validate:"-" bun:""`                                                            // Private comment on this block, viewable to admins
-	PublicComment      string    `validate:"-" bun:""`                                                            // Public comment on this block, viewable (optionally) by everyone
-	Obfuscate          bool      `validate:"-" bun:",default:false"`                                              // whether the domain name should appear obfuscated when displaying it publicly
-	SubscriptionID     string    `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                         // if this block was created through a subscription, what's the subscription ID?
+	ID                 string              `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`                   // id of this item in the database
+	CreatedAt          time.Time           `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`            // when was item created
+	UpdatedAt          time.Time           `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"`            // when was item last updated
+	Domain             string              `validate:"required,fqdn" bun:",nullzero,notnull"`                                          // domain to block. Eg. 'whatever.com'
+	CreatedByAccountID string              `validate:"required,ulid" bun:"type:CHAR(26),nullzero,notnull"`                             // Account ID of the creator of this block
+	CreatedByAccount   *Account            `validate:"-" bun:"rel:belongs-to"`                                                         // Account corresponding to createdByAccountID
+	PrivateComment     string              `validate:"-" bun:""`                                                                       // Private comment on this block, viewable to admins
+	PublicComment      string              `validate:"-" bun:""`                                                                       // Public comment on this block, viewable (optionally) by everyone
+	Obfuscate          bool                `validate:"-" bun:",default:false"`                                                         // whether the domain name should appear obfuscated when displaying it publicly
+	Severity           DomainBlockSeverity `validate:"omitempty,oneof=suspend reject_media" bun:",nullzero,notnull,default:'suspend'"` // how severely the domain is limited
+	SubscriptionID     string              `validate:"omitempty,ulid" bun:"type:CHAR(26),nullzero"`                                    // if this block was created through a subscription, what's the subscription ID?
 }
+
+// DomainBlockSeverity describes how severely a blocked domain is limited.
+type DomainBlockSeverity string
+
+const (
+	// DomainBlockSeveritySuspend cuts the domain off completely, media included.
+	DomainBlockSeveritySuspend DomainBlockSeverity = "suspend"
+	// DomainBlockSeverityRejectMedia federates with the domain, but doesn't download its media.
+	DomainBlockSeverityRejectMedia DomainBlockSeverity = "reject_media"
+)
@@ -40,9 +40,12 @@ type MediaAttachment struct {
 	Blurhash          string           `validate:"required_if=Type Image,required_if=Type Gif,required_if=Type Video" bun:",nullzero"` // What is the generated blurhash of this attachment
 	Processing        ProcessingStatus `validate:"oneof=0 1 2 666" bun:",notnull,default:2"`                                           // What is the processing status of this attachment
 	File              File             `validate:"required" bun:",notnull,nullzero"`                                                   // metadata for the whole file
+	FileHash          string           `validate:"omitempty,len=64,hexadecimal" bun:"type:CHAR(64),nullzero"`                          // hex-encoded sha256 of the original file in storage, for finding duplicates
 	Thumbnail         Thumbnail        `validate:"required" bun:",notnull,nullzero"`                                                   // small image thumbnail derived from a larger image, video, or audio file.
 	Avatar            bool             `validate:"-" bun:",notnull,default:false"`                                                     // Is this attachment being used as an avatar?
 	Header            bool             `validate:"-" bun:",notnull,default:false"`                                                     // Is this attachment being used as a header?
+	LastAccessedAt    time.Time        `validate:"-" bun:"type:timestamptz,nullzero"`                                                  // When was this attachment last served by this instance (only tracked for remote media)
+	PrunedAt          time.Time        `validate:"-" bun:"type:timestamptz,nullzero"`                                                  // When were the files of this remote attachment removed from storage to save space; they can be fetched again from RemoteURL
 }
 
 // File refers to the metadata for the whole file
@@ -59,7 +59,10 @@ type Status struct {
 	CreatedWithApplication   *Application       `validate:"-" bun:"rel:belongs-to"`                                                                    // application corresponding to createdWithApplicationID
 	ActivityStreamsType      string             `validate:"required" bun:",nullzero,notnull"`                                                          // What is the activitystreams type of this status? See: https://www.w3.org/TR/activitystreams-vocabulary/#object-types. Will probably almost always be Note but who knows!.
 	Text                     string             `validate:"-" bun:""`                                                                                  // Original text of the status without formatting
+	ContentKey               string             `validate:"omitempty,len=64,hexadecimal" bun:"type:CHAR(64),nullzero"`                                 // hash of the normalized text of the status, shared by statuses with near-identical content
 	Pinned                   bool               `validate:"-" bun:",notnull,default:false"`                                                            // Has this status been pinned by its owner?
+	PinnedAt                 time.Time          `validate:"-" bun:"type:timestamptz,nullzero"`                                                         // When was this status pinned by its owner?
+	ExpiresAt                time.Time          `validate:"omitempty,gtfield=CreatedAt" bun:"type:timestamptz,nullzero"`                               // When should this status be deleted? If not set, it never expires.
 	Federated                bool               `validate:"-" bun:",notnull"`                                                                          // This status will be federated beyond the local timeline(s)
 	Boostable                bool               `validate:"-" bun:",notnull"`                                                                          // This status can be boosted/reblogged
 	Replyable                bool               `validate:"-" bun:",notnull"`                                                                          // This status can be replied to
@@ -0,0 +1,34 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gtsmodel
+
+import "time"
+
+// StatusEdit represents a previous version of a status. The status itself always holds the latest
+// version: whenever it's edited, what it looked like before the edit gets stored as a StatusEdit.
+type StatusEdit struct {
+	ID             string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`        // id of this item in the database
+	CreatedAt      time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item created (ie., when was the status edited and this version replaced)
+	StatusID       string    `validate:"required,ulid" bun:"type:CHAR(26),nullzero,notnull"`                  // id of the status that this is a previous version of
+	Content        string    `validate:"-" bun:""`                                                            // content of the status at this version
+	ContentWarning string    `validate:"-" bun:",nullzero"`                                                   // cw string of the status at this version
+	Sensitive      bool      `validate:"-" bun:",notnull,default:false"`                                      // was the status marked sensitive at this version?
+	Text           string    `validate:"-" bun:""`                                                            // original text of the status at this version, without formatting
+	AttachmentIDs  []string  `validate:"dive,ulid" bun:"attachments,array"`                                   // database IDs of the media attachments the status had at this version
+}
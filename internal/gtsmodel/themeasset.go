@@ -0,0 +1,34 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package gtsmodel
+
+import "time"
+
+// ThemeAsset is a file used to customise the look of this instance, such as a stylesheet or a background image,
+// that the web UI refers to by name. The file itself is kept in storage; this is its metadata.
+type ThemeAsset struct {
+	ID          string    `validate:"required,ulid" bun:"type:CHAR(26),pk,nullzero,notnull,unique"`        // id of this item in the database
+	CreatedAt   time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item created
+	UpdatedAt   time.Time `validate:"-" bun:"type:timestamptz,nullzero,notnull,default:current_timestamp"` // when was item last updated
+	Name        string    `validate:"required" bun:",nullzero,notnull,unique"`                             // name the web UI refers to the asset by, eg. 'custom.css'
+	Path        string    `validate:"required" bun:",nullzero,notnull"`                                    // path of the current version of the file in storage
+	ContentType string    `validate:"required" bun:",nullzero,notnull"`                                    // MIME content type of the file
+	FileSize    int       `validate:"required" bun:",notnull"`                                             // file size in bytes
+	Version     int       `validate:"min=1" bun:",notnull,default:1"`                                      // starts at 1 and goes up by one every time the asset is replaced, for cache busting
+}
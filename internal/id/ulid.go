@@ -31,6 +31,16 @@ func NewULIDFromTime(t time.Time) (string, error) {
 	return newUlid.String(), nil
 }
 
+// NewMinULIDFromTime returns the lowest possible ULID string for the given time, ie., one whose random part is all
+// zeroes. Every ULID generated at or after that time sorts after it, so it's handy as a lower bound for ID ranges.
+func NewMinULIDFromTime(t time.Time) (string, error) {
+	newUlid, err := ulid.New(ulid.Timestamp(t), nil)
+	if err != nil {
+		return "", err
+	}
+	return newUlid.String(), nil
+}
+
 // NewRandomULID returns a new ULID string using a random time in an ~80 year range around the current datetime, or an error if something goes wrong.
 func NewRandomULID() (string, error) {
 	b1, err := rand.Int(rand.Reader, big.NewInt(randomRange))
@@ -0,0 +1,86 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+)
+
+// blurhashBatchSize is how many attachments to work out blurhashes for per page when backfilling.
+const blurhashBatchSize = 100
+
+func (mh *mediaHandler) BackfillBlurhashes(ctx context.Context, force bool) (int, error) {
+	l := logrus.WithField("func", "BackfillBlurhashes")
+
+	var (
+		maxID   string
+		derived int
+	)
+
+	for {
+		attachments, err := mh.db.GetMediaMissingBlurhash(ctx, force, maxID, blurhashBatchSize)
+		if err != nil {
+			if errors.Is(err, db.ErrNoEntries) {
+				return derived, nil
+			}
+			return derived, fmt.Errorf("error getting attachments: %s", err)
+		}
+
+		for _, a := range attachments {
+			if a.File.Path == "" {
+				// remote media that was never fetched, nothing to work from
+				continue
+			}
+
+			b, err := mh.storage.Get(a.File.Path)
+			if err != nil {
+				// the file might well have been cleaned up already,
+				// that's no reason to stop with everything else
+				l.Warnf("error getting file for attachment %s: %s", a.ID, err)
+				continue
+			}
+
+			// the blurhash is worked out from a thumbnail of the same size as when the
+			// attachment was first processed: avatars and headers get smaller ones
+			var size uint = 512
+			if a.Avatar || a.Header {
+				size = 256
+			}
+
+			small, err := deriveThumbnail(b, a.File.ContentType, size, size)
+			if err != nil {
+				l.Warnf("error deriving blurhash for attachment %s: %s", a.ID, err)
+				continue
+			}
+
+			if err := mh.db.SetAttachmentBlurhash(ctx, a.ID, small.blurhash); err != nil {
+				return derived, fmt.Errorf("error setting blurhash for attachment %s: %s", a.ID, err)
+			}
+			derived++
+		}
+
+		maxID = attachments[len(attachments)-1].ID
+		l.Debugf("derived %d blurhashes so far", derived)
+	}
+}
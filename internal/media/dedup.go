@@ -0,0 +1,81 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
+)
+
+// fileHashBatchSize is how many attachments to hash per page when backfilling.
+const fileHashBatchSize = 100
+
+// hashFile returns the hex-encoded sha256 of the given file, for storing as an attachment's FileHash.
+func hashFile(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (mh *mediaHandler) BackfillFileHashes(ctx context.Context) (int, error) {
+	l := logrus.WithField("func", "BackfillFileHashes")
+
+	var (
+		maxID  string
+		hashed int
+	)
+
+	for {
+		attachments, err := mh.db.GetAttachmentsWithoutFileHash(ctx, maxID, fileHashBatchSize)
+		if err != nil {
+			if errors.Is(err, db.ErrNoEntries) {
+				return hashed, nil
+			}
+			return hashed, fmt.Errorf("error getting attachments: %s", err)
+		}
+
+		for _, a := range attachments {
+			if a.File.Path == "" {
+				// remote media that was never fetched, nothing to hash
+				continue
+			}
+
+			b, err := mh.storage.Get(a.File.Path)
+			if err != nil {
+				// the file might well have been cleaned up already,
+				// that's no reason to stop hashing everything else
+				l.Warnf("error getting file for attachment %s: %s", a.ID, err)
+				continue
+			}
+
+			if err := mh.db.SetAttachmentFileHash(ctx, a.ID, hashFile(b)); err != nil {
+				return hashed, fmt.Errorf("error setting file hash for attachment %s: %s", a.ID, err)
+			}
+			hashed++
+		}
+
+		maxID = attachments[len(attachments)-1].ID
+		l.Debugf("hashed %d attachments so far", hashed)
+	}
+}
@@ -74,6 +74,14 @@ type Handler interface {
 	ProcessLocalEmoji(ctx context.Context, emojiBytes []byte, shortcode string) (*gtsmodel.Emoji, error)
 
 	ProcessRemoteHeaderOrAvatar(ctx context.Context, t transport.Transport, currentAttachment *gtsmodel.MediaAttachment, accountID string) (*gtsmodel.MediaAttachment, error)
+
+	// BackfillFileHashes reads the files of any attachments that don't have a file hash yet from storage, and sets their
+	// hash, so that duplicate files stored before hashes were recorded can be found. It returns how many were hashed.
+	BackfillFileHashes(ctx context.Context) (int, error)
+
+	// BackfillBlurhashes works out blurhashes from storage for any image and gif attachments that don't have one, eg., because
+	// generating it failed at the time. If force is true, every blurhash is worked out again. It returns how many were set.
+	BackfillBlurhashes(ctx context.Context, force bool) (int, error)
 }
 
 type mediaHandler struct {
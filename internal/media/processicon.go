@@ -127,6 +127,7 @@ func (mh *mediaHandler) processHeaderOrAvi(imageBytes []byte, contentType string
 			FileSize:    len(original.image),
 			UpdatedAt:   time.Now(),
 		},
+		FileHash: hashFile(original.image),
 		Thumbnail: gtsmodel.Thumbnail{
 			Path:        smallPath,
 			ContentType: contentType,
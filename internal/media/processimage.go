@@ -117,6 +117,7 @@ func (mh *mediaHandler) processImageAttachment(data []byte, minAttachment *gtsmo
 			FileSize:    len(original.image),
 			UpdatedAt:   time.Now(),
 		},
+		FileHash: hashFile(original.image),
 		Thumbnail: gtsmodel.Thumbnail{
 			Path:        smallPath,
 			ContentType: MIMEJpeg, // all thumbnails/smalls are encoded as jpeg
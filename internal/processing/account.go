@@ -54,6 +54,10 @@ func (p *processor) AccountRelationshipGet(ctx context.Context, authed *oauth.Au
 	return p.accountProcessor.RelationshipGet(ctx, authed.Account, targetAccountID)
 }
 
+func (p *processor) AccountRelationshipsGet(ctx context.Context, authed *oauth.Auth, targetAccountIDs []string) ([]apimodel.Relationship, gtserror.WithCode) {
+	return p.accountProcessor.RelationshipsGet(ctx, authed.Account, targetAccountIDs)
+}
+
 func (p *processor) AccountFollowCreate(ctx context.Context, authed *oauth.Auth, form *apimodel.AccountFollowRequest) (*apimodel.Relationship, gtserror.WithCode) {
 	return p.accountProcessor.FollowCreate(ctx, authed.Account, form)
 }
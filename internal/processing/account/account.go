@@ -56,6 +56,8 @@ type Processor interface {
 	FollowingGet(ctx context.Context, requestingAccount *gtsmodel.Account, targetAccountID string) ([]apimodel.Account, gtserror.WithCode)
 	// RelationshipGet returns a relationship model describing the relationship of the targetAccount to the Authed account.
 	RelationshipGet(ctx context.Context, requestingAccount *gtsmodel.Account, targetAccountID string) (*apimodel.Relationship, gtserror.WithCode)
+	// RelationshipsGet is like RelationshipGet, but for several target accounts at once.
+	RelationshipsGet(ctx context.Context, requestingAccount *gtsmodel.Account, targetAccountIDs []string) ([]apimodel.Relationship, gtserror.WithCode)
 	// FollowCreate handles a follow request to an account, either remote or local.
 	FollowCreate(ctx context.Context, requestingAccount *gtsmodel.Account, form *apimodel.AccountFollowRequest) (*apimodel.Relationship, gtserror.WithCode)
 	// FollowRemove handles the removal of a follow/follow request to an account, either remote or local.
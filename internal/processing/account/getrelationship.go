@@ -45,3 +45,25 @@ func (p *processor) RelationshipGet(ctx context.Context, requestingAccount *gtsm
 
 	return r, nil
 }
+
+func (p *processor) RelationshipsGet(ctx context.Context, requestingAccount *gtsmodel.Account, targetAccountIDs []string) ([]apimodel.Relationship, gtserror.WithCode) {
+	if requestingAccount == nil {
+		return nil, gtserror.NewErrorForbidden(errors.New("not authed"))
+	}
+
+	gtsRs, err := p.db.GetAccountRelationships(ctx, requestingAccount.ID, targetAccountIDs)
+	if err != nil {
+		return nil, gtserror.NewErrorInternalError(fmt.Errorf("error getting relationships: %s", err))
+	}
+
+	rs := make([]apimodel.Relationship, 0, len(gtsRs))
+	for _, gtsR := range gtsRs {
+		r, err := p.tc.RelationshipToAPIRelationship(ctx, gtsR)
+		if err != nil {
+			return nil, gtserror.NewErrorInternalError(fmt.Errorf("error converting relationship: %s", err))
+		}
+		rs = append(rs, *r)
+	}
+
+	return rs, nil
+}
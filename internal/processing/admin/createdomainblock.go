@@ -61,8 +61,8 @@ func (p *processor) DomainBlockCreate(ctx context.Context, account *gtsmodel.Acc
 			SubscriptionID:     subscriptionID,
 		}
 
-		// put the new block in the database
-		if err := p.db.Put(ctx, domainBlock); err != nil {
+		// put the new block in the database, along with its audit log entry
+		if err := p.db.PutDomainBlock(ctx, domainBlock); err != nil {
 			if err != db.ErrNoEntries {
 				// there's a real error creating the block
 				return nil, gtserror.NewErrorInternalError(fmt.Errorf("DomainBlockCreate: db error putting new domain block %s: %s", domain, err))
@@ -47,8 +47,8 @@ func (p *processor) DomainBlockDelete(ctx context.Context, account *gtsmodel.Acc
 		return nil, gtserror.NewErrorInternalError(err)
 	}
 
-	// delete the domain block
-	if err := p.db.DeleteByID(ctx, id, domainBlock); err != nil {
+	// delete the domain block, along with its audit log entry
+	if err := p.db.DeleteDomainBlock(ctx, domainBlock, account.ID); err != nil {
 		return nil, gtserror.NewErrorInternalError(err)
 	}
 
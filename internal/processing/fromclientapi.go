@@ -298,7 +298,7 @@ func (p *processor) processDeleteStatusFromClientAPI(ctx context.Context, client
 	}
 
 	// delete all notifications for this status
-	if err := p.db.DeleteWhere(ctx, []db.Where{{Key: "status_id", Value: statusToDelete.ID}}, &[]*gtsmodel.Notification{}); err != nil {
+	if err := p.db.DeleteNotificationsForStatus(ctx, statusToDelete.ID); err != nil {
 		return err
 	}
 
@@ -313,14 +313,33 @@ func (p *processor) processDeleteStatusFromClientAPI(ctx context.Context, client
 func (p *processor) processDeleteAccountFromClientAPI(ctx context.Context, clientMsg messages.FromClientAPI) error {
 	// the origin of the delete could be either a domain block, or an action by another (or this) account
 	var origin string
+	var suspendedByModerator bool
 	if domainBlock, ok := clientMsg.GTSModel.(*gtsmodel.DomainBlock); ok {
 		// origin is a domain block
 		origin = domainBlock.ID
 	} else {
 		// origin is whichever account caused this message
 		origin = clientMsg.OriginAccount.ID
+		suspendedByModerator = origin != clientMsg.TargetAccount.ID
 	}
-	return p.accountProcessor.Delete(ctx, clientMsg.TargetAccount, origin)
+
+	if err := p.accountProcessor.Delete(ctx, clientMsg.TargetAccount, origin); err != nil {
+		return err
+	}
+
+	// an account deleted by someone other than its owner was suspended by a moderator,
+	// which belongs in the audit log; domain blocks are logged when they're created
+	if suspendedByModerator {
+		if err := p.db.PutAdminAction(ctx, &gtsmodel.AdminAction{
+			AccountID:       origin,
+			Type:            gtsmodel.AdminActionSuspend,
+			TargetAccountID: clientMsg.TargetAccount.ID,
+		}); err != nil {
+			return fmt.Errorf("processDeleteAccountFromClientAPI: error recording suspension of %s: %s", clientMsg.TargetAccount.ID, err)
+		}
+	}
+
+	return nil
 }
 
 // TODO: move all the below functions into federation.Federator
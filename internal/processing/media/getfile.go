@@ -22,13 +22,19 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	apimodel "github.com/superseriousbusiness/gotosocial/internal/api/model"
 	"github.com/superseriousbusiness/gotosocial/internal/gtserror"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 	"github.com/superseriousbusiness/gotosocial/internal/media"
 )
 
+// lastAccessedGranularity is how stale the last access time of remote media is allowed
+// to get before serving it updates it, so that popular media doesn't mean a write per request.
+const lastAccessedGranularity = time.Hour
+
 func (p *processor) GetFile(ctx context.Context, account *gtsmodel.Account, form *apimodel.GetContentRequestForm) (*apimodel.Content, error) {
 	// parse the form fields
 	mediaSize, err := media.ParseMediaSize(form.MediaSize)
@@ -98,6 +104,13 @@ func (p *processor) GetFile(ctx context.Context, account *gtsmodel.Account, form
 		if a.AccountID != form.AccountID {
 			return nil, gtserror.NewErrorNotFound(fmt.Errorf("attachment %s is not owned by %s", wantedMediaID, form.AccountID))
 		}
+		if a.RemoteURL != "" && time.Since(a.LastAccessedAt) > lastAccessedGranularity {
+			// remote media that isn't being served gets pruned
+			// eventually, so keep track of when it last was
+			if err := p.db.SetAttachmentAccessedAt(ctx, a.ID, time.Now()); err != nil {
+				logrus.Warnf("GetFile: error updating last access of attachment %s: %s", a.ID, err)
+			}
+		}
 		switch mediaSize {
 		case media.SizeOriginal:
 			content.ContentType = a.File.ContentType
@@ -86,6 +86,8 @@ type Processor interface {
 	AccountFollowingGet(ctx context.Context, authed *oauth.Auth, targetAccountID string) ([]apimodel.Account, gtserror.WithCode)
 	// AccountRelationshipGet returns a relationship model describing the relationship of the targetAccount to the Authed account.
 	AccountRelationshipGet(ctx context.Context, authed *oauth.Auth, targetAccountID string) (*apimodel.Relationship, gtserror.WithCode)
+	// AccountRelationshipsGet returns relationship models describing the relationships of the target accounts to the Authed account.
+	AccountRelationshipsGet(ctx context.Context, authed *oauth.Auth, targetAccountIDs []string) ([]apimodel.Relationship, gtserror.WithCode)
 	// AccountFollowCreate handles a follow request to an account, either remote or local.
 	AccountFollowCreate(ctx context.Context, authed *oauth.Auth, form *apimodel.AccountFollowRequest) (*apimodel.Relationship, gtserror.WithCode)
 	// AccountFollowRemove handles the removal of a follow/follow request to an account, either remote or local.
@@ -300,6 +302,10 @@ func NewProcessor(
 
 // Start starts the Processor, reading from its channels and passing messages back and forth.
 func (p *processor) Start(ctx context.Context) error {
+	// queries run while handling messages are background work,
+	// not part of answering whoever sent the message
+	ctx = db.WithSubsystem(ctx, "worker")
+
 	go func() {
 	DistLoop:
 		for {
@@ -25,6 +25,7 @@ import (
 	"sort"
 
 	apimodel "github.com/superseriousbusiness/gotosocial/internal/api/model"
+	"github.com/superseriousbusiness/gotosocial/internal/db"
 	"github.com/superseriousbusiness/gotosocial/internal/gtserror"
 	"github.com/superseriousbusiness/gotosocial/internal/gtsmodel"
 )
@@ -46,6 +47,13 @@ func (p *processor) Context(ctx context.Context, requestingAccount *gtsmodel.Acc
 		return nil, gtserror.NewErrorNotFound(errors.New("status is not visible"))
 	}
 
+	// let the database leave out what requestingAccount can't see before we check each status properly
+	viewer := db.ViewerContext{}
+	if requestingAccount != nil {
+		viewer.AccountID = requestingAccount.ID
+	}
+	ctx = db.WithViewer(ctx, viewer)
+
 	context := &apimodel.Context{
 		Ancestors:   []apimodel.Status{},
 		Descendants: []apimodel.Status{},
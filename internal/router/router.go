@@ -122,6 +122,7 @@ func New(ctx context.Context, db db.DB) (Router, error) {
 
 	engine.Use(gin.RecoveryWithWriter(logrus.StandardLogger().Writer()))
 	engine.Use(loggingMiddleware())
+	engine.Use(subsystemMiddleware())
 
 	// 8 MiB
 	engine.MaxMultipartMemory = 8 << 20
@@ -0,0 +1,79 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package storage
+
+import (
+	"errors"
+	"io"
+
+	"codeberg.org/gruf/go-store/storage"
+)
+
+// ErrObjectTooLarge is returned when trying to write an object bigger than the maximum object size.
+var ErrObjectTooLarge = errors.New("storage: object is larger than the maximum object size")
+
+// limited wraps a storage.Storage, refusing to write any object bigger than maxSize bytes.
+type limited struct {
+	storage.Storage
+	maxSize int64
+}
+
+// NewLimited wraps the given storage so that writes of objects bigger than maxSize bytes fail
+// with ErrObjectTooLarge, and nothing is stored for them. If maxSize is 0 or less, the given
+// storage is returned as-is.
+func NewLimited(s storage.Storage, maxSize int64) storage.Storage {
+	if maxSize <= 0 {
+		return s
+	}
+	return &limited{Storage: s, maxSize: maxSize}
+}
+
+func (l *limited) WriteBytes(key string, value []byte) error {
+	if int64(len(value)) > l.maxSize {
+		return ErrObjectTooLarge
+	}
+	return l.Storage.WriteBytes(key, value)
+}
+
+func (l *limited) WriteStream(key string, r io.Reader) error {
+	// count bytes as they go past rather than reading the whole
+	// thing into memory first, since that's what we're avoiding
+	err := l.Storage.WriteStream(key, &limitedReader{r: r, remaining: l.maxSize})
+	if errors.Is(err, ErrObjectTooLarge) {
+		// don't leave half an object lying around
+		_ = l.Storage.Remove(key)
+	}
+	return err
+}
+
+// limitedReader reads from r, failing with ErrObjectTooLarge
+// once more than the remaining number of bytes has been read.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.remaining -= int64(n)
+	if lr.remaining < 0 {
+		return n, ErrObjectTooLarge
+	}
+	return n, err
+}
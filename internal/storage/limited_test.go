@@ -0,0 +1,83 @@
+/*
+   GoToSocial
+   Copyright (C) 2021-2022 GoToSocial Authors admin@gotosocial.org
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU Affero General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU Affero General Public License for more details.
+
+   You should have received a copy of the GNU Affero General Public License
+   along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package storage_test
+
+import (
+	"bytes"
+	"testing"
+
+	"codeberg.org/gruf/go-store/storage"
+	"github.com/stretchr/testify/suite"
+	gtsstorage "github.com/superseriousbusiness/gotosocial/internal/storage"
+)
+
+type LimitedTestSuite struct {
+	suite.Suite
+	storage storage.Storage
+}
+
+func (suite *LimitedTestSuite) SetupTest() {
+	disk, err := storage.OpenFile(suite.T().TempDir(), nil)
+	if err != nil {
+		suite.FailNow(err.Error())
+	}
+	suite.storage = gtsstorage.NewLimited(disk, 1024)
+}
+
+func (suite *LimitedTestSuite) TestWriteBytes() {
+	err := suite.storage.WriteBytes("small", make([]byte, 1024))
+	suite.NoError(err)
+
+	err = suite.storage.WriteBytes("big", make([]byte, 1025))
+	suite.ErrorIs(err, gtsstorage.ErrObjectTooLarge)
+
+	has, err := suite.storage.Stat("big")
+	suite.NoError(err)
+	suite.False(has)
+}
+
+func (suite *LimitedTestSuite) TestWriteStream() {
+	err := suite.storage.WriteStream("small", bytes.NewReader(make([]byte, 1024)))
+	suite.NoError(err)
+
+	b, err := suite.storage.ReadBytes("small")
+	suite.NoError(err)
+	suite.Len(b, 1024)
+
+	err = suite.storage.WriteStream("big", bytes.NewReader(make([]byte, 1<<20)))
+	suite.ErrorIs(err, gtsstorage.ErrObjectTooLarge)
+
+	// nothing should have been left behind
+	has, err := suite.storage.Stat("big")
+	suite.NoError(err)
+	suite.False(has)
+}
+
+func (suite *LimitedTestSuite) TestNoLimit() {
+	disk, err := storage.OpenFile(suite.T().TempDir(), nil)
+	suite.NoError(err)
+
+	s := gtsstorage.NewLimited(disk, 0)
+	err = s.WriteStream("big", bytes.NewReader(make([]byte, 1<<20)))
+	suite.NoError(err)
+}
+
+func TestLimitedTestSuite(t *testing.T) {
+	suite.Run(t, new(LimitedTestSuite))
+}
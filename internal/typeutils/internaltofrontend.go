@@ -389,16 +389,15 @@ func (c *converter) StatusToAPIStatus(ctx context.Context, s *gtsmodel.Status, r
 		}
 		// the status doesn't have gts attachments on it, but it does have attachment IDs
 		// in this case, we need to pull the gts attachments from the db to convert them into api ones
-	} else {
-		for _, aID := range s.AttachmentIDs {
-			gtsAttachment, err := c.db.GetAttachmentByID(ctx, aID)
-			if err != nil {
-				logrus.Errorf("error getting attachment with id %s: %s", aID, err)
-				continue
-			}
+	} else if len(s.AttachmentIDs) != 0 {
+		gtsAttachments, err := c.db.GetMediaByIDs(ctx, s.AttachmentIDs)
+		if err != nil {
+			logrus.Errorf("error getting attachments of status %s: %s", s.ID, err)
+		}
+		for _, gtsAttachment := range gtsAttachments {
 			apiAttachment, err := c.AttachmentToAPIAttachment(ctx, gtsAttachment)
 			if err != nil {
-				logrus.Errorf("error converting attachment with id %s: %s", aID, err)
+				logrus.Errorf("error converting attachment with id %s: %s", gtsAttachment.ID, err)
 				continue
 			}
 			apiAttachments = append(apiAttachments, apiAttachment)
@@ -573,19 +572,11 @@ func (c *converter) InstanceToAPIInstance(ctx context.Context, i *gtsmodel.Insta
 	keys := config.Keys
 	host := viper.GetString(keys.Host)
 	if i.Domain == host {
-		userCount, err := c.db.CountInstanceUsers(ctx, host)
-		if err == nil {
-			mi.Stats["user_count"] = userCount
-		}
-
-		statusCount, err := c.db.CountInstanceStatuses(ctx, host)
-		if err == nil {
-			mi.Stats["status_count"] = statusCount
-		}
-
-		domainCount, err := c.db.CountInstanceDomains(ctx, host)
+		stats, err := c.db.GetLocalInstanceStats(ctx)
 		if err == nil {
-			mi.Stats["domain_count"] = domainCount
+			mi.Stats["user_count"] = stats.UserCount
+			mi.Stats["status_count"] = stats.StatusCount
+			mi.Stats["domain_count"] = stats.DomainCount
 		}
 
 		mi.Registrations = viper.GetBool(keys.AccountsRegistrationOpen)
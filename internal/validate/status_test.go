@@ -156,6 +156,18 @@ func (suite *StatusValidateTestSuite) TestValidateStatusReplyFields() {
 	suite.NoError(err)
 }
 
+func (suite *StatusValidateTestSuite) TestValidateStatusExpiresAt() {
+	s := happyStatus()
+
+	s.ExpiresAt = s.CreatedAt
+	err := validate.Struct(s)
+	suite.EqualError(err, "Key: 'Status.ExpiresAt' Error:Field validation for 'ExpiresAt' failed on the 'gtfield' tag")
+
+	s.ExpiresAt = s.CreatedAt.Add(time.Hour)
+	err = validate.Struct(s)
+	suite.NoError(err)
+}
+
 func TestStatusValidateTestSuite(t *testing.T) {
 	suite.Run(t, new(StatusValidateTestSuite))
 }
@@ -20,6 +20,7 @@ package testrig
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/spf13/viper"
@@ -61,6 +62,7 @@ var TestDefaults = config.Values{
 	LogLevel:        "trace",
 	ApplicationName: "gotosocial",
 	ConfigPath:      "",
+	DumpConfig:      false,
 	Host:            "localhost:8080",
 	AccountDomain:   "localhost:8080",
 	Protocol:        "http",
@@ -68,12 +70,36 @@ var TestDefaults = config.Values{
 	Port:            8080,
 	TrustedProxies:  []string{"127.0.0.1/32"},
 
-	DbType:     "sqlite",
-	DbAddress:  ":memory:",
-	DbPort:     5432,
-	DbUser:     "postgres",
-	DbPassword: "postgres",
-	DbDatabase: "postgres",
+	DbType:                      "sqlite",
+	DbAddress:                   ":memory:",
+	DbPort:                      5432,
+	DbUser:                      "postgres",
+	DbPassword:                  "postgres",
+	DbDatabase:                  "postgres",
+	DbMigrationAnalyze:          true,
+	DbReadOnly:                  false,
+	DbOpenReadOnly:              false,
+	DbMigrationTimeout:          0,
+	DbMigrateOnStart:            true,
+	DbMigrationVerifyChecksums:  true,
+	DbMigrationSkipIncompatible: false,
+	DbAllowNoPassword:           false,
+	DbSqliteBusyTimeout:         5 * time.Second,
+	DbSqliteCache:               "private",
+	DbSqliteForeignKeys:         true,
+	DbTimezone:                  "UTC",
+	DbPostgresFlavor:            "postgres",
+	DbPostgresSchema:            "",
+	DbPoolSampleInterval:        0,
+	DbPoolWaitThreshold:         time.Second,
+	DbQueryLogSampleRate:        1,
+	DbKeepaliveInterval:         time.Minute,
+	DbDialTimeout:               10 * time.Second,
+	DbWarmup:                    false,
+	DbSqlCommenterEnabled:       false,
+	DbLogConnections:            false,
+	DbSqliteBackupPath:          "",
+	DbSqliteBackupInterval:      0,
 
 	WebTemplateBaseDir: "./web/template/",
 	WebAssetBaseDir:    "./web/assets/",
@@ -89,6 +115,7 @@ var TestDefaults = config.Values{
 
 	StorageBackend:       "local",
 	StorageLocalBasePath: "/gotosocial/storage",
+	StorageMaxObjectSize: 41943040,
 
 	StatusesMaxChars:           5000,
 	StatusesCWMaxChars:         100,
@@ -47,6 +47,13 @@ var testModels = []interface{}{
 	&gtsmodel.StatusFave{},
 	&gtsmodel.StatusBookmark{},
 	&gtsmodel.StatusMute{},
+	&gtsmodel.StatusEdit{},
+	&gtsmodel.Announcement{},
+	&gtsmodel.AnnouncementRead{},
+	&gtsmodel.FeatureFlag{},
+	&gtsmodel.ThemeAsset{},
+	&gtsmodel.AccountAlias{},
+	&gtsmodel.AdminAction{},
 	&gtsmodel.Tag{},
 	&gtsmodel.User{},
 	&gtsmodel.Emoji{},